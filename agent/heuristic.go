@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"math/rand"
+
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/state/query"
+)
+
+const (
+	dodgeDistance = 3
+
+	// Don't move more than a person would.
+	minMoveInterval    = 20
+	moveIntervalJitter = 20
+
+	// chipPatience keeps a chip that can't reach from holding up the rest.
+	chipPatience = 300
+)
+
+// Heuristic dodges shots, lines up with its opponent and uses its chips when
+// in range. It ends the turn once it has used them all.
+type Heuristic struct {
+	rand *rand.Rand
+
+	ticks        int
+	nextMoveTick int
+
+	numChips      int
+	chipHeldSince int
+}
+
+func NewHeuristic(seed int64) *Heuristic {
+	return &Heuristic{rand: rand.New(rand.NewSource(seed))}
+}
+
+// findOpponent skips entities without HP, i.e. attacks.
+func findOpponent(s *state.State, e *state.Entity) *state.Entity {
+	var opponent *state.Entity
+	bestDist := 0
+	for _, cand := range s.OrderedEntities() {
+		if cand.IsAlliedWithAnswerer == e.IsAlliedWithAnswerer || cand.MaxHP == 0 || cand.IsDead {
+			continue
+		}
+		if d := query.HorizontalDistance(e.TilePos, cand.TilePos); opponent == nil || d < bestDist {
+			opponent = cand
+			bestDist = d
+		}
+	}
+	return opponent
+}
+
+func sameRowDistance(src state.TilePos, dest state.TilePos) int {
+	_, y1 := src.XY()
+	_, y2 := dest.XY()
+	if y1 != y2 {
+		return -1
+	}
+	return query.HorizontalDistance(src, dest)
+}
+
+func isLinedUp(e *state.Entity, opponent *state.Entity) bool {
+	x1, y1 := e.TilePos.XY()
+	x2, y2 := opponent.TilePos.XY()
+	return y1 == y2 && query.IsInFrontOf(x1, x2, e.IsFlipped)
+}
+
+func chipInRange(s *state.State, e *state.Entity, opponent *state.Entity, chip *state.Chip) bool {
+	switch b := chip.MakeBehavior(state.Damage{}).(type) {
+	case *behaviors.Sword:
+		for _, pos := range behaviors.SwordTargetPositions(s, e, b.Range) {
+			if pos == opponent.TilePos {
+				return true
+			}
+		}
+		return false
+	case *behaviors.Recov:
+		// Don't waste any of it.
+		return e.HP+b.HP <= e.MaxHP
+	}
+	return isLinedUp(e, opponent)
+}
+
+func (h *Heuristic) tryMove(s *state.State, e *state.Entity, dirs ...state.Direction) state.Direction {
+	x, y := e.TilePos.XY()
+	for _, dir := range dirs {
+		dx, dy := dir.XY()
+		if e.CanMoveTo(state.TilePosXY(x+dx, y+dy), s) {
+			return dir
+		}
+	}
+	return state.DirectionNone
+}
+
+func (h *Heuristic) dodge(s *state.State, e *state.Entity) state.Direction {
+	threatID, dist := query.FindNearestEntity(s, e.ID(), e.TilePos, e.IsAlliedWithAnswerer, e.IsFlipped, sameRowDistance)
+	if dist > dodgeDistance {
+		return state.DirectionNone
+	}
+	threat, ok := s.Entities[threatID]
+	if !ok || !state.BehaviorIs[*behaviors.Shot](threat.BehaviorState.Behavior) {
+		return state.DirectionNone
+	}
+	if h.rand.Intn(2) == 0 {
+		return h.tryMove(s, e, state.DirectionUp, state.DirectionDown)
+	}
+	return h.tryMove(s, e, state.DirectionDown, state.DirectionUp)
+}
+
+func (h *Heuristic) approach(s *state.State, e *state.Entity, opponent *state.Entity) state.Direction {
+	_, y1 := e.TilePos.XY()
+	_, y2 := opponent.TilePos.XY()
+	if y1 < y2 {
+		return h.tryMove(s, e, state.DirectionDown)
+	}
+	if y1 > y2 {
+		return h.tryMove(s, e, state.DirectionUp)
+	}
+
+	// Swords need to be up close, everything else can hang back.
+	forward := e.Facing()
+	if len(e.Chips) > 0 {
+		if _, ok := e.Chips[len(e.Chips)-1].MakeBehavior(state.Damage{}).(*behaviors.Sword); ok {
+			return h.tryMove(s, e, forward)
+		}
+	}
+	if h.rand.Intn(4) == 0 {
+		return h.tryMove(s, e, forward.FlipH())
+	}
+	return state.DirectionNone
+}
+
+func (h *Heuristic) pickChips(e *state.Entity) state.Intent {
+	var intent state.Intent
+
+	// The custom screen goes by rising edges, so let go in between.
+	if e.LastIntent != intent {
+		return intent
+	}
+
+	cs := &e.Custom
+	switch {
+	case cs.CanSelect(cs.Cursor):
+		intent.UseChip = true
+	case cs.Cursor < len(cs.Hand) && len(cs.Selected) < state.MaxSelectedChips:
+		intent.Direction = e.Facing()
+	default:
+		intent.Confirm = true
+	}
+	return intent
+}
+
+func (h *Heuristic) Intent(s *state.State, self state.EntityID) state.Intent {
+	h.ticks++
+
+	var intent state.Intent
+
+	e, ok := s.Entities[self]
+	if !ok || e.IsDead {
+		return intent
+	}
+
+	if s.Match != nil && s.Match.Phase == state.MatchPhaseCustom {
+		return h.pickChips(e)
+	}
+
+	if s.Match != nil && s.Match.IsCustomGaugeFull() && len(e.Chips) == 0 && !e.LastIntent.EndTurn {
+		intent.EndTurn = true
+		return intent
+	}
+
+	// Always be charging, and only let go once fully charged and lined up.
+	intent.ChargeBasicWeapon = true
+
+	opponent := findOpponent(s, e)
+	if opponent == nil {
+		return intent
+	}
+
+	idle, ok := e.BehaviorState.Behavior.(*behaviors.Idle)
+	if !ok {
+		return intent
+	}
+
+	if idle.ChargingElapsedTime >= e.PowerShotChargeTime && isLinedUp(e, opponent) {
+		intent.ChargeBasicWeapon = false
+	}
+
+	if dir := h.dodge(s, e); dir != state.DirectionNone {
+		intent.Direction = dir
+		return intent
+	}
+
+	if len(e.Chips) != h.numChips {
+		h.numChips = len(e.Chips)
+		h.chipHeldSince = h.ticks
+	}
+
+	// Chips are only used when the button goes down.
+	if len(e.Chips) > 0 && e.ChipUseLockoutTimeLeft == 0 && !e.LastIntent.UseChip && (chipInRange(s, e, opponent, e.Chips[len(e.Chips)-1]) || h.ticks-h.chipHeldSince >= chipPatience) {
+		intent.UseChip = true
+		return intent
+	}
+
+	if h.ticks >= h.nextMoveTick {
+		intent.Direction = h.approach(s, e, opponent)
+		if intent.Direction != state.DirectionNone {
+			h.nextMoveTick = h.ticks + minMoveInterval + h.rand.Intn(moveIntervalJitter)
+		}
+	}
+
+	return intent
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+)
+
+var testData = gamedata.NewData()
+
+var testFolder = state.Folder{{Chip: chips.Recov200, Code: 'C'}, {Chip: chips.Cannon, Code: 'C'}, {Chip: chips.WideSwrd, Code: 'C'}, {Chip: chips.Vulcan1, Code: 'C'}}
+
+func newTestState() (*state.State, state.EntityID, state.EntityID) {
+	return match.NewState([]byte("agent"), 1, testFolder, testFolder)
+}
+
+func runMatch(ticks int, onTick func(s *state.State)) (*state.State, state.EntityID, state.EntityID) {
+	s, offererID, answererID := newTestState()
+	offerer := NewHeuristic(1)
+	answerer := NewHeuristic(2)
+	for i := 0; i < ticks; i++ {
+		offererIntent := offerer.Intent(s, offererID)
+		answererIntent := answerer.Intent(s, answererID)
+		s.Entities[offererID].Intent = offererIntent
+		s.Entities[answererID].Intent = answererIntent
+		step.Step(s, testData)
+		if onTick != nil {
+			onTick(s)
+		}
+	}
+	return s, offererID, answererID
+}
+
+func TestHeuristicFights(t *testing.T) {
+	// HP can be recovered, and chips are lost at the custom screen.
+	tookDamage := map[state.EntityID]bool{}
+	usedChip := map[state.EntityID]bool{}
+	numChips := map[state.EntityID]int{}
+	_, offererID, answererID := runMatch(1200, func(s *state.State) {
+		for _, e := range s.Entities {
+			if e.MaxHP > 0 && e.HP < e.MaxHP {
+				tookDamage[e.ID()] = true
+			}
+			if s.Match.Phase == state.MatchPhaseBattle && len(e.Chips) < numChips[e.ID()] {
+				usedChip[e.ID()] = true
+			}
+			numChips[e.ID()] = len(e.Chips)
+		}
+	})
+
+	for _, id := range []state.EntityID{offererID, answererID} {
+		if !tookDamage[id] {
+			t.Errorf("entity %d took no damage", id)
+		}
+		if !usedChip[id] {
+			t.Errorf("entity %d used no chips", id)
+		}
+	}
+}
+
+func TestHeuristicIsReproducible(t *testing.T) {
+	s1, _, _ := runMatch(600, nil)
+	s2, _, _ := runMatch(600, nil)
+	if got, want := s1.Checksum(), s2.Checksum(); got != want {
+		t.Errorf("checksums differ: %016x != %016x", got, want)
+	}
+}
+
+func TestHeuristicDodgesShots(t *testing.T) {
+	s, offererID, answererID := newTestState()
+	answerer := s.Entities[answererID]
+	s.AttachEntity(behaviors.MakeShotEntity(answerer, state.TilePosXY(4, 2), &behaviors.Shot{}))
+
+	intent := NewHeuristic(1).Intent(s, offererID)
+	if intent.Direction != state.DirectionUp && intent.Direction != state.DirectionDown {
+		t.Errorf("expected to dodge up or down, got direction %v", intent.Direction)
+	}
+}
@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/murkland/nbarena/state"
+)
+
+type Agent interface {
+	// Intent must not modify the state.
+	Intent(s *state.State, self state.EntityID) state.Intent
+}
+
+type Func func(s *state.State, self state.EntityID) state.Intent
+
+func (f Func) Intent(s *state.State, self state.EntityID) state.Intent {
+	return f(s, self)
+}
+
+var Names = []string{"heuristic", "idle"}
+
+// Idle only confirms the custom screen, so that the match goes on.
+var Idle = Func(func(s *state.State, self state.EntityID) state.Intent {
+	e, ok := s.Entities[self]
+	if !ok || s.Match == nil || s.Match.Phase != state.MatchPhaseCustom || e.LastIntent.Confirm {
+		return state.Intent{}
+	}
+	return state.Intent{Confirm: true}
+})
+
+// New seeds agents that make random choices, so matches can be reproduced.
+func New(name string, seed int64) (Agent, error) {
+	switch name {
+	case "heuristic":
+		return NewHeuristic(seed), nil
+	case "idle":
+		return Idle, nil
+	}
+	return nil, fmt.Errorf("unknown agent %q, must be one of %v", name, Names)
+}
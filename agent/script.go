@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"errors"
+	"io"
+
+	"github.com/murkland/nbarena/replay"
+	"github.com/murkland/nbarena/state"
+)
+
+// Script starts over once it runs out of intents.
+type Script struct {
+	intents []state.Intent
+	next    int
+}
+
+func NewScript(intents []state.Intent) *Script {
+	return &Script{intents: intents}
+}
+
+func ReadScript(r io.Reader, answerer bool) ([]state.Intent, error) {
+	rp, err := replay.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rp.Intents) == 0 {
+		return nil, errors.New("replay has no intents")
+	}
+
+	intents := make([]state.Intent, len(rp.Intents))
+	for i, pair := range rp.Intents {
+		if answerer {
+			intents[i] = pair.Answerer
+		} else {
+			intents[i] = pair.Offerer
+		}
+	}
+	return intents, nil
+}
+
+func (a *Script) Intent(s *state.State, self state.EntityID) state.Intent {
+	if len(a.intents) == 0 {
+		return state.Intent{}
+	}
+	intent := a.intents[a.next]
+	a.next = (a.next + 1) % len(a.intents)
+	return intent
+}
@@ -0,0 +1,224 @@
+package batch
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"runtime"
+	"sync"
+
+	"github.com/murkland/nbarena/agent"
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+)
+
+const BusterName = "Buster"
+
+type Options struct {
+	Data *gamedata.Data
+
+	OffererFolder  state.Folder
+	AnswererFolder state.Folder
+
+	MaxTicks int
+}
+
+type ChipStats struct {
+	Uses   int
+	Damage int
+}
+
+func (cs *ChipStats) add(cs2 *ChipStats) {
+	cs.Uses += cs2.Uses
+	cs.Damage += cs2.Damage
+}
+
+type Result struct {
+	Winner match.Side
+	Ticks  int
+
+	// Chips are keyed by chip name, or BusterName.
+	Chips map[string]*ChipStats
+}
+
+// damageTracker attributes damage to whatever the attacker most recently
+// attacked with, even if an older attack is still in flight.
+type damageTracker struct {
+	attackerID state.EntityID
+	defenderID state.EntityID
+
+	source       string
+	lastBehavior state.EntityBehavior
+	lastNumChips int
+	lastTopChip  *state.Chip
+	lastHP       int
+}
+
+func newDamageTracker(s *state.State, attackerID state.EntityID, defenderID state.EntityID) *damageTracker {
+	dt := &damageTracker{
+		attackerID: attackerID,
+		defenderID: defenderID,
+		source:     BusterName,
+	}
+	dt.observe(s)
+	return dt
+}
+
+func (dt *damageTracker) observe(s *state.State) {
+	attacker := s.Entities[dt.attackerID]
+	dt.lastBehavior = attacker.BehaviorState.Behavior
+	dt.lastNumChips = len(attacker.Chips)
+	dt.lastTopChip = nil
+	if len(attacker.Chips) > 0 {
+		dt.lastTopChip = attacker.Chips[len(attacker.Chips)-1]
+	}
+	dt.lastHP = s.Entities[dt.defenderID].HP
+}
+
+func (dt *damageTracker) update(s *state.State, chips map[string]*ChipStats) {
+	stats := func(name string) *ChipStats {
+		cs, ok := chips[name]
+		if !ok {
+			cs = &ChipStats{}
+			chips[name] = cs
+		}
+		return cs
+	}
+
+	attacker := s.Entities[dt.attackerID]
+	// Chips left over when the custom screen opens are lost, not used.
+	if len(attacker.Chips) < dt.lastNumChips && dt.lastTopChip != nil && s.Match.Phase != state.MatchPhaseCustom {
+		dt.source = dt.lastTopChip.Name
+		stats(dt.source).Uses++
+	} else if attacker.BehaviorState.Behavior != dt.lastBehavior && state.BehaviorIs[*behaviors.Buster](attacker.BehaviorState.Behavior) {
+		dt.source = BusterName
+		stats(dt.source).Uses++
+	}
+
+	if damage := dt.lastHP - s.Entities[dt.defenderID].HP; damage > 0 {
+		stats(dt.source).Damage += damage
+	}
+
+	dt.observe(s)
+}
+
+// RunMatch runs a single round, until either side runs out of HP or
+// opts.MaxTicks is reached.
+func RunMatch(randSeed []byte, offerer agent.Agent, answerer agent.Agent, opts Options) Result {
+	s, offererEntityID, answererEntityID := match.NewState(randSeed, 1, opts.OffererFolder, opts.AnswererFolder)
+
+	result := Result{Chips: map[string]*ChipStats{}}
+	trackers := []*damageTracker{
+		newDamageTracker(s, offererEntityID, answererEntityID),
+		newDamageTracker(s, answererEntityID, offererEntityID),
+	}
+
+	for result.Ticks < opts.MaxTicks {
+		offererIntent := offerer.Intent(s, offererEntityID)
+		answererIntent := answerer.Intent(s, answererEntityID)
+		s.Entities[offererEntityID].Intent = offererIntent
+		s.Entities[answererEntityID].Intent = answererIntent
+		step.Step(s, opts.Data)
+		result.Ticks++
+
+		for _, dt := range trackers {
+			dt.update(s, result.Chips)
+		}
+
+		// With only one round, the first KO decides the match.
+		if winner, over := match.RoundWinner(s); over {
+			result.Winner = winner
+			break
+		}
+	}
+
+	return result
+}
+
+type NewAgentFunc func(seed int64) agent.Agent
+
+type Summary struct {
+	Matches      int
+	OffererWins  int
+	AnswererWins int
+	Draws        int
+	TotalTicks   int
+
+	Chips map[string]*ChipStats
+}
+
+func (s *Summary) Add(r Result) {
+	s.Matches++
+	switch r.Winner {
+	case match.SideOfferer:
+		s.OffererWins++
+	case match.SideAnswerer:
+		s.AnswererWins++
+	default:
+		s.Draws++
+	}
+	s.TotalTicks += r.Ticks
+
+	if s.Chips == nil {
+		s.Chips = map[string]*ChipStats{}
+	}
+	for name, cs := range r.Chips {
+		if _, ok := s.Chips[name]; !ok {
+			s.Chips[name] = &ChipStats{}
+		}
+		s.Chips[name].add(cs)
+	}
+}
+
+func (s *Summary) AverageTicks() float64 {
+	if s.Matches == 0 {
+		return 0
+	}
+	return float64(s.TotalTicks) / float64(s.Matches)
+}
+
+// matchSeeds lets any match be reproduced on its own.
+func matchSeeds(seed int64, i int) ([]byte, int64, int64) {
+	r := rand.New(rand.NewSource(seed + int64(i)))
+	randSeed := make([]byte, 32)
+	for j := 0; j < len(randSeed); j += 8 {
+		binary.LittleEndian.PutUint64(randSeed[j:], r.Uint64())
+	}
+	return randSeed, r.Int63(), r.Int63()
+}
+
+// Run runs one match per CPU at a time if parallelism is not positive.
+func Run(n int, seed int64, newOfferer NewAgentFunc, newAnswerer NewAgentFunc, opts Options, parallelism int) *Summary {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+
+	summary := &Summary{Chips: map[string]*ChipStats{}}
+	var mu sync.Mutex
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				randSeed, offererSeed, answererSeed := matchSeeds(seed, i)
+				r := RunMatch(randSeed, newOfferer(offererSeed), newAnswerer(answererSeed), opts)
+
+				mu.Lock()
+				summary.Add(r)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return summary
+}
@@ -0,0 +1,60 @@
+package batch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/murkland/nbarena/agent"
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+)
+
+var testOptions = Options{
+	Data:           gamedata.NewData(),
+	OffererFolder:  state.Folder{{Chip: chips.Recov200, Code: 'C'}, {Chip: chips.Cannon, Code: 'C'}, {Chip: chips.WideSwrd, Code: 'C'}, {Chip: chips.Vulcan1, Code: 'C'}},
+	AnswererFolder: state.Folder{{Chip: chips.Recov200, Code: 'C'}, {Chip: chips.Cannon, Code: 'C'}, {Chip: chips.WideSwrd, Code: 'C'}, {Chip: chips.Vulcan1, Code: 'C'}},
+	MaxTicks:       1200,
+}
+
+func newHeuristic(seed int64) agent.Agent {
+	return agent.NewHeuristic(seed)
+}
+
+func TestRun(t *testing.T) {
+	summary := Run(8, 1, newHeuristic, newHeuristic, testOptions, 4)
+
+	if summary.Matches != 8 {
+		t.Errorf("expected 8 matches, got %d", summary.Matches)
+	}
+	if summary.OffererWins+summary.AnswererWins+summary.Draws != summary.Matches {
+		t.Errorf("wins and draws don't add up: %+v", summary)
+	}
+	if summary.AverageTicks() <= 0 || summary.AverageTicks() > float64(testOptions.MaxTicks) {
+		t.Errorf("average match length out of range: %f", summary.AverageTicks())
+	}
+	for _, name := range []string{BusterName, chips.Vulcan1.Name} {
+		if cs, ok := summary.Chips[name]; !ok || cs.Uses == 0 || cs.Damage == 0 {
+			t.Errorf("expected %s to be used and do damage, got %+v", name, cs)
+		}
+	}
+
+	if summary2 := Run(8, 1, newHeuristic, newHeuristic, testOptions, 2); !reflect.DeepEqual(summary, summary2) {
+		t.Errorf("summaries differ: %+v != %+v", summary, summary2)
+	}
+}
+
+func TestRunMatchEndsWhenHPRunsOut(t *testing.T) {
+	opts := testOptions
+	opts.MaxTicks = 60 * 60 * 10
+	opts.AnswererFolder = nil
+	r := RunMatch([]byte("seed"), agent.NewHeuristic(1), agent.Idle, opts)
+
+	if r.Winner != match.SideOfferer {
+		t.Fatalf("expected the offerer to win, got %d after %d ticks", r.Winner, r.Ticks)
+	}
+	if r.Ticks >= opts.MaxTicks {
+		t.Errorf("expected the match to end early, took %d ticks", r.Ticks)
+	}
+}
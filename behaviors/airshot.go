@@ -1,8 +1,7 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -34,7 +33,7 @@ func (eb *AirShot) Step(e *state.Entity, s *state.State) {
 				RemovesFullSynchro: true,
 				CanCounter:         true,
 			},
-			ExplosionDecorationType: bundle.DecorationTypeCannonExplosion,
+			ExplosionDecorationType: gamedata.DecorationTypeCannonExplosion,
 		}))
 	} else if e.BehaviorState.ElapsedTime == 21-1 {
 		e.NextBehavior = &Idle{}
@@ -43,14 +42,3 @@ func (eb *AirShot) Step(e *state.Entity, s *state.State) {
 
 func (eb *AirShot) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *AirShot) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.RecoilShotAnimation, int(e.BehaviorState.ElapsedTime)))
-
-	airShooterNode := &draw.OptionsNode{Layer: 6}
-	airShooterNode.Opts.GeoM.Translate(float64(16), float64(-24))
-	rootNode.Children = append(rootNode.Children, airShooterNode)
-	airShooterNode.Children = append(airShooterNode.Children, draw.ImageWithAnimation(b.AirShooterSprites.Image, b.AirShooterSprites.Animations[0], int(e.BehaviorState.ElapsedTime)))
-	return rootNode
-}
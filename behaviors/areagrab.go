@@ -1,8 +1,7 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -40,7 +39,8 @@ func (tb *AreaGrab) Step(t *state.Timestop, s *state.State) {
 	found:
 		for y := 1; y < 4; y++ {
 			s.AttachEntity(&state.Entity{
-				TilePos: state.TilePosXY(x, y),
+				TilePos:       state.TilePosXY(x, y),
+				FutureTilePos: state.TilePosXY(x, y),
 
 				RunsInTimestop: true,
 
@@ -56,7 +56,7 @@ func (tb *AreaGrab) Step(t *state.Timestop, s *state.State) {
 				},
 
 				BehaviorState: state.EntityBehaviorState{
-					Behavior: &areaGrabBall{owner.ID()},
+					Behavior: &AreaGrabBall{owner.ID()},
 				},
 			})
 		}
@@ -66,41 +66,26 @@ func (tb *AreaGrab) Step(t *state.Timestop, s *state.State) {
 	}
 }
 
-type areaGrabBall struct {
+type AreaGrabBall struct {
 	Owner state.EntityID
 }
 
-func (eb *areaGrabBall) Traits(e *state.Entity) state.EntityBehaviorTraits {
+func (eb *AreaGrabBall) Traits(e *state.Entity) state.EntityBehaviorTraits {
 	return state.EntityBehaviorTraits{}
 }
 
-func (eb *areaGrabBall) Clone() state.EntityBehavior {
-	return &areaGrabBall{eb.Owner}
+func (eb *AreaGrabBall) Clone() state.EntityBehavior {
+	return &AreaGrabBall{eb.Owner}
 }
 
-func (eb *areaGrabBall) Cleanup(e *state.Entity, s *state.State) {
+func (eb *AreaGrabBall) Cleanup(e *state.Entity, s *state.State) {
 }
 
-func (eb *areaGrabBall) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	ballNode := &draw.OptionsNode{Layer: 7}
-
-	if e.BehaviorState.ElapsedTime < 32 {
-		frames := b.AreaGrabSprites.Animations[0].Frames
-		ballNode.Opts.GeoM.Translate(0, float64(-9*(31-e.BehaviorState.ElapsedTime-1)))
-		ballNode.Children = append(ballNode.Children, draw.ImageWithFrame(b.AreaGrabSprites.Image, frames[int(e.BehaviorState.ElapsedTime)%len(frames)]))
-	} else {
-		frames := b.AreaGrabSprites.Animations[1].Frames
-		ballNode.Children = append(ballNode.Children, draw.ImageWithFrame(b.AreaGrabSprites.Image, frames[int(e.BehaviorState.ElapsedTime)-31]))
-	}
-
-	return ballNode
-}
-
-func (eb *areaGrabBall) Step(e *state.Entity, s *state.State) {
+func (eb *AreaGrabBall) Step(e *state.Entity, s *state.State) {
 	if e.BehaviorState.ElapsedTime == 0 {
-		s.AttachSound(&state.Sound{Type: bundle.SoundTypeAreaGrabStart})
+		s.AttachSound(&state.Sound{Type: gamedata.SoundTypeAreaGrabStart})
 	} else if e.BehaviorState.ElapsedTime == 31 {
-		s.AttachSound(&state.Sound{Type: bundle.SoundTypeAreaGrabEnd})
+		s.AttachSound(&state.Sound{Type: gamedata.SoundTypeAreaGrabEnd})
 		x, _ := e.TilePos.XY()
 		if x == 1 || x == state.TileCols-2 {
 			return
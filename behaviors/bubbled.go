@@ -1,8 +1,6 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -26,8 +24,3 @@ func (eb *Bubbled) Step(e *state.Entity, s *state.State) {
 
 func (eb *Bubbled) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *Bubbled) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	// TODO: Renber bubble.
-	return draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.StuckAnimation, int(e.BehaviorState.ElapsedTime))
-}
@@ -1,8 +1,7 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 	"github.com/murkland/nbarena/state/query"
 )
@@ -11,7 +10,7 @@ type Buster struct {
 	Speed        int
 	BaseDamage   int
 	IsPowerShot  bool
-	isJammed     bool
+	IsJammed     bool
 	cooldownTime state.Ticks
 }
 
@@ -19,7 +18,7 @@ func (eb *Buster) Traits(e *state.Entity) state.EntityBehaviorTraits {
 	return state.EntityBehaviorTraits{}
 }
 
-func (eb *Buster) realElapsedTime(e *state.Entity) state.Ticks {
+func (eb *Buster) RealElapsedTime(e *state.Entity) state.Ticks {
 	t := e.BehaviorState.ElapsedTime
 	if eb.IsPowerShot {
 		t -= 5
@@ -32,7 +31,7 @@ func (eb *Buster) Clone() state.EntityBehavior {
 		eb.Speed,
 		eb.BaseDamage,
 		eb.IsPowerShot,
-		eb.isJammed,
+		eb.IsJammed,
 		eb.cooldownTime,
 	}
 }
@@ -48,7 +47,7 @@ var busterCooldownDurations = [][]state.Ticks{
 }
 
 func (eb *Buster) Step(e *state.Entity, s *state.State) {
-	realElapsedTime := eb.realElapsedTime(e)
+	realElapsedTime := eb.RealElapsedTime(e)
 
 	if realElapsedTime == 5+eb.cooldownTime-1 {
 		e.NextBehavior = &Idle{}
@@ -66,12 +65,12 @@ func (eb *Buster) Step(e *state.Entity, s *state.State) {
 		if eb.IsPowerShot {
 			damage *= 10
 		}
-		decorationType := bundle.DecorationTypeBusterExplosion
+		decorationType := gamedata.DecorationTypeBusterExplosion
 		if eb.IsPowerShot {
-			decorationType = bundle.DecorationTypeBusterPowerShotExplosion
+			decorationType = gamedata.DecorationTypeBusterPowerShotExplosion
 		}
 		s.AttachSound(&state.Sound{
-			Type: bundle.SoundTypeBuster,
+			Type: gamedata.SoundTypeBuster,
 		})
 		s.AttachEntity(MakeShotEntity(e, state.TilePosXY(x+dx, y), &Shot{
 			Damage: state.Damage{Base: damage},
@@ -100,27 +99,3 @@ func (eb *Buster) Step(e *state.Entity, s *state.State) {
 
 func (eb *Buster) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *Buster) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	realElapsedTime := eb.realElapsedTime(e)
-
-	if realElapsedTime < 0 {
-		return draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.IdleAnimation, int(e.ElapsedTime))
-	}
-
-	rootNode := &draw.OptionsNode{}
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.BusterAnimation, int(realElapsedTime)))
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.BusterSprites.Image, b.BusterSprites.BaseAnimation, int(realElapsedTime)))
-
-	if !eb.isJammed {
-		muzzleFlashAnimTime := int(realElapsedTime) - 1
-		if muzzleFlashAnimTime > 0 && muzzleFlashAnimTime < len(b.MuzzleFlashSprites.Animations[0].Frames) {
-			muzzleFlashNode := &draw.OptionsNode{Layer: 7}
-			muzzleFlashNode.Opts.GeoM.Translate(float64(state.TileRenderedWidth), float64(-26))
-			muzzleFlashNode.Children = append(muzzleFlashNode.Children, draw.ImageWithAnimation(b.MuzzleFlashSprites.Image, b.MuzzleFlashSprites.Animations[0], muzzleFlashAnimTime))
-			rootNode.Children = append(rootNode.Children, muzzleFlashNode)
-		}
-	}
-
-	return rootNode
-}
@@ -1,9 +1,7 @@
 package behaviors
 
 import (
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -46,7 +44,7 @@ func (eb *Cannon) Step(e *state.Entity, s *state.State) {
 				CanCounter:         true,
 				RemovesFullSynchro: true,
 			},
-			ExplosionDecorationType: bundle.DecorationTypeCannonExplosion,
+			ExplosionDecorationType: gamedata.DecorationTypeCannonExplosion,
 		}))
 	} else if e.BehaviorState.ElapsedTime == 33-1 {
 		e.NextBehavior = &Idle{}
@@ -55,27 +53,3 @@ func (eb *Cannon) Step(e *state.Entity, s *state.State) {
 
 func (eb *Cannon) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *Cannon) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	if e.BehaviorState.ElapsedTime >= 29 {
-		return draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.BraceAnimation.Frames[int(e.BehaviorState.ElapsedTime-29)])
-	}
-
-	rootNode := &draw.OptionsNode{}
-	rootNode.Children = append(rootNode.Children, draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.CannonAnimation.Frames[e.BehaviorState.ElapsedTime]))
-
-	cannonNode := &draw.OptionsNode{Layer: 6}
-	cannonNode.Opts.GeoM.Translate(float64(16), float64(-24))
-	rootNode.Children = append(rootNode.Children, cannonNode)
-	var img *ebiten.Image
-	switch eb.Style {
-	case CannonStyleCannon:
-		img = b.CannonSprites.CannonImage
-	case CannonStyleHiCannon:
-		img = b.CannonSprites.HiCannonImage
-	case CannonStyleMCannon:
-		img = b.CannonSprites.MCannonImage
-	}
-	cannonNode.Children = append(cannonNode.Children, draw.ImageWithFrame(img, b.CannonSprites.Animation.Frames[e.BehaviorState.ElapsedTime]))
-	return rootNode
-}
@@ -0,0 +1,39 @@
+package behaviors
+
+import (
+	"image"
+	"math/rand"
+
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/state"
+)
+
+const DeletedTicks = 64
+
+// Deleted hides a fighter that ran out of HP, but never removes it, so that
+// the match can bring it back for the next round.
+type Deleted struct {
+}
+
+func (eb *Deleted) Clone() state.EntityBehavior {
+	return &Deleted{}
+}
+
+func (eb *Deleted) Traits(e *state.Entity) state.EntityBehaviorTraits {
+	return state.EntityBehaviorTraits{}
+}
+
+func (eb *Deleted) Step(e *state.Entity, s *state.State) {
+	if e.BehaviorState.ElapsedTime < DeletedTicks && e.BehaviorState.ElapsedTime%16 == 0 {
+		rand := rand.New(s.RandSource)
+		s.AttachDecoration(&state.Decoration{
+			Type:      gamedata.DecorationTypeDeathExplosion,
+			TilePos:   e.TilePos,
+			Offset:    image.Point{rand.Intn(state.TileRenderedWidth/2) - state.TileRenderedWidth/4, -rand.Intn(state.TileRenderedHeight)},
+			IsFlipped: e.IsFlipped,
+		})
+	}
+}
+
+func (eb *Deleted) Cleanup(e *state.Entity, s *state.State) {
+}
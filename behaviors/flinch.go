@@ -1,8 +1,6 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -25,7 +23,3 @@ func (eb *Flinch) Step(e *state.Entity, s *state.State) {
 
 func (eb *Flinch) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *Flinch) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	return draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.FlinchAnimation.Frames[int(e.BehaviorState.ElapsedTime)])
-}
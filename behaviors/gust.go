@@ -1,9 +1,6 @@
 package behaviors
 
 import (
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -33,27 +30,6 @@ func (eb *Gust) Traits(e *state.Entity) state.EntityBehaviorTraits {
 	return state.EntityBehaviorTraits{}
 }
 
-func (eb *Gust) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	if e.IsPendingDestruction {
-		return nil
-	}
-
-	var gustImg *ebiten.Image = nil
-	switch eb.Style {
-	case GustStyleWind:
-		gustImg = b.GustSprites.WindImage
-	case GustStyleFan:
-		gustImg = b.GustSprites.FanImage
-	}
-
-	dx := (int(e.BehaviorState.ElapsedTime)-1+4)%4 - 2
-
-	rootNode := &draw.OptionsNode{}
-	rootNode.Opts.GeoM.Translate(float64(dx*state.TileRenderedWidth/4), 0)
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(gustImg, b.GustSprites.Animation, int(e.BehaviorState.ElapsedTime)))
-	return rootNode
-}
-
 func (eb *Gust) Step(e *state.Entity, s *state.State) {
 	if e.BehaviorState.ElapsedTime%4 == 1 {
 		x, y := e.TilePos.XY()
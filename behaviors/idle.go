@@ -1,8 +1,7 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -49,12 +48,12 @@ func (eb *Idle) Step(e *state.Entity, s *state.State) {
 		eb.ChargingElapsedTime++
 		if eb.ChargingElapsedTime == 10 {
 			s.AttachSound(&state.Sound{
-				Type: bundle.SoundTypeCharging,
+				Type: gamedata.SoundTypeCharging,
 			})
 		}
 		if eb.ChargingElapsedTime == e.PowerShotChargeTime {
 			s.AttachSound(&state.Sound{
-				Type: bundle.SoundTypeCharged,
+				Type: gamedata.SoundTypeCharged,
 			})
 		}
 	}
@@ -62,23 +61,3 @@ func (eb *Idle) Step(e *state.Entity, s *state.State) {
 
 func (eb *Idle) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *Idle) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.IdleAnimation, int(e.ElapsedTime)))
-
-	if eb.ChargingElapsedTime >= 10 {
-		chargingNode := &draw.OptionsNode{}
-		rootNode.Children = append(rootNode.Children, chargingNode)
-
-		frames := b.ChargingSprites.ChargingAnimation.Frames
-		if eb.ChargingElapsedTime >= e.PowerShotChargeTime {
-			frames = b.ChargingSprites.ChargedAnimation.Frames
-		}
-		frame := frames[int(eb.ChargingElapsedTime)%len(frames)]
-		chargingNode.Children = append(chargingNode.Children, draw.ImageWithFrame(b.ChargingSprites.Image, frame))
-	}
-
-	return rootNode
-}
@@ -1,8 +1,6 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -26,12 +24,3 @@ func (eb *Paralyzed) Step(e *state.Entity, s *state.State) {
 
 func (eb *Paralyzed) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *Paralyzed) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-	if (e.ElapsedTime/2)%2 == 1 {
-		rootNode.Opts.ColorM.Translate(1.0, 1.0, 0.0, 0.0)
-	}
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.StuckAnimation, int(e.BehaviorState.ElapsedTime)))
-	return rootNode
-}
@@ -3,8 +3,7 @@ package behaviors
 import (
 	"image"
 
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -30,10 +29,10 @@ func (eb *Recov) Step(e *state.Entity, s *state.State) {
 		// TODO: Trigger antirecovery.
 
 		s.AttachSound(&state.Sound{
-			Type: bundle.SoundTypeRecov,
+			Type: gamedata.SoundTypeRecov,
 		})
 		s.AttachDecoration(&state.Decoration{
-			Type:      bundle.DecorationTypeRecov,
+			Type:      gamedata.DecorationTypeRecov,
 			TilePos:   e.TilePos,
 			Offset:    image.Point{0, 0},
 			IsFlipped: e.IsFlipped,
@@ -47,7 +46,3 @@ func (eb *Recov) Step(e *state.Entity, s *state.State) {
 
 func (eb *Recov) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *Recov) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	return nil
-}
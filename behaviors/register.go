@@ -0,0 +1,26 @@
+package behaviors
+
+import "github.com/murkland/nbarena/state"
+
+func init() {
+	state.RegisterType("behaviors.AirShot", &AirShot{})
+	state.RegisterType("behaviors.AreaGrab", &AreaGrab{})
+	state.RegisterType("behaviors.AreaGrabBall", &AreaGrabBall{})
+	state.RegisterType("behaviors.Bubbled", &Bubbled{})
+	state.RegisterType("behaviors.Buster", &Buster{})
+	state.RegisterType("behaviors.Cannon", &Cannon{})
+	state.RegisterType("behaviors.Deleted", &Deleted{})
+	state.RegisterType("behaviors.Flinch", &Flinch{})
+	state.RegisterType("behaviors.Frozen", &Frozen{})
+	state.RegisterType("behaviors.Gust", &Gust{})
+	state.RegisterType("behaviors.Idle", &Idle{})
+	state.RegisterType("behaviors.Paralyzed", &Paralyzed{})
+	state.RegisterType("behaviors.Recov", &Recov{})
+	state.RegisterType("behaviors.Shot", &Shot{})
+	state.RegisterType("behaviors.Sword", &Sword{})
+	state.RegisterType("behaviors.Teleport", &Teleport{})
+	state.RegisterType("behaviors.Vulcan", &Vulcan{})
+	state.RegisterType("behaviors.vulcanShot", &vulcanShot{})
+	state.RegisterType("behaviors.WindFan", &WindFan{})
+	state.RegisterType("behaviors.WindRack", &WindRack{})
+}
@@ -4,8 +4,7 @@ import (
 	"image"
 	"math/rand"
 
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -13,7 +12,7 @@ type Shot struct {
 	Owner                   state.EntityID
 	Damage                  state.Damage
 	Hit                     state.Hit
-	ExplosionDecorationType bundle.DecorationType
+	ExplosionDecorationType gamedata.DecorationType
 }
 
 func (eb *Shot) Clone() state.EntityBehavior {
@@ -29,10 +28,6 @@ func (eb *Shot) Traits(e *state.Entity) state.EntityBehaviorTraits {
 	return state.EntityBehaviorTraits{}
 }
 
-func (eb *Shot) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	return nil
-}
-
 func (eb *Shot) Step(e *state.Entity, s *state.State) {
 	if e.BehaviorState.ElapsedTime%2 == 1 {
 		x, y := e.TilePos.XY()
@@ -46,7 +41,7 @@ func (eb *Shot) Step(e *state.Entity, s *state.State) {
 	h := eb.Hit
 	h.AddDamage(eb.Damage)
 	if s.ApplyHit(s.Entities[eb.Owner], e.TilePos, h) {
-		if eb.ExplosionDecorationType != bundle.DecorationTypeNone {
+		if eb.ExplosionDecorationType != gamedata.DecorationTypeNone {
 			rand := rand.New(s.RandSource)
 
 			xOff := rand.Intn(state.TileRenderedWidth / 4)
@@ -72,7 +67,8 @@ func MakeShotEntity(owner *state.Entity, pos state.TilePos, shot *Shot) *state.E
 	shot.Owner = owner.ID()
 
 	return &state.Entity{
-		TilePos: pos,
+		TilePos:       pos,
+		FutureTilePos: pos,
 
 		IsFlipped:            owner.IsFlipped,
 		IsAlliedWithAnswerer: owner.IsAlliedWithAnswerer,
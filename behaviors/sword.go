@@ -3,8 +3,7 @@ package behaviors
 import (
 	"image"
 
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -24,32 +23,32 @@ const (
 	SwordStyleBlade SwordStyle = 1
 )
 
-func swordSlashDecorationType(s SwordStyle, r SwordRange) bundle.DecorationType {
+func swordSlashDecorationType(s SwordStyle, r SwordRange) gamedata.DecorationType {
 	switch s {
 	case SwordStyleSword:
 		switch r {
 		case SwordRangeShort:
-			return bundle.DecorationTypeNullShortSwordSlash
+			return gamedata.DecorationTypeNullShortSwordSlash
 		case SwordRangeWide:
-			return bundle.DecorationTypeNullWideSwordSlash
+			return gamedata.DecorationTypeNullWideSwordSlash
 		case SwordRangeLong:
-			return bundle.DecorationTypeNullLongSwordSlash
+			return gamedata.DecorationTypeNullLongSwordSlash
 		case SwordRangeVeryLong:
-			return bundle.DecorationTypeNullVeryLongSwordSlash
+			return gamedata.DecorationTypeNullVeryLongSwordSlash
 		}
 	case SwordStyleBlade:
 		switch r {
 		case SwordRangeShort:
-			return bundle.DecorationTypeNullShortBladeSlash
+			return gamedata.DecorationTypeNullShortBladeSlash
 		case SwordRangeWide:
-			return bundle.DecorationTypeNullWideBladeSlash
+			return gamedata.DecorationTypeNullWideBladeSlash
 		case SwordRangeLong:
-			return bundle.DecorationTypeNullLongBladeSlash
+			return gamedata.DecorationTypeNullLongBladeSlash
 		case SwordRangeVeryLong:
-			return bundle.DecorationTypeNullVeryLongBladeSlash
+			return gamedata.DecorationTypeNullVeryLongBladeSlash
 		}
 	}
-	return bundle.DecorationTypeNone
+	return gamedata.DecorationTypeNone
 }
 
 type Sword struct {
@@ -75,7 +74,7 @@ func (eb *Sword) Clone() state.EntityBehavior {
 	}
 }
 
-func swordTargetEntities(s *state.State, e *state.Entity, r SwordRange) []state.TilePos {
+func SwordTargetPositions(s *state.State, e *state.Entity, r SwordRange) []state.TilePos {
 	x, y := e.TilePos.XY()
 	dx, _ := e.Facing().XY()
 	var positions []state.TilePos
@@ -103,10 +102,10 @@ func (eb *Sword) Step(e *state.Entity, s *state.State) {
 			IsFlipped: e.IsFlipped,
 		})
 		s.AttachSound(&state.Sound{
-			Type: bundle.SoundTypeSwordSlash,
+			Type: gamedata.SoundTypeSwordSlash,
 		})
 
-		for _, pos := range swordTargetEntities(s, e, eb.Range) {
+		for _, pos := range SwordTargetPositions(s, e, eb.Range) {
 			var h state.Hit
 			h.Flinch = true
 			h.FlashTime = state.DefaultFlashTime
@@ -121,14 +120,3 @@ func (eb *Sword) Step(e *state.Entity, s *state.State) {
 		e.NextBehavior = &Idle{}
 	}
 }
-
-func (eb *Sword) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.SlashAnimation, int(e.BehaviorState.ElapsedTime)))
-
-	swordNode := &draw.OptionsNode{Layer: 6}
-	rootNode.Children = append(rootNode.Children, swordNode)
-	swordNode.Children = append(swordNode.Children, draw.ImageWithAnimation(b.SwordSprites.Image, b.SwordSprites.BaseAnimation, int(e.BehaviorState.ElapsedTime)))
-
-	return rootNode
-}
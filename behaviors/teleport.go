@@ -1,10 +1,8 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
-	"github.com/murkland/pngsheet"
 )
 
 const teleportEndlagTicks = 6
@@ -42,12 +40,12 @@ func (eb *Teleport) Step(e *state.Entity, s *state.State) {
 		eb.ChargingElapsedTime++
 		if eb.ChargingElapsedTime == 10 {
 			s.AttachSound(&state.Sound{
-				Type: bundle.SoundTypeCharging,
+				Type: gamedata.SoundTypeCharging,
 			})
 		}
 		if eb.ChargingElapsedTime == e.PowerShotChargeTime {
 			s.AttachSound(&state.Sound{
-				Type: bundle.SoundTypeCharged,
+				Type: gamedata.SoundTypeCharged,
 			})
 		}
 	}
@@ -72,31 +70,3 @@ func (eb *Teleport) Step(e *state.Entity, s *state.State) {
 		}
 	}
 }
-
-func (eb *Teleport) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-
-	var frame *pngsheet.Frame
-	if e.BehaviorState.ElapsedTime < 3 {
-		frame = b.MegamanSprites.TeleportStartAnimation.Frames[e.BehaviorState.ElapsedTime]
-	} else if e.BehaviorState.ElapsedTime < 6 {
-		frame = b.MegamanSprites.TeleportEndAnimation.Frames[e.BehaviorState.ElapsedTime-3]
-	} else {
-		frame = b.MegamanSprites.TeleportEndAnimation.Frames[len(b.MegamanSprites.TeleportEndAnimation.Frames)-1]
-	}
-	rootNode.Children = append(rootNode.Children, draw.ImageWithFrame(b.MegamanSprites.Image, frame))
-
-	if eb.ChargingElapsedTime >= 10 {
-		chargingNode := &draw.OptionsNode{}
-		rootNode.Children = append(rootNode.Children, chargingNode)
-
-		frames := b.ChargingSprites.ChargingAnimation.Frames
-		if eb.ChargingElapsedTime >= e.PowerShotChargeTime {
-			frames = b.ChargingSprites.ChargedAnimation.Frames
-		}
-		frame := frames[int(eb.ChargingElapsedTime)%len(frames)]
-		chargingNode.Children = append(chargingNode.Children, draw.ImageWithFrame(b.ChargingSprites.Image, frame))
-	}
-
-	return rootNode
-}
@@ -4,15 +4,14 @@ import (
 	"image"
 	"math/rand"
 
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
 type Vulcan struct {
 	Shots                   int
 	Damage                  state.Damage
-	ExplosionDecorationType bundle.DecorationType
+	ExplosionDecorationType gamedata.DecorationType
 }
 
 func (eb *Vulcan) Clone() state.EntityBehavior {
@@ -39,7 +38,8 @@ func (eb *Vulcan) Step(e *state.Entity, s *state.State) {
 		x, y := e.TilePos.XY()
 		dx, _ := e.Facing().XY()
 		s.AttachEntity(&state.Entity{
-			TilePos: state.TilePosXY(x+dx, y),
+			TilePos:       state.TilePosXY(x+dx, y),
+			FutureTilePos: state.TilePosXY(x+dx, y),
 
 			IsFlipped:            e.IsFlipped,
 			IsAlliedWithAnswerer: e.IsAlliedWithAnswerer,
@@ -63,36 +63,10 @@ func (eb *Vulcan) Step(e *state.Entity, s *state.State) {
 func (eb *Vulcan) Cleanup(e *state.Entity, s *state.State) {
 }
 
-func (eb *Vulcan) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-	var megamanImageNode draw.Node
-
-	if e.BehaviorState.ElapsedTime < 2 {
-		megamanImageNode = draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.HoldInFrontAnimation.Frames[int(e.BehaviorState.ElapsedTime)%len(b.MegamanSprites.HoldInFrontAnimation.Frames)])
-	} else {
-		megamanImageNode = draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.GattlingAnimation.Frames[int(e.BehaviorState.ElapsedTime-2)%len(b.MegamanSprites.GattlingAnimation.Frames)])
-	}
-	rootNode.Children = append(rootNode.Children, megamanImageNode)
-
-	vulcanNode := &draw.OptionsNode{Layer: 6}
-	rootNode.Children = append(rootNode.Children, vulcanNode)
-	vulcanNode.Opts.GeoM.Translate(float64(24), float64(-24))
-	var vulcanImageNode draw.Node
-	if e.BehaviorState.ElapsedTime < 2 {
-		vulcanImageNode = draw.ImageWithFrame(b.VulcanSprites.Image, b.VulcanSprites.Animations[0].Frames[e.BehaviorState.ElapsedTime])
-	} else {
-		vulcanFrames := b.VulcanSprites.Animations[1].Frames
-		vulcanImageNode = draw.ImageWithFrame(b.VulcanSprites.Image, vulcanFrames[int(e.BehaviorState.ElapsedTime-2)%len(vulcanFrames)])
-	}
-	vulcanNode.Children = append(vulcanNode.Children, vulcanImageNode)
-
-	return rootNode
-}
-
 type vulcanShot struct {
 	Owner                   state.EntityID
 	Damage                  state.Damage
-	ExplosionDecorationType bundle.DecorationType
+	ExplosionDecorationType gamedata.DecorationType
 }
 
 func (eb *vulcanShot) Clone() state.EntityBehavior {
@@ -107,10 +81,6 @@ func (eb *vulcanShot) Traits(e *state.Entity) state.EntityBehaviorTraits {
 	return state.EntityBehaviorTraits{}
 }
 
-func (eb *vulcanShot) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	return nil
-}
-
 func (eb *vulcanShot) Step(e *state.Entity, s *state.State) {
 	if e.BehaviorState.ElapsedTime == 0 {
 		return
@@ -130,7 +100,7 @@ func (eb *vulcanShot) Step(e *state.Entity, s *state.State) {
 		yOff := -rand.Intn(state.TileRenderedHeight)
 
 		s.AttachDecoration(&state.Decoration{
-			Type:      bundle.DecorationTypeBusterExplosion,
+			Type:      gamedata.DecorationTypeBusterExplosion,
 			TilePos:   e.TilePos,
 			Offset:    image.Point{xOff + rand.Intn(2) - 4, yOff + rand.Intn(2) - 4},
 			IsFlipped: e.IsFlipped,
@@ -138,7 +108,7 @@ func (eb *vulcanShot) Step(e *state.Entity, s *state.State) {
 
 		s.AttachDecoration(&state.Decoration{
 			ElapsedTime: -1,
-			Type:        bundle.DecorationTypeBusterExplosion,
+			Type:        gamedata.DecorationTypeBusterExplosion,
 			TilePos:     e.TilePos,
 			Offset:      image.Point{xOff + rand.Intn(2) - 4, yOff + rand.Intn(2) - 4},
 			IsFlipped:   e.IsFlipped,
@@ -1,8 +1,6 @@
 package behaviors
 
 import (
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -46,7 +44,8 @@ func (eb *WindFan) Step(e *state.Entity, s *state.State) {
 		}
 
 		s.AttachEntity(&state.Entity{
-			TilePos: state.TilePosXY(x, y),
+			TilePos:       state.TilePosXY(x, y),
+			FutureTilePos: state.TilePosXY(x, y),
 
 			IsFlipped:            isFlipped,
 			IsAlliedWithAnswerer: e.IsAlliedWithAnswerer,
@@ -66,14 +65,3 @@ func (eb *WindFan) Step(e *state.Entity, s *state.State) {
 		})
 	}
 }
-
-func (eb *WindFan) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	img := b.WindFanSprites.WindImage
-	if eb.IsFan {
-		img = b.WindFanSprites.FanImage
-	}
-
-	rootNode := &draw.OptionsNode{}
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(img, b.WindFanSprites.Animation, int(e.BehaviorState.ElapsedTime)))
-	return rootNode
-}
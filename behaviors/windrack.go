@@ -3,8 +3,7 @@ package behaviors
 import (
 	"image"
 
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
 )
 
@@ -27,7 +26,7 @@ func (eb *WindRack) Traits(e *state.Entity) state.EntityBehaviorTraits {
 func (eb *WindRack) Step(e *state.Entity, s *state.State) {
 	if e.BehaviorState.ElapsedTime == 0 {
 		s.AttachDecoration(&state.Decoration{
-			Type:      bundle.DecorationTypeWindSlash,
+			Type:      gamedata.DecorationTypeWindSlash,
 			TilePos:   e.TilePos,
 			Offset:    image.Point{0, -16},
 			IsFlipped: e.IsFlipped,
@@ -53,7 +52,8 @@ func (eb *WindRack) Step(e *state.Entity, s *state.State) {
 
 		for i := 1; i <= 3; i++ {
 			s.AttachEntity(&state.Entity{
-				TilePos: state.TilePosXY(x+dx, i),
+				TilePos:       state.TilePosXY(x+dx, i),
+				FutureTilePos: state.TilePosXY(x+dx, i),
 
 				IsFlipped:            e.IsFlipped,
 				IsAlliedWithAnswerer: e.IsAlliedWithAnswerer,
@@ -79,14 +79,3 @@ func (eb *WindRack) Step(e *state.Entity, s *state.State) {
 
 func (eb *WindRack) Cleanup(e *state.Entity, s *state.State) {
 }
-
-func (eb *WindRack) Appearance(e *state.Entity, b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.SlashAnimation, int(e.BehaviorState.ElapsedTime)))
-
-	swordNode := &draw.OptionsNode{Layer: 6}
-	rootNode.Children = append(rootNode.Children, swordNode)
-	swordNode.Children = append(swordNode.Children, draw.ImageWithAnimation(b.WindRackSprites.Image, b.WindRackSprites.Animations[0], int(e.BehaviorState.ElapsedTime)))
-
-	return rootNode
-}
@@ -11,6 +11,7 @@ import (
 	"github.com/faiface/beep/vorbis"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/murkland/moreio"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/loader"
 	"github.com/murkland/oggloop"
 	"github.com/murkland/pngsheet"
@@ -177,47 +178,6 @@ type Sprite struct {
 	Animation *pngsheet.Animation
 }
 
-type DecorationType int
-
-const (
-	DecorationTypeNone           DecorationType = 0
-	DecorationTypeDeathExplosion DecorationType = iota
-	DecorationTypeCannonExplosion
-	DecorationTypeBusterPowerShotExplosion
-	DecorationTypeBusterExplosion
-	DecorationTypeVulcanExplosion
-	DecorationTypeSuperVulcanExplosion
-	DecorationTypeUninstallExplosion
-	DecorationTypeChipDeleteExplosion
-	DecorationTypeShieldHitExplosion
-	DecorationTypeNullShortSwordSlash
-	DecorationTypeNullWideSwordSlash
-	DecorationTypeNullLongSwordSlash
-	DecorationTypeNullVeryLongSwordSlash
-	DecorationTypeNullShortBladeSlash
-	DecorationTypeNullWideBladeSlash
-	DecorationTypeNullLongBladeSlash
-	DecorationTypeNullVeryLongBladeSlash
-	DecorationTypeWindSlash
-	DecorationTypeRecov
-)
-
-type SoundType int
-
-const (
-	SoundTypeNone   SoundType = 0
-	SoundTypeBuster SoundType = iota
-	SoundTypeOuch
-	SoundTypeCharging
-	SoundTypeCharged
-	SoundTypeSwordSlash
-	SoundTypeCounterHit
-	SoundTypeDoubleDamageConsumed
-	SoundTypeRecov
-	SoundTypeAreaGrabStart
-	SoundTypeAreaGrabEnd
-)
-
 type BGM struct {
 	Buffer   *beep.Buffer
 	LoopInfo oggloop.Info
@@ -244,7 +204,7 @@ type Bundle struct {
 	FullSynchroSprites *Sprites
 	IcedSprites        *Sprites
 
-	DecorationSprites map[DecorationType]*Sprite
+	DecorationSprites map[gamedata.DecorationType]*Sprite
 
 	ChargingSprites *ChargingSprites
 
@@ -252,11 +212,13 @@ type Bundle struct {
 
 	BattleBGM *BGM
 
-	Sounds map[SoundType]*beep.Buffer
+	Sounds map[gamedata.SoundType]*beep.Buffer
 
 	TallFont    font.Face
 	Tall2Font   font.Face
 	TinyNumFont font.Face
+
+	Data *gamedata.Data
 }
 
 func loadBDF(ctx context.Context, f moreio.File) (font.Face, error) {
@@ -505,35 +467,14 @@ func Load(ctx context.Context, loaderCallback loader.Callback) (*Bundle, error)
 
 	loader.Add(ctx, l, "assets/sounds/034.ogg", &b.BattleBGM, loadBGM)
 
-	var busterSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/106.ogg", &busterSound, loadSound)
-
-	var ouchSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/107.ogg", &ouchSound, loadSound)
-
-	var chargingSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/113.ogg", &chargingSound, loadSound)
-
-	var chargedSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/114.ogg", &chargedSound, loadSound)
-
-	var counterHitSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/134.ogg", &counterHitSound, loadSound)
-
-	var doubleDamageConsumedSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/135.ogg", &doubleDamageConsumedSound, loadSound)
-
-	var confusedSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/136.ogg", &confusedSound, loadSound)
-
-	var recovSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/138.ogg", &recovSound, loadSound)
-
-	var areaGrabStartSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/161.ogg", &areaGrabStartSound, loadSound)
-
-	var areaGrabEndSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/162.ogg", &areaGrabEndSound, loadSound)
+	soundTypes := make([]gamedata.SoundType, 0, len(gamedata.SoundPaths))
+	for typ := range gamedata.SoundPaths {
+		soundTypes = append(soundTypes, typ)
+	}
+	sounds := make([]*beep.Buffer, len(soundTypes))
+	for i, typ := range soundTypes {
+		loader.Add(ctx, l, gamedata.SoundPaths[typ], &sounds[i], loadSound)
+	}
 
 	// 110: shield?
 	// 120: battle start
@@ -541,11 +482,8 @@ func Load(ctx context.Context, loaderCallback loader.Callback) (*Bundle, error)
 	// 122: crossselect
 	// 130: confirm
 	// 132: low hp?
-	// 134: counter hit
 	// 151: tile destroyed?
 	// 168: fanfare
-	var swordSlashSound *beep.Buffer
-	loader.Add(ctx, l, "assets/sounds/176.ogg", &swordSlashSound, loadSound)
 
 	loader.Add(ctx, l, "assets/fonts/tall.bdf", &b.TallFont, loadBDF)
 	loader.Add(ctx, l, "assets/fonts/tall2.bdf", &b.Tall2Font, loadBDF)
@@ -555,39 +493,39 @@ func Load(ctx context.Context, loaderCallback loader.Callback) (*Bundle, error)
 		return nil, err
 	}
 
-	b.DecorationSprites = map[DecorationType]*Sprite{
-		DecorationTypeDeathExplosion:           {deathExplosionDecorationSprites.Image, deathExplosionDecorationSprites.Animations[0]},
-		DecorationTypeCannonExplosion:          {cannonExplosionDecorationSprites.Image, cannonExplosionDecorationSprites.Animations[0]},
-		DecorationTypeBusterPowerShotExplosion: {chargeShotExplosionDecorationSprites.Image, chargeShotExplosionDecorationSprites.Animations[0]},
-		DecorationTypeBusterExplosion:          {explosionDecorationSprites.Image, explosionDecorationSprites.Animations[0]},
-		DecorationTypeVulcanExplosion:          {vulcanExplosionDecorationSprites.VulcanImage, vulcanExplosionDecorationSprites.Animation},
-		DecorationTypeSuperVulcanExplosion:     {vulcanExplosionDecorationSprites.SuperVulcanImage, vulcanExplosionDecorationSprites.Animation},
-		DecorationTypeUninstallExplosion:       {uninstallExplosionDecorationSprites.Image, uninstallExplosionDecorationSprites.Animations[0]},
-		DecorationTypeChipDeleteExplosion:      {chipDeleteExplosionDecorationSprites.Image, chipDeleteExplosionDecorationSprites.Animations[0]},
-		DecorationTypeShieldHitExplosion:       {shieldHitExplosionDecorationSprites.Image, shieldHitExplosionDecorationSprites.Animations[0]},
-		DecorationTypeNullShortSwordSlash:      {slashDecorationSprites.SwordImage, slashDecorationSprites.ShortAnimation},
-		DecorationTypeNullWideSwordSlash:       {slashDecorationSprites.SwordImage, slashDecorationSprites.WideAnimation},
-		DecorationTypeNullLongSwordSlash:       {slashDecorationSprites.SwordImage, slashDecorationSprites.LongAnimation},
-		DecorationTypeNullVeryLongSwordSlash:   {slashDecorationSprites.SwordImage, slashDecorationSprites.VeryLongAnimation},
-		DecorationTypeNullShortBladeSlash:      {slashDecorationSprites.BladeImage, slashDecorationSprites.ShortAnimation},
-		DecorationTypeNullWideBladeSlash:       {slashDecorationSprites.BladeImage, slashDecorationSprites.WideAnimation},
-		DecorationTypeNullLongBladeSlash:       {slashDecorationSprites.BladeImage, slashDecorationSprites.LongAnimation},
-		DecorationTypeNullVeryLongBladeSlash:   {slashDecorationSprites.BladeImage, slashDecorationSprites.VeryLongAnimation},
-		DecorationTypeWindSlash:                {windSlashDecorationSprites.Image, windSlashDecorationSprites.Animations[0]},
-		DecorationTypeRecov:                    {recovDecorationSprites.Image, recovDecorationSprites.Animations[0]},
+	b.DecorationSprites = map[gamedata.DecorationType]*Sprite{
+		gamedata.DecorationTypeDeathExplosion:           {deathExplosionDecorationSprites.Image, deathExplosionDecorationSprites.Animations[0]},
+		gamedata.DecorationTypeCannonExplosion:          {cannonExplosionDecorationSprites.Image, cannonExplosionDecorationSprites.Animations[0]},
+		gamedata.DecorationTypeBusterPowerShotExplosion: {chargeShotExplosionDecorationSprites.Image, chargeShotExplosionDecorationSprites.Animations[0]},
+		gamedata.DecorationTypeBusterExplosion:          {explosionDecorationSprites.Image, explosionDecorationSprites.Animations[0]},
+		gamedata.DecorationTypeVulcanExplosion:          {vulcanExplosionDecorationSprites.VulcanImage, vulcanExplosionDecorationSprites.Animation},
+		gamedata.DecorationTypeSuperVulcanExplosion:     {vulcanExplosionDecorationSprites.SuperVulcanImage, vulcanExplosionDecorationSprites.Animation},
+		gamedata.DecorationTypeUninstallExplosion:       {uninstallExplosionDecorationSprites.Image, uninstallExplosionDecorationSprites.Animations[0]},
+		gamedata.DecorationTypeChipDeleteExplosion:      {chipDeleteExplosionDecorationSprites.Image, chipDeleteExplosionDecorationSprites.Animations[0]},
+		gamedata.DecorationTypeShieldHitExplosion:       {shieldHitExplosionDecorationSprites.Image, shieldHitExplosionDecorationSprites.Animations[0]},
+		gamedata.DecorationTypeNullShortSwordSlash:      {slashDecorationSprites.SwordImage, slashDecorationSprites.ShortAnimation},
+		gamedata.DecorationTypeNullWideSwordSlash:       {slashDecorationSprites.SwordImage, slashDecorationSprites.WideAnimation},
+		gamedata.DecorationTypeNullLongSwordSlash:       {slashDecorationSprites.SwordImage, slashDecorationSprites.LongAnimation},
+		gamedata.DecorationTypeNullVeryLongSwordSlash:   {slashDecorationSprites.SwordImage, slashDecorationSprites.VeryLongAnimation},
+		gamedata.DecorationTypeNullShortBladeSlash:      {slashDecorationSprites.BladeImage, slashDecorationSprites.ShortAnimation},
+		gamedata.DecorationTypeNullWideBladeSlash:       {slashDecorationSprites.BladeImage, slashDecorationSprites.WideAnimation},
+		gamedata.DecorationTypeNullLongBladeSlash:       {slashDecorationSprites.BladeImage, slashDecorationSprites.LongAnimation},
+		gamedata.DecorationTypeNullVeryLongBladeSlash:   {slashDecorationSprites.BladeImage, slashDecorationSprites.VeryLongAnimation},
+		gamedata.DecorationTypeWindSlash:                {windSlashDecorationSprites.Image, windSlashDecorationSprites.Animations[0]},
+		gamedata.DecorationTypeRecov:                    {recovDecorationSprites.Image, recovDecorationSprites.Animations[0]},
+	}
+
+	b.Sounds = map[gamedata.SoundType]*beep.Buffer{}
+	for i, typ := range soundTypes {
+		b.Sounds[typ] = sounds[i]
 	}
 
-	b.Sounds = map[SoundType]*beep.Buffer{
-		SoundTypeBuster:               busterSound,
-		SoundTypeOuch:                 ouchSound,
-		SoundTypeCharging:             chargingSound,
-		SoundTypeCharged:              chargedSound,
-		SoundTypeSwordSlash:           swordSlashSound,
-		SoundTypeCounterHit:           counterHitSound,
-		SoundTypeDoubleDamageConsumed: doubleDamageConsumedSound,
-		SoundTypeRecov:                recovSound,
-		SoundTypeAreaGrabStart:        areaGrabStartSound,
-		SoundTypeAreaGrabEnd:          areaGrabEndSound,
+	b.Data = gamedata.NewData()
+	for typ, sprite := range b.DecorationSprites {
+		b.Data.DecorationDurations[typ] = len(sprite.Animation.Frames)
+	}
+	for typ, buf := range b.Sounds {
+		b.Data.SoundDurations[typ] = gamedata.SoundDuration(buf.Format().SampleRate, buf.Len())
 	}
 
 	return b, nil
@@ -0,0 +1,394 @@
+package chips
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/state"
+)
+
+// Catalog is loaded from data, so that chips can be rebalanced without a
+// recompile.
+type Catalog struct {
+	chips   []*state.Chip
+	byIndex map[int]*state.Chip
+	byName  map[string]*state.Chip
+}
+
+func (c *Catalog) Chips() []*state.Chip {
+	return slices.Clone(c.chips)
+}
+
+func (c *Catalog) ChipByIndex(index int) (*state.Chip, bool) {
+	chip, ok := c.byIndex[index]
+	return chip, ok
+}
+
+func (c *Catalog) ChipByName(name string) (*state.Chip, bool) {
+	chip, ok := c.byName[name]
+	return chip, ok
+}
+
+// Install registers the catalog's chips and unregisters any it leaves out. A
+// chip already registered is overwritten in place, so that anything pointing
+// at it picks up the change. Nothing is installed if DefaultFolder would
+// become illegal. It must be called before any match is set up.
+func (c *Catalog) Install() error {
+	defaultFolder := make(state.Folder, len(DefaultFolder))
+	for i, fc := range DefaultFolder {
+		chip, ok := c.byIndex[fc.Chip.Index]
+		if !ok {
+			return fmt.Errorf("default folder: chip %d (%s) is missing from the catalog", fc.Chip.Index, fc.Chip.Name)
+		}
+		defaultFolder[i] = state.FolderChip{Chip: chip, Code: fc.Code}
+	}
+	if err := defaultFolder.Validate(state.DefaultFolderRules); err != nil {
+		return fmt.Errorf("default folder: %w", err)
+	}
+
+	for _, chip := range state.RegisteredChips() {
+		if _, ok := c.byIndex[chip.Index]; !ok {
+			state.UnregisterChip(chip.Index)
+		}
+	}
+
+	for i, chip := range c.chips {
+		existing, ok := state.ChipByIndex(chip.Index)
+		if !ok {
+			state.RegisterChip(chip)
+			continue
+		}
+		*existing = *chip
+		c.chips[i] = existing
+		c.byIndex[chip.Index] = existing
+		c.byName[chip.Name] = existing
+	}
+	return nil
+}
+
+type catalogEntry struct {
+	Index    int           `json:"index"`
+	Name     string        `json:"name"`
+	Damage   int           `json:"damage"`
+	Element  string        `json:"element"`
+	Codes    string        `json:"codes"`
+	MB       int           `json:"mb"`
+	Class    string        `json:"class"`
+	Behavior behaviorEntry `json:"behavior"`
+}
+
+type behaviorEntry struct {
+	Kind   string          `json:"kind"`
+	Params json.RawMessage `json:"params"`
+}
+
+var elementNames = map[string]state.Element{
+	"null":   state.ElementNull,
+	"fire":   state.ElementFire,
+	"aqua":   state.ElementAqua,
+	"elec":   state.ElementElec,
+	"wood":   state.ElementWood,
+	"sword":  state.ElementSword,
+	"wind":   state.ElementWind,
+	"cursor": state.ElementCursor,
+	"break":  state.ElementBreak,
+}
+
+var classNames = map[string]state.ChipClass{
+	"standard": state.ChipClassStandard,
+	"mega":     state.ChipClassMega,
+	"giga":     state.ChipClassGiga,
+}
+
+var cannonStyleNames = map[string]behaviors.CannonStyle{
+	"cannon":   behaviors.CannonStyleCannon,
+	"hicannon": behaviors.CannonStyleHiCannon,
+	"mcannon":  behaviors.CannonStyleMCannon,
+}
+
+var swordStyleNames = map[string]behaviors.SwordStyle{
+	"sword": behaviors.SwordStyleSword,
+	"blade": behaviors.SwordStyleBlade,
+}
+
+var swordRangeNames = map[string]behaviors.SwordRange{
+	"short":    behaviors.SwordRangeShort,
+	"wide":     behaviors.SwordRangeWide,
+	"long":     behaviors.SwordRangeLong,
+	"verylong": behaviors.SwordRangeVeryLong,
+}
+
+var vulcanExplosionNames = map[string]gamedata.DecorationType{
+	"vulcan":      gamedata.DecorationTypeVulcanExplosion,
+	"supervulcan": gamedata.DecorationTypeSuperVulcanExplosion,
+}
+
+func lookup[T any](names map[string]T, what string, name string) (T, error) {
+	v, ok := names[name]
+	if !ok {
+		return v, fmt.Errorf("unknown %s %q", what, name)
+	}
+	return v, nil
+}
+
+// decodeParams rejects unknown parameters, so that typos don't go unnoticed.
+func decodeParams(raw json.RawMessage, params any) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	return dec.Decode(params)
+}
+
+var behaviorKinds = map[string]func(params json.RawMessage) (func(damage state.Damage) state.EntityBehavior, error){
+	"none": func(params json.RawMessage) (func(damage state.Damage) state.EntityBehavior, error) {
+		return func(damage state.Damage) state.EntityBehavior {
+			return nil
+		}, decodeParams(params, &struct{}{})
+	},
+	"cannon": func(params json.RawMessage) (func(damage state.Damage) state.EntityBehavior, error) {
+		var p struct {
+			Style string `json:"style"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		style, err := lookup(cannonStyleNames, "cannon style", p.Style)
+		if err != nil {
+			return nil, err
+		}
+		return func(damage state.Damage) state.EntityBehavior {
+			return &behaviors.Cannon{Style: style, Damage: damage}
+		}, nil
+	},
+	"airshot": func(params json.RawMessage) (func(damage state.Damage) state.EntityBehavior, error) {
+		return func(damage state.Damage) state.EntityBehavior {
+			return &behaviors.AirShot{Damage: damage}
+		}, decodeParams(params, &struct{}{})
+	},
+	"vulcan": func(params json.RawMessage) (func(damage state.Damage) state.EntityBehavior, error) {
+		var p struct {
+			Shots     int    `json:"shots"`
+			Explosion string `json:"explosion"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Shots < 1 {
+			return nil, fmt.Errorf("vulcan must fire at least 1 shot, got %d", p.Shots)
+		}
+		explosion, err := lookup(vulcanExplosionNames, "vulcan explosion", p.Explosion)
+		if err != nil {
+			return nil, err
+		}
+		return func(damage state.Damage) state.EntityBehavior {
+			return &behaviors.Vulcan{Shots: p.Shots, Damage: damage, ExplosionDecorationType: explosion}
+		}, nil
+	},
+	"sword": func(params json.RawMessage) (func(damage state.Damage) state.EntityBehavior, error) {
+		var p struct {
+			Style string `json:"style"`
+			Range string `json:"range"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		style, err := lookup(swordStyleNames, "sword style", p.Style)
+		if err != nil {
+			return nil, err
+		}
+		swordRange, err := lookup(swordRangeNames, "sword range", p.Range)
+		if err != nil {
+			return nil, err
+		}
+		return func(damage state.Damage) state.EntityBehavior {
+			return &behaviors.Sword{Damage: damage, Style: style, Range: swordRange}
+		}, nil
+	},
+	"recov": func(params json.RawMessage) (func(damage state.Damage) state.EntityBehavior, error) {
+		var p struct {
+			HP int `json:"hp"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return func(damage state.Damage) state.EntityBehavior {
+			return &behaviors.Recov{HP: p.HP}
+		}, nil
+	},
+	"windrack": func(params json.RawMessage) (func(damage state.Damage) state.EntityBehavior, error) {
+		return func(damage state.Damage) state.EntityBehavior {
+			return &behaviors.WindRack{Damage: damage}
+		}, decodeParams(params, &struct{}{})
+	},
+}
+
+func (ce catalogEntry) chip() (*state.Chip, error) {
+	chip := &state.Chip{
+		Index:      ce.Index,
+		Name:       ce.Name,
+		BaseDamage: ce.Damage,
+		MB:         ce.MB,
+	}
+
+	if ce.Name == "" || strings.ContainsAny(ce.Name, ", \t") {
+		return nil, fmt.Errorf("name %q must not be empty or contain commas or spaces", ce.Name)
+	}
+	if ce.MB < 0 {
+		return nil, fmt.Errorf("mb must not be negative, got %d", ce.MB)
+	}
+
+	var err error
+	if chip.Element, err = lookup(elementNames, "element", ce.Element); err != nil {
+		return nil, err
+	}
+	if chip.Class, err = lookup(classNames, "class", ce.Class); err != nil {
+		return nil, err
+	}
+
+	if ce.Codes == "" {
+		return nil, fmt.Errorf("must come in at least one code")
+	}
+	for _, r := range ce.Codes {
+		code := state.ChipCode(r)
+		if (r < 'A' || r > 'Z') && code != state.ChipCodeAny {
+			return nil, fmt.Errorf("code %q must be a letter from A to Z or %s", r, state.ChipCodeAny)
+		}
+		if chip.HasCode(code) {
+			return nil, fmt.Errorf("code %s listed more than once", code)
+		}
+		chip.Codes = append(chip.Codes, code)
+	}
+
+	makeMakeBehavior, ok := behaviorKinds[ce.Behavior.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown behavior kind %q", ce.Behavior.Kind)
+	}
+	if chip.MakeBehavior, err = makeMakeBehavior(ce.Behavior.Params); err != nil {
+		return nil, fmt.Errorf("%s behavior: %w", ce.Behavior.Kind, err)
+	}
+	chip.BehaviorSpec = ce.Behavior.Kind
+	if len(ce.Behavior.Params) > 0 {
+		var params bytes.Buffer
+		if err := json.Compact(&params, ce.Behavior.Params); err != nil {
+			return nil, err
+		}
+		chip.BehaviorSpec += " " + params.String()
+	}
+
+	return chip, nil
+}
+
+// ParseCatalog parses a JSON array of chips. See catalog.json for the
+// built-in catalog.
+func ParseCatalog(r io.Reader) (*Catalog, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var entries []catalogEntry
+	if err := dec.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to parse chip catalog: %w", err)
+	}
+
+	c := &Catalog{
+		byIndex: map[int]*state.Chip{},
+		byName:  map[string]*state.Chip{},
+	}
+	for _, entry := range entries {
+		chip, err := entry.chip()
+		if err != nil {
+			return nil, fmt.Errorf("chip %d (%s): %w", entry.Index, entry.Name, err)
+		}
+		if _, ok := c.byIndex[chip.Index]; ok {
+			return nil, fmt.Errorf("chip %d listed more than once", chip.Index)
+		}
+		if _, ok := c.byName[chip.Name]; ok {
+			return nil, fmt.Errorf("chip %q listed more than once", chip.Name)
+		}
+		c.chips = append(c.chips, chip)
+		c.byIndex[chip.Index] = chip
+		c.byName[chip.Name] = chip
+	}
+	slices.SortFunc(c.chips, func(a, b *state.Chip) bool {
+		return a.Index < b.Index
+	})
+	return c, nil
+}
+
+func LoadCatalog(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseCatalog(f)
+}
+
+//go:embed catalog.json
+var defaultCatalogJSON []byte
+
+// Default is installed on startup.
+var Default = func() *Catalog {
+	c, err := ParseCatalog(bytes.NewReader(defaultCatalogJSON))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+func mustChip(name string) *state.Chip {
+	chip, ok := Default.ChipByName(name)
+	if !ok {
+		panic(fmt.Sprintf("chip %q missing from the built-in catalog", name))
+	}
+	return chip
+}
+
+// The chips in the built-in catalog, for use from code.
+var (
+	Cannon   = mustChip("Cannon")
+	HiCannon = mustChip("HiCannon")
+	MCannon  = mustChip("M-Cannon")
+	AirShot  = mustChip("AirShot")
+
+	Vulcan1  = mustChip("Vulcan1")
+	Vulcan2  = mustChip("Vulcan2")
+	Vulcan3  = mustChip("Vulcan3")
+	SuprVulc = mustChip("SuprVulc")
+
+	Sword    = mustChip("Sword")
+	WideSwrd = mustChip("WideSwrd")
+	LongSwrd = mustChip("LongSwrd")
+	WideBlde = mustChip("WideBlde")
+	LongBlde = mustChip("LongBlde")
+	WindRack = mustChip("WindRack")
+
+	Wind = mustChip("Wind")
+	Fan  = mustChip("Fan")
+
+	Recov10  = mustChip("Recov10")
+	Recov30  = mustChip("Recov30")
+	Recov50  = mustChip("Recov50")
+	Recov80  = mustChip("Recov80")
+	Recov120 = mustChip("Recov120")
+	Recov150 = mustChip("Recov150")
+	Recov200 = mustChip("Recov200")
+	Recov300 = mustChip("Recov300")
+
+	AreaGrab = mustChip("AreaGrab")
+)
+
+func init() {
+	if err := Default.Install(); err != nil {
+		panic(err)
+	}
+}
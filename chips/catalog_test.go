@@ -0,0 +1,201 @@
+package chips
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/murkland/nbarena/state"
+)
+
+const testEntry = `{"index": 0, "name": "Cannon", "damage": 40, "element": "null", "codes": "A*", "mb": 12, "class": "standard", "behavior": {"kind": "cannon", "params": {"style": "cannon"}}}`
+
+func TestParseCatalog(t *testing.T) {
+	c, err := ParseCatalog(strings.NewReader(`[` + testEntry + `, {"index": 4, "name": "Vulcan1", "damage": 10, "element": "null", "codes": "C", "mb": 12, "class": "standard", "behavior": {"kind": "vulcan", "params": {"shots": 3, "explosion": "vulcan"}}}]`))
+	if err != nil {
+		t.Fatalf("ParseCatalog: %s", err)
+	}
+	chip, ok := c.ChipByName("Cannon")
+	if !ok {
+		t.Fatalf("expected Cannon in the catalog")
+	}
+	if chip.BaseDamage != 40 || chip.MB != 12 || !chip.HasCode('A') || !chip.HasCode(state.ChipCodeAny) || chip.HasCode('B') {
+		t.Errorf("Cannon parsed wrong: %+v", chip)
+	}
+	if chip.BehaviorSpec != `cannon {"style":"cannon"}` {
+		t.Errorf("expected the behavior spec to include its params, got %q", chip.BehaviorSpec)
+	}
+	if chip, ok := c.ChipByIndex(4); !ok || chip.Name != "Vulcan1" {
+		t.Errorf("expected Vulcan1 at index 4")
+	}
+}
+
+func TestParseCatalogRejects(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		entries string
+		want    string
+	}{
+		{"unknown field", `{"index": 0, "name": "Cannon", "damage": 40, "element": "null", "codes": "A", "mb": 12, "class": "standard", "power": 9000, "behavior": {"kind": "none"}}`, "unknown field"},
+		{"duplicate index", testEntry + `, {"index": 0, "name": "Cannon2", "damage": 40, "element": "null", "codes": "A", "mb": 12, "class": "standard", "behavior": {"kind": "none"}}`, "chip 0 listed more than once"},
+		{"duplicate name", testEntry + `, {"index": 1, "name": "Cannon", "damage": 40, "element": "null", "codes": "A", "mb": 12, "class": "standard", "behavior": {"kind": "none"}}`, `chip "Cannon" listed more than once`},
+		{"empty name", strings.Replace(testEntry, `"Cannon"`, `""`, 1), "must not be empty"},
+		{"name with a space", strings.Replace(testEntry, `"Cannon"`, `"Big Cannon"`, 1), "must not be empty or contain"},
+		{"negative mb", strings.Replace(testEntry, `"mb": 12`, `"mb": -1`, 1), "mb must not be negative"},
+		{"unknown element", strings.Replace(testEntry, `"null"`, `"plasma"`, 1), `unknown element "plasma"`},
+		{"unknown class", strings.Replace(testEntry, `"standard"`, `"ultra"`, 1), `unknown class "ultra"`},
+		{"no codes", strings.Replace(testEntry, `"A*"`, `""`, 1), "at least one code"},
+		{"lowercase code", strings.Replace(testEntry, `"A*"`, `"a"`, 1), "must be a letter"},
+		{"digit code", strings.Replace(testEntry, `"A*"`, `"A1"`, 1), "must be a letter"},
+		{"duplicate code", strings.Replace(testEntry, `"A*"`, `"ABA"`, 1), "listed more than once"},
+		{"unknown behavior kind", strings.Replace(testEntry, `"kind": "cannon"`, `"kind": "laser"`, 1), `unknown behavior kind "laser"`},
+		{"unknown behavior param", strings.Replace(testEntry, `{"style": "cannon"}`, `{"style": "cannon", "power": 9000}`, 1), "unknown field"},
+		{"unknown behavior param value", strings.Replace(testEntry, `{"style": "cannon"}`, `{"style": "laser"}`, 1), `unknown cannon style "laser"`},
+		{"params for a kind without any", strings.Replace(testEntry, `"kind": "cannon", "params": {"style": "cannon"}`, `"kind": "airshot", "params": {"style": "cannon"}`, 1), "unknown field"},
+		{"vulcan without shots", strings.Replace(testEntry, `"kind": "cannon", "params": {"style": "cannon"}`, `"kind": "vulcan", "params": {"shots": 0, "explosion": "vulcan"}`, 1), "at least 1 shot"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCatalog(strings.NewReader(`[` + tc.entries + `]`))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected an error containing %q, got %q", tc.want, err)
+			}
+		})
+	}
+}
+
+// restoreRegisteredChips undoes Install once the test is done.
+func restoreRegisteredChips(t *testing.T) {
+	saved := map[*state.Chip]state.Chip{}
+	for _, chip := range state.RegisteredChips() {
+		saved[chip] = *chip
+	}
+	t.Cleanup(func() {
+		for _, chip := range state.RegisteredChips() {
+			state.UnregisterChip(chip.Index)
+		}
+		for chip, saved := range saved {
+			*chip = saved
+			state.RegisterChip(chip)
+		}
+	})
+}
+
+func modifiedDefault(t *testing.T, modify func(entries []catalogEntry) []catalogEntry) *Catalog {
+	t.Helper()
+	var entries []catalogEntry
+	if err := json.Unmarshal(defaultCatalogJSON, &entries); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(modify(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseCatalog(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ParseCatalog: %s", err)
+	}
+	return c
+}
+
+func TestInstall(t *testing.T) {
+	restoreRegisteredChips(t)
+
+	c := modifiedDefault(t, func(entries []catalogEntry) []catalogEntry {
+		var modified []catalogEntry
+		for _, entry := range entries {
+			switch entry.Name {
+			case "Recov300":
+				continue
+			case "Cannon":
+				entry.Damage = 50
+			}
+			modified = append(modified, entry)
+		}
+		return append(modified, catalogEntry{Index: 300, Name: "Nothing", Element: "null", Codes: "Z", Class: "standard", Behavior: behaviorEntry{Kind: "none"}})
+	})
+	if err := c.Install(); err != nil {
+		t.Fatalf("Install: %s", err)
+	}
+
+	if _, ok := state.ChipByIndex(Recov300.Index); ok {
+		t.Errorf("expected a chip left out of the catalog to be unregistered")
+	}
+	if chip, ok := state.ChipByIndex(Cannon.Index); !ok || chip != Cannon || Cannon.BaseDamage != 50 {
+		t.Errorf("expected Cannon to be updated in place")
+	}
+	if chip, ok := state.ChipByName("Nothing"); !ok || chip.Index != 300 {
+		t.Errorf("expected a new chip to be registered")
+	}
+	if err := DefaultFolder.Validate(state.DefaultFolderRules); err != nil {
+		t.Errorf("expected the default folder to stay legal: %s", err)
+	}
+}
+
+func TestInstallRejectsBreakingDefaultFolder(t *testing.T) {
+	restoreRegisteredChips(t)
+
+	for _, tc := range []struct {
+		name   string
+		modify func(entry *catalogEntry) bool
+		want   error
+	}{
+		{
+			name: "missing chip",
+			modify: func(entry *catalogEntry) bool {
+				return entry.Name != "Cannon"
+			},
+		},
+		{
+			name: "missing code",
+			modify: func(entry *catalogEntry) bool {
+				if entry.Name == "Cannon" {
+					entry.Codes = "BCDE*"
+				}
+				return true
+			},
+			want: state.ErrIllegalFolder,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := modifiedDefault(t, func(entries []catalogEntry) []catalogEntry {
+				var modified []catalogEntry
+				for _, entry := range entries {
+					if tc.modify(&entry) {
+						modified = append(modified, entry)
+					}
+				}
+				// Recov300 must still be registered if installing fails.
+				return withoutChip(modified, "Recov300")
+			})
+
+			err := c.Install()
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if tc.want != nil && !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+			if _, ok := state.ChipByIndex(Recov300.Index); !ok {
+				t.Errorf("expected nothing to be installed")
+			}
+			if Cannon.BaseDamage != 40 || !Cannon.HasCode('A') {
+				t.Errorf("expected Cannon to be left alone")
+			}
+		})
+	}
+}
+
+func withoutChip(entries []catalogEntry, name string) []catalogEntry {
+	var kept []catalogEntry
+	for _, entry := range entries {
+		if entry.Name != name {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,34 @@
+package chips
+
+import "github.com/murkland/nbarena/state"
+
+func repeat(n int, fc state.FolderChip) state.Folder {
+	f := make(state.Folder, n)
+	for i := range f {
+		f[i] = fc
+	}
+	return f
+}
+
+func concat(folders ...state.Folder) state.Folder {
+	var f state.Folder
+	for _, folder := range folders {
+		f = append(f, folder...)
+	}
+	return f
+}
+
+// DefaultFolder is for players who don't bring their own.
+var DefaultFolder = concat(
+	repeat(4, state.FolderChip{Chip: Cannon, Code: 'A'}),
+	repeat(2, state.FolderChip{Chip: AirShot, Code: 'A'}),
+	repeat(2, state.FolderChip{Chip: Recov10, Code: 'A'}),
+	repeat(2, state.FolderChip{Chip: Recov120, Code: 'A'}),
+	repeat(2, state.FolderChip{Chip: WindRack, Code: 'A'}),
+	repeat(4, state.FolderChip{Chip: Sword, Code: 'S'}),
+	repeat(4, state.FolderChip{Chip: WideSwrd, Code: 'S'}),
+	repeat(4, state.FolderChip{Chip: LongSwrd, Code: 'S'}),
+	repeat(2, state.FolderChip{Chip: AreaGrab, Code: 'S'}),
+	repeat(2, state.FolderChip{Chip: WideBlde, Code: 'S'}),
+	repeat(2, state.FolderChip{Chip: Vulcan1, Code: '*'}),
+)
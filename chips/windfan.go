@@ -35,21 +35,3 @@ func makeWindFanOnUse(isFan bool) func(s *state.State, e *state.Entity, damage s
 
 	}
 }
-
-var Wind = &state.Chip{
-	Index:      128,
-	Name:       "Wind",
-	BaseDamage: 0,
-	MakeBehavior: func(damage state.Damage) state.EntityBehavior {
-		return nil
-	},
-}
-
-var Fan = &state.Chip{
-	Index:      129,
-	Name:       "Fan",
-	BaseDamage: 0,
-	MakeBehavior: func(damage state.Damage) state.EntityBehavior {
-		return nil
-	},
-}
@@ -0,0 +1,149 @@
+// Command batchsim runs headless matches between agents and reports how they
+// went.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/murkland/nbarena/agent"
+	"github.com/murkland/nbarena/batch"
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/state"
+)
+
+var (
+	matches            = flag.Int("matches", 1000, "number of matches to run")
+	seed               = flag.Int64("seed", 1, "seed to derive the seed of every match from")
+	offererAgent       = flag.String("offerer", "heuristic", "controller for the offerer: one of "+strings.Join(agent.Names, ", ")+", or script:<path> to play back the offerer's side of a replay")
+	answererAgent      = flag.String("answerer", "heuristic", "controller for the answerer, like -offerer")
+	offererFolderFlag  = flag.String("offerer_folder", "", "the chips in the offerer's folder, comma-separated, each a chip name and a code, e.g. \"Cannon A,Sword *\". If empty, a default folder is used")
+	answererFolderFlag = flag.String("answerer_folder", "", "the chips in the answerer's folder, like -offerer_folder")
+	chipCatalog        = flag.String("chip_catalog", "", "if set, path to a chip catalog to use in place of the built-in one")
+	maxTicks           = flag.Int("max_ticks", 60*60*5, "how long a match may go on for before it is called a draw")
+	parallelism        = flag.Int("parallelism", 0, "how many matches to run at once, or 0 for one per CPU")
+	jsonOutput         = flag.Bool("json", false, "if true, outputs the summary as JSON instead of a table")
+)
+
+func parseFolder(s string) (state.Folder, error) {
+	if s == "" {
+		return chips.DefaultFolder, nil
+	}
+	return state.ParseFolder(s)
+}
+
+func parseAgent(s string, answerer bool) (batch.NewAgentFunc, error) {
+	if path := strings.TrimPrefix(s, "script:"); path != s {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		intents, err := agent.ReadScript(f, answerer)
+		if err != nil {
+			return nil, err
+		}
+		return func(seed int64) agent.Agent {
+			return agent.NewScript(intents)
+		}, nil
+	}
+
+	if _, err := agent.New(s, 0); err != nil {
+		return nil, err
+	}
+	return func(seed int64) agent.Agent {
+		a, _ := agent.New(s, seed)
+		return a
+	}, nil
+}
+
+func printSummary(summary *batch.Summary) {
+	percent := func(n int) float64 {
+		return float64(n) * 100 / float64(summary.Matches)
+	}
+
+	fmt.Printf("matches:         %d\n", summary.Matches)
+	fmt.Printf("offerer wins:    %d (%.1f%%)\n", summary.OffererWins, percent(summary.OffererWins))
+	fmt.Printf("answerer wins:   %d (%.1f%%)\n", summary.AnswererWins, percent(summary.AnswererWins))
+	fmt.Printf("draws:           %d (%.1f%%)\n", summary.Draws, percent(summary.Draws))
+	fmt.Printf("average length:  %.1f ticks (%.1fs)\n", summary.AverageTicks(), summary.AverageTicks()/60)
+	fmt.Println()
+
+	names := make([]string, 0, len(summary.Chips))
+	for name := range summary.Chips {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return summary.Chips[names[i]].Damage > summary.Chips[names[j]].Damage
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "chip\tuses\tdamage\tdamage/use\tdamage/match\t")
+	for _, name := range names {
+		cs := summary.Chips[name]
+		perUse := 0.0
+		if cs.Uses > 0 {
+			perUse = float64(cs.Damage) / float64(cs.Uses)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%.1f\t\n", name, cs.Uses, cs.Damage, perUse, float64(cs.Damage)/float64(summary.Matches))
+	}
+	w.Flush()
+}
+
+func main() {
+	flag.Parse()
+	if *chipCatalog != "" {
+		catalog, err := chips.LoadCatalog(*chipCatalog)
+		if err != nil {
+			log.Fatalf("failed to load chip catalog: %s", err)
+		}
+		if err := catalog.Install(); err != nil {
+			log.Fatalf("failed to install chip catalog: %s", err)
+		}
+	}
+
+	newOfferer, err := parseAgent(*offererAgent, false)
+	if err != nil {
+		log.Fatalf("failed to parse offerer: %s", err)
+	}
+	newAnswerer, err := parseAgent(*answererAgent, true)
+	if err != nil {
+		log.Fatalf("failed to parse answerer: %s", err)
+	}
+
+	offererFolder, err := parseFolder(*offererFolderFlag)
+	if err != nil {
+		log.Fatalf("failed to parse offerer folder: %s", err)
+	}
+	answererFolder, err := parseFolder(*answererFolderFlag)
+	if err != nil {
+		log.Fatalf("failed to parse answerer folder: %s", err)
+	}
+
+	opts := batch.Options{
+		Data:           gamedata.NewData(),
+		OffererFolder:  offererFolder,
+		AnswererFolder: answererFolder,
+		MaxTicks:       *maxTicks,
+	}
+
+	summary := batch.Run(*matches, *seed, newOfferer, newAnswerer, opts, *parallelism)
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			log.Fatalf("failed to encode summary: %s", err)
+		}
+		return
+	}
+	printSummary(summary)
+}
@@ -0,0 +1,61 @@
+// Command gym serves a gym environment over stdin and stdout, so trainers in
+// other languages can drive matches. See package gym for the protocol.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/gym"
+	"github.com/murkland/nbarena/state"
+)
+
+var (
+	offererFolderFlag  = flag.String("offerer_folder", "", "the chips in the offerer's folder, comma-separated, each a chip name and a code, e.g. \"Cannon A,Sword *\". If empty, a default folder is used")
+	answererFolderFlag = flag.String("answerer_folder", "", "the chips in the answerer's folder, like -offerer_folder")
+	chipCatalog        = flag.String("chip_catalog", "", "if set, path to a chip catalog to use in place of the built-in one")
+	maxTicks           = flag.Int("max_ticks", 60*60*5, "how long an episode may go on for before it is cut off as a draw")
+)
+
+func parseFolder(s string) (state.Folder, error) {
+	if s == "" {
+		return chips.DefaultFolder, nil
+	}
+	return state.ParseFolder(s)
+}
+
+func main() {
+	flag.Parse()
+	if *chipCatalog != "" {
+		catalog, err := chips.LoadCatalog(*chipCatalog)
+		if err != nil {
+			log.Fatalf("failed to load chip catalog: %s", err)
+		}
+		if err := catalog.Install(); err != nil {
+			log.Fatalf("failed to install chip catalog: %s", err)
+		}
+	}
+
+	offererFolder, err := parseFolder(*offererFolderFlag)
+	if err != nil {
+		log.Fatalf("failed to parse offerer folder: %s", err)
+	}
+	answererFolder, err := parseFolder(*answererFolderFlag)
+	if err != nil {
+		log.Fatalf("failed to parse answerer folder: %s", err)
+	}
+
+	env := gym.NewEnv(gym.Options{
+		Data:           gamedata.NewData(),
+		OffererFolder:  offererFolder,
+		AnswererFolder: answererFolder,
+		MaxTicks:       *maxTicks,
+	})
+
+	if err := env.Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("failed to serve: %s", err)
+	}
+}
@@ -0,0 +1,29 @@
+// Command signalingserver serves signaling for clients run with -connect_addr.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/murkland/nbarena/signaling"
+)
+
+var (
+	listenAddr = flag.String("listen_addr", "localhost:12345", "address to listen on")
+)
+
+func main() {
+	flag.Parse()
+
+	lis, err := net.Listen("tcp", *listenAddr)
+	if err != nil {
+		log.Fatalf("failed to listen: %s", err)
+	}
+	log.Printf("listening on %s", lis.Addr())
+
+	if err := http.Serve(lis, signaling.NewServer().Handler()); err != nil {
+		log.Fatalf("failed to serve: %s", err)
+	}
+}
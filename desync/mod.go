@@ -0,0 +1,148 @@
+// Package desync compares per-tick state checksums with the remote's.
+package desync
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/murkland/nbarena/packets"
+)
+
+type Error struct {
+	Tick           uint32
+	LocalChecksum  uint64
+	RemoteChecksum uint64
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("desync at tick %d: local checksum %016x, remote checksum %016x", e.Tick, e.LocalChecksum, e.RemoteChecksum)
+}
+
+// Checker resends checksums until the remote acknowledges them, so it works
+// over lossy, unordered transports.
+type Checker struct {
+	local  map[uint32]uint64
+	remote map[uint32]uint64
+
+	// remoteNextTick is the first tick without a remote checksum yet.
+	remoteNextTick uint32
+	ackOwed        bool
+
+	unacked          []uint64
+	unackedStartTick uint32
+
+	desync *Error
+}
+
+func New() *Checker {
+	return &Checker{
+		local:            map[uint32]uint64{},
+		remote:           map[uint32]uint64{},
+		remoteNextTick:   1,
+		unackedStartTick: 1,
+	}
+}
+
+// AddLocal adds the local checksum for the tick after the last one added.
+func (c *Checker) AddLocal(tick uint32, checksum uint64) {
+	if c.desync == nil {
+		c.local[tick] = checksum
+	}
+	c.unacked = append(c.unacked, checksum)
+}
+
+func (c *Checker) ack(tick uint32) {
+	if tick < c.unackedStartTick {
+		return
+	}
+	n := int(tick - c.unackedStartTick + 1)
+	if n > len(c.unacked) {
+		n = len(c.unacked)
+	}
+	c.unacked = c.unacked[n:]
+	c.unackedStartTick += uint32(n)
+}
+
+// AddRemote drops packets that start past the next tick needed: they will be
+// resent.
+func (c *Checker) AddRemote(p packets.Checksums) error {
+	if int(p.NumChecksums) > len(p.Checksums) {
+		return fmt.Errorf("checksum packet has too many checksums: %d", p.NumChecksums)
+	}
+
+	c.ack(p.AckTick)
+	if p.NumChecksums == 0 {
+		return nil
+	}
+
+	// Our last acknowledgement may have been lost.
+	c.ackOwed = true
+	if p.StartTick > c.remoteNextTick {
+		return nil
+	}
+	for i := int(c.remoteNextTick - p.StartTick); i < int(p.NumChecksums); i++ {
+		if c.desync == nil {
+			c.remote[p.StartTick+uint32(i)] = p.Checksums[i]
+		}
+		c.remoteNextTick++
+	}
+	return nil
+}
+
+// Verify returns the first desync found, which is sticky.
+func (c *Checker) Verify() *Error {
+	if c.desync != nil {
+		return c.desync
+	}
+
+	ticks := make([]uint32, 0, len(c.remote))
+	for tick := range c.remote {
+		if _, ok := c.local[tick]; ok {
+			ticks = append(ticks, tick)
+		}
+	}
+	sort.Slice(ticks, func(i, j int) bool {
+		return ticks[i] < ticks[j]
+	})
+
+	for _, tick := range ticks {
+		local := c.local[tick]
+		remote := c.remote[tick]
+		delete(c.local, tick)
+		delete(c.remote, tick)
+		if local != remote {
+			c.desync = &Error{tick, local, remote}
+			log.Printf("%s", c.desync)
+			return c.desync
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) Desync() *Error {
+	return c.desync
+}
+
+// Packets returns every full packet of unacknowledged checksums, or just an
+// acknowledgement if one is owed.
+func (c *Checker) Packets() []packets.Checksums {
+	ackTick := c.remoteNextTick - 1
+
+	var ps []packets.Checksums
+	for i := 0; i+packets.ChecksumsPerPacket <= len(c.unacked); i += packets.ChecksumsPerPacket {
+		p := packets.Checksums{
+			AckTick:      ackTick,
+			StartTick:    c.unackedStartTick + uint32(i),
+			NumChecksums: packets.ChecksumsPerPacket,
+		}
+		copy(p.Checksums[:], c.unacked[i:])
+		ps = append(ps, p)
+	}
+	if len(ps) == 0 && c.ackOwed {
+		ps = append(ps, packets.Checksums{AckTick: ackTick})
+	}
+	c.ackOwed = false
+	return ps
+}
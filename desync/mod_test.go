@@ -0,0 +1,110 @@
+package desync
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/murkland/nbarena/packets"
+)
+
+// network reorders, drops and duplicates packets.
+type network struct {
+	rand     *rand.Rand
+	inFlight []packets.Checksums
+}
+
+func (n *network) send(ps []packets.Checksums) {
+	for _, p := range ps {
+		switch r := n.rand.Float64(); {
+		case r < 0.3:
+		case r < 0.4:
+			n.inFlight = append(n.inFlight, p, p)
+		default:
+			n.inFlight = append(n.inFlight, p)
+		}
+	}
+	n.rand.Shuffle(len(n.inFlight), func(i, j int) {
+		n.inFlight[i], n.inFlight[j] = n.inFlight[j], n.inFlight[i]
+	})
+}
+
+func (n *network) deliver(t *testing.T, c *Checker) {
+	t.Helper()
+	k := len(n.inFlight) / 2
+	if len(n.inFlight) == 1 {
+		k = 1
+	}
+	for _, p := range n.inFlight[:k] {
+		if err := c.AddRemote(p); err != nil {
+			t.Fatalf("AddRemote: %s", err)
+		}
+		c.Verify()
+	}
+	n.inFlight = n.inFlight[k:]
+}
+
+// exchange runs two checkers over lossy networks. b diverges from desyncTick
+// on, unless it is 0.
+func exchange(t *testing.T, seed int64, ticks int, desyncTick uint32) (*Checker, *Checker) {
+	t.Helper()
+
+	a, b := New(), New()
+	aToB := &network{rand: rand.New(rand.NewSource(seed))}
+	bToA := &network{rand: rand.New(rand.NewSource(seed + 1))}
+	for i := 1; i <= ticks+200; i++ {
+		if i <= ticks {
+			tick := uint32(i)
+			a.AddLocal(tick, uint64(tick))
+			if desyncTick != 0 && tick >= desyncTick {
+				b.AddLocal(tick, uint64(tick)+1)
+			} else {
+				b.AddLocal(tick, uint64(tick))
+			}
+			a.Verify()
+			b.Verify()
+		}
+		aToB.send(a.Packets())
+		bToA.send(b.Packets())
+		aToB.deliver(t, b)
+		bToA.deliver(t, a)
+	}
+	return a, b
+}
+
+func TestCheckerOverLossyNetwork(t *testing.T) {
+	const ticks = 20 * packets.ChecksumsPerPacket
+	for seed := int64(1); seed <= 5; seed++ {
+		a, b := exchange(t, seed, ticks, 0)
+		for name, c := range map[string]*Checker{"a": a, "b": b} {
+			if err := c.Desync(); err != nil {
+				t.Fatalf("seed %d: %s: unexpected %s", seed, name, err)
+			}
+			if len(c.local) != 0 || len(c.remote) != 0 || len(c.unacked) != 0 {
+				t.Errorf("seed %d: %s: expected every checksum to be compared and acknowledged, %d local, %d remote and %d unacknowledged left", seed, name, len(c.local), len(c.remote), len(c.unacked))
+			}
+		}
+	}
+}
+
+func TestCheckerDetectsDesyncOverLossyNetwork(t *testing.T) {
+	const ticks = 20 * packets.ChecksumsPerPacket
+	for seed := int64(1); seed <= 5; seed++ {
+		for _, desyncTick := range []uint32{1, 45, ticks - packets.ChecksumsPerPacket + 1} {
+			a, b := exchange(t, seed, ticks, desyncTick)
+			for name, c := range map[string]*Checker{"a": a, "b": b} {
+				err := c.Desync()
+				if err == nil {
+					t.Errorf("seed %d: %s: expected a desync at tick %d", seed, name, desyncTick)
+				} else if err.Tick != desyncTick {
+					t.Errorf("seed %d: %s: expected a desync at tick %d, got %d", seed, name, desyncTick, err.Tick)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckerRejectsTooManyChecksums(t *testing.T) {
+	if err := New().AddRemote(packets.Checksums{StartTick: 1, NumChecksums: packets.ChecksumsPerPacket + 1}); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -0,0 +1,183 @@
+// Package discovery announces sessions over UDP broadcast.
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/murkland/nbarena/packets"
+)
+
+const DefaultAddr = "255.255.255.255:12347"
+
+const (
+	announceInterval = 1 * time.Second
+	sessionTTL       = 3 * announceInterval
+)
+
+var magic = []byte("nbarena-discovery\n")
+
+type Announcement struct {
+	ProtocolVersion uint32
+	Name            string
+
+	// Transport is as for the -transport flag.
+	Transport string
+	// Port is the signaling server's when using WebRTC.
+	Port      int
+	SessionID string
+
+	// IsAnswerer is true if whoever joins must offer.
+	IsAnswerer bool
+}
+
+type Session struct {
+	Announcement
+	Addr string
+
+	lastSeen time.Time
+}
+
+func marshal(a Announcement) []byte {
+	buf, err := json.Marshal(a)
+	if err != nil {
+		panic(err)
+	}
+	return append(append([]byte(nil), magic...), buf...)
+}
+
+func unmarshal(raw []byte) (Announcement, error) {
+	var a Announcement
+	if !bytes.HasPrefix(raw, magic) {
+		return a, errors.New("not an announcement")
+	}
+	err := json.Unmarshal(raw[len(magic):], &a)
+	return a, err
+}
+
+func Announce(ctx context.Context, addr string, a Announcement) error {
+	raddr, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	buf := marshal(a)
+	for {
+		if _, err := conn.WriteTo(buf, raddr); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(announceInterval):
+		}
+	}
+}
+
+type Browser struct {
+	conn net.PacketConn
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	changed  chan struct{}
+}
+
+// Browse only lists sessions compatible with this build.
+func Browse(addr string) (*Browser, error) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp4", ":"+port)
+	if err != nil {
+		return nil, err
+	}
+	b := &Browser{
+		conn:     conn,
+		sessions: map[string]*Session{},
+		changed:  make(chan struct{}, 1),
+	}
+	go b.listen()
+	return b, nil
+}
+
+func (b *Browser) notify() {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Browser) listen() {
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := b.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		a, err := unmarshal(buf[:n])
+		if err != nil || a.ProtocolVersion != packets.ProtocolVersion {
+			continue
+		}
+		udpFrom, ok := from.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+
+		addr := net.JoinHostPort(udpFrom.IP.String(), strconv.Itoa(a.Port))
+		key := addr + "/" + a.SessionID
+
+		b.mu.Lock()
+		sess, ok := b.sessions[key]
+		if !ok || sess.Announcement != a {
+			b.sessions[key] = &Session{Announcement: a, Addr: addr}
+			sess = b.sessions[key]
+			b.notify()
+		}
+		sess.lastSeen = time.Now()
+		b.mu.Unlock()
+	}
+}
+
+func (b *Browser) Sessions() []Session {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]Session, 0, len(b.sessions))
+	for key, sess := range b.sessions {
+		if now.Sub(sess.lastSeen) > sessionTTL {
+			delete(b.sessions, key)
+			continue
+		}
+		sessions = append(sessions, *sess)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Name != sessions[j].Name {
+			return sessions[i].Name < sessions[j].Name
+		}
+		return sessions[i].Addr < sessions[j].Addr
+	})
+	return sessions
+}
+
+// Changed isn't signaled when sessions expire, so poll Sessions too.
+func (b *Browser) Changed() <-chan struct{} {
+	return b.changed
+}
+
+func (b *Browser) Close() error {
+	return b.conn.Close()
+}
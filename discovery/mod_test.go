@@ -0,0 +1,135 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/murkland/nbarena/packets"
+)
+
+func freeUDPPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestAnnounceAndBrowse(t *testing.T) {
+	addr := "127.0.0.1:" + strconv.Itoa(freeUDPPort(t))
+
+	b, err := Browse(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	announce := func(a Announcement) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Announce(ctx, addr, a)
+		}()
+	}
+
+	a := Announcement{
+		ProtocolVersion: packets.ProtocolVersion,
+		Name:            "player",
+		Transport:       "webrtc",
+		Port:            12345,
+		SessionID:       "session",
+		IsAnswerer:      true,
+	}
+	announce(a)
+
+	incompatible := a
+	incompatible.ProtocolVersion++
+	incompatible.SessionID = "incompatible"
+	announce(incompatible)
+
+	conn, err := net.Dial("udp4", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(`{"ProtocolVersion": 0, "SessionID": "garbage"}`))
+
+	select {
+	case <-b.Changed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for announcement")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	sessions := b.Sessions()
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1: %+v", len(sessions), sessions)
+	}
+	if sessions[0].Announcement != a {
+		t.Errorf("got announcement %+v, want %+v", sessions[0].Announcement, a)
+	}
+	if want := "127.0.0.1:12345"; sessions[0].Addr != want {
+		t.Errorf("got addr %s, want %s", sessions[0].Addr, want)
+	}
+
+	cancel()
+	wg.Wait()
+	time.Sleep(100 * time.Millisecond)
+	key := sessions[0].Addr + "/" + a.SessionID
+	backdate := func(d time.Duration) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.sessions[key].lastSeen = time.Now().Add(-d)
+	}
+
+	backdate(sessionTTL - time.Second)
+	if sessions := b.Sessions(); len(sessions) != 1 {
+		t.Errorf("expected the session to be listed until sessionTTL has passed, got %d sessions", len(sessions))
+	}
+	backdate(sessionTTL + time.Millisecond)
+	if sessions := b.Sessions(); len(sessions) != 0 {
+		t.Errorf("expected the session to be dropped after sessionTTL, got %+v", sessions)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	a := Announcement{
+		ProtocolVersion: packets.ProtocolVersion,
+		Name:            "player",
+		Transport:       "udp",
+		Port:            12345,
+		SessionID:       "session",
+	}
+
+	raw := marshal(a)
+	if !bytes.HasPrefix(raw, magic) {
+		t.Errorf("expected %q to start with the magic prefix", raw)
+	}
+	got, err := unmarshal(raw)
+	if err != nil {
+		t.Fatalf("unmarshal: %s", err)
+	}
+	if got != a {
+		t.Errorf("got %+v, want %+v", got, a)
+	}
+
+	for _, raw := range [][]byte{
+		nil,
+		raw[len(magic):],
+		append([]byte("nbarena-discoverx\n"), raw[len(magic):]...),
+		raw[:len(magic)+1],
+	} {
+		if _, err := unmarshal(raw); err == nil {
+			t.Errorf("expected %q to be rejected", raw)
+		}
+	}
+}
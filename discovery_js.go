@@ -0,0 +1,10 @@
+//go:build js
+
+package main
+
+import "context"
+
+// setUpDiscovery does nothing, as the browser can't send or receive broadcasts.
+func setUpDiscovery(ctx context.Context, isAnswerer bool) (bool, func(), error) {
+	return isAnswerer, func() {}, nil
+}
@@ -0,0 +1,210 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/murkland/nbarena/discovery"
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/signaling"
+)
+
+var (
+	discoveryMode = flag.String("discovery", "", "if set, finds the opponent on the local network: announce, to announce a session for someone else to join, or browse, to pick an announced session to join")
+	discoveryAddr = flag.String("discovery_addr", discovery.DefaultAddr, "broadcast address to announce sessions to and browse for them on")
+	playerName    = flag.String("name", defaultPlayerName(), "name to announce sessions under")
+)
+
+func defaultPlayerName() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "nbarena"
+	}
+	return name
+}
+
+// setUpDiscovery points the connection flags at the session. When joining,
+// the announcer decides whether we answer.
+func setUpDiscovery(ctx context.Context, isAnswerer bool) (bool, func(), error) {
+	switch *discoveryMode {
+	case "":
+		return isAnswerer, func() {}, nil
+	case "announce":
+		stop, err := announceSession(ctx, isAnswerer)
+		return isAnswerer, stop, err
+	case "browse":
+		sess, err := browseSessions(ctx)
+		if err != nil {
+			return false, nil, err
+		}
+		log.Printf("joining %s's session at %s", sess.Name, sess.Addr)
+		*transportType = sess.Transport
+		switch sess.Transport {
+		case "webrtc":
+			*signalingMode = "signor"
+			*connectAddr = "http://" + sess.Addr
+			*sessionID = sess.SessionID
+		default:
+			*lanAddr = sess.Addr
+		}
+		return !sess.IsAnswerer, func() {}, nil
+	}
+	return false, nil, fmt.Errorf("unknown discovery mode %q", *discoveryMode)
+}
+
+func announceSession(ctx context.Context, isAnswerer bool) (func(), error) {
+	a := discovery.Announcement{
+		ProtocolVersion: packets.ProtocolVersion,
+		Name:            *playerName,
+		Transport:       *transportType,
+		IsAnswerer:      isAnswerer,
+	}
+
+	switch *transportType {
+	case "webrtc":
+		if *signalingMode != "signor" {
+			return nil, errors.New("announcing a webrtc session requires signor signaling")
+		}
+
+		// Share WebRTC's fixed port, if any, so only one port needs to be opened.
+		signalingAddr := ":0"
+		if *webRTCListenAddr != "" {
+			signalingAddr = *webRTCListenAddr
+		}
+		lis, err := net.Listen("tcp", signalingAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen for signaling: %w", err)
+		}
+		go func() {
+			if err := http.Serve(lis, signaling.NewServer().Handler()); err != nil {
+				log.Printf("signaling server stopped: %s", err)
+			}
+		}()
+		a.Port = lis.Addr().(*net.TCPAddr).Port
+
+		var rawSessionID [8]byte
+		if _, err := rand.Read(rawSessionID[:]); err != nil {
+			return nil, err
+		}
+		*sessionID = hex.EncodeToString(rawSessionID[:])
+		*connectAddr = "http://" + net.JoinHostPort("localhost", strconv.Itoa(a.Port))
+	case "tcp", "udp":
+		if !isAnswerer {
+			return nil, fmt.Errorf("announcing a %s session requires -answer, as the answerer is the one that listens", *transportType)
+		}
+		_, port, err := net.SplitHostPort(*lanAddr)
+		if err != nil {
+			return nil, err
+		}
+		a.Port, err = strconv.Atoi(port)
+		if err != nil {
+			return nil, err
+		}
+		*lanAddr = ":" + port
+	default:
+		return nil, fmt.Errorf("unknown transport %q", *transportType)
+	}
+	a.SessionID = *sessionID
+
+	log.Printf("announcing session as %s to %s", a.Name, *discoveryAddr)
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		if err := discovery.Announce(ctx, *discoveryAddr, a); err != nil && !errors.Is(err, context.Canceled) {
+			log.Printf("failed to announce session: %s", err)
+		}
+	}()
+	return cancel, nil
+}
+
+func sessionKeys(sessions []discovery.Session) []string {
+	keys := make([]string, len(sessions))
+	for i, sess := range sessions {
+		keys[i] = sess.Addr + "/" + sess.SessionID
+	}
+	return keys
+}
+
+func printSessions(sessions []discovery.Session) {
+	if len(sessions) == 0 {
+		fmt.Printf("no sessions found yet, waiting for some to be announced...\n")
+		return
+	}
+	fmt.Printf("sessions:\n")
+	for i, sess := range sessions {
+		role := "offering"
+		if sess.IsAnswerer {
+			role = "answering"
+		}
+		fmt.Printf("  %d. %s (%s, %s) at %s\n", i+1, sess.Name, sess.Transport, role, sess.Addr)
+	}
+	fmt.Printf("enter the number of the session to join: ")
+}
+
+func browseSessions(ctx context.Context) (discovery.Session, error) {
+	b, err := discovery.Browse(*discoveryAddr)
+	if err != nil {
+		return discovery.Session{}, fmt.Errorf("failed to browse for sessions: %w", err)
+	}
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lines := make(chan stdinLine)
+	go func() {
+		for {
+			line, err := readStdinLine(ctx)
+			select {
+			case lines <- stdinLine{line, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var shown []discovery.Session
+	var shownKeys []string
+	for {
+		if sessions := b.Sessions(); shownKeys == nil || strings.Join(sessionKeys(sessions), "\n") != strings.Join(shownKeys, "\n") {
+			shown = sessions
+			shownKeys = sessionKeys(sessions)
+			printSessions(shown)
+		}
+
+		select {
+		case <-ctx.Done():
+			return discovery.Session{}, ctx.Err()
+		case <-b.Changed():
+		case <-ticker.C:
+		case l := <-lines:
+			if l.err != nil {
+				return discovery.Session{}, l.err
+			}
+			i, err := strconv.Atoi(strings.TrimSpace(l.line))
+			if err != nil || i < 1 || i > len(shown) {
+				fmt.Printf("no such session, enter a number from the list: ")
+				continue
+			}
+			return shown[i-1], nil
+		}
+	}
+}
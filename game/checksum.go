@@ -0,0 +1,21 @@
+package game
+
+import (
+	"context"
+
+	"github.com/murkland/nbarena/packets"
+)
+
+func (g *Game) sendChecksums(ctx context.Context) error {
+	if g.reconnecting {
+		// Keep them until there's a connection to send them over.
+		return nil
+	}
+	for _, p := range g.cs.checksums.Packets() {
+		p.Match = g.matchNumber
+		if err := packets.Send(ctx, g.conn, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/transport"
+	"golang.org/x/sync/errgroup"
+)
+
+var ErrDisconnected = errors.New("disconnected")
+
+type ReconnectFunc func(ctx context.Context) (transport.Transport, error)
+
+// waitingForOpponentDelay keeps ordinary jitter from making the message flicker.
+const waitingForOpponentDelay = 500 * time.Millisecond
+
+// recv fails with ErrDisconnected if the remote has been silent for too long.
+// Pings are sent every second, so a healthy connection never is.
+func (g *Game) recv(ctx context.Context, conn transport.Transport) (packets.Packet, error) {
+	if g.disconnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.disconnectTimeout)
+		defer cancel()
+	}
+	packet, err := packets.Recv(ctx, conn)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return nil, fmt.Errorf("%w: nothing received for %s", ErrDisconnected, g.disconnectTimeout)
+	}
+	return packet, err
+}
+
+func (g *Game) runConn(ctx context.Context, conn transport.Transport) error {
+	errg, ctx := errgroup.WithContext(ctx)
+
+	errg.Go(func() error {
+		return g.handleConn(ctx, conn)
+	})
+
+	errg.Go(func() error {
+		return g.sendPings(ctx, conn)
+	})
+
+	return errg.Wait()
+}
+
+func (g *Game) RunBackgroundTasks(ctx context.Context) error {
+	g.csMu.Lock()
+	conn := g.conn
+	g.csMu.Unlock()
+
+	for {
+		err := g.runConn(ctx, conn)
+		if ctx.Err() != nil || g.reconnect == nil {
+			return err
+		}
+
+		log.Printf("connection lost: %s, reconnecting", err)
+		g.csMu.Lock()
+		g.reconnecting = true
+		g.csMu.Unlock()
+
+		conn.Close()
+		conn, err = g.reconnect(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to reconnect: %w", err)
+		}
+
+		// Both sides resend everything that hasn't been acknowledged.
+		g.csMu.Lock()
+		log.Printf("reconnected, resuming from tick %d", g.cs.rollback.CommittedTick())
+		g.conn = conn
+		g.reconnecting = false
+		g.csMu.Unlock()
+	}
+}
+
+func (g *Game) connStatusText() string {
+	if g.reconnecting {
+		return "RECONNECTING..."
+	}
+	if !g.stalledSince.IsZero() && time.Since(g.stalledSince) >= waitingForOpponentDelay {
+		return "WAITING FOR OPPONENT..."
+	}
+	return ""
+}
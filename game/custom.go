@@ -0,0 +1,155 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/draw/styledtext"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+)
+
+const (
+	customGaugeWidth  = 96
+	customGaugeHeight = 6
+
+	customSlotWidth  = 18
+	customPanelWidth = (state.HandSize+1)*customSlotWidth + 4
+	customPanelY     = 96
+)
+
+var (
+	pixelImage = func() *ebiten.Image {
+		img := ebiten.NewImage(1, 1)
+		img.Fill(color.White)
+		return img
+	}()
+
+	customPanelImage = func() *ebiten.Image {
+		img := ebiten.NewImage(customPanelWidth, 40)
+		img.Fill(color.RGBA{0xf8, 0xff, 0xff, 0xff})
+		img.SubImage(image.Rect(1, 1, customPanelWidth-1, 39)).(*ebiten.Image).Fill(color.RGBA{0x39, 0x52, 0x6b, 0xff})
+		return img
+	}()
+)
+
+func rectAppearance(x int, y int, w int, h int, c color.RGBA) draw.Node {
+	node := &draw.OptionsNode{}
+	node.Opts.GeoM.Scale(float64(w), float64(h))
+	node.Opts.GeoM.Translate(float64(x), float64(y))
+	node.Opts.ColorM.Scale(float64(c.R)/0xff, float64(c.G)/0xff, float64(c.B)/0xff, float64(c.A)/0xff)
+	node.Children = append(node.Children, &draw.ImageNode{Image: pixelImage})
+	return node
+}
+
+func (g *Game) customGaugeAppearance(m *state.Match) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	x := (sceneWidth - customGaugeWidth) / 2
+	rootNode.Children = append(rootNode.Children, rectAppearance(x-1, 3, customGaugeWidth+2, customGaugeHeight+2, color.RGBA{0xf8, 0xff, 0xff, 0xff}))
+	rootNode.Children = append(rootNode.Children, rectAppearance(x, 4, customGaugeWidth, customGaugeHeight, color.RGBA{0x39, 0x52, 0x6b, 0xff}))
+
+	fill := color.RGBA{0x52, 0x84, 0xff, 0xff}
+	if m.IsCustomGaugeFull() && (g.cs.dirtyState.ElapsedTime/8)%2 == 0 {
+		fill = color.RGBA{0xff, 0xde, 0x00, 0xff}
+	}
+	rootNode.Children = append(rootNode.Children, rectAppearance(x, 4, int(m.CustomGauge)*customGaugeWidth/int(state.CustomGaugeTicks), customGaugeHeight, fill))
+	return rootNode
+}
+
+// customScreenAppearance lays the hand out right to left from x if mirrored,
+// for a fighter on the right seen from the offerer's side.
+func (g *Game) customScreenAppearance(e *state.Entity, x int, mirrored bool) draw.Node {
+	cs := &e.Custom
+
+	rootNode := &draw.OptionsNode{}
+	rootNode.Opts.GeoM.Translate(float64(x), customPanelY)
+	if mirrored {
+		rootNode.Opts.GeoM.Translate(-customPanelWidth, 0)
+	}
+	rootNode.Children = append(rootNode.Children, &draw.ImageNode{Image: customPanelImage})
+
+	slotX := func(i int) int {
+		if mirrored {
+			return customPanelWidth - 2 - (i+1)*customSlotWidth
+		}
+		return 2 + i*customSlotWidth
+	}
+
+	if !cs.Confirmed {
+		rootNode.Children = append(rootNode.Children, rectAppearance(slotX(cs.Cursor), 2, customSlotWidth, customSlotWidth, color.RGBA{0xff, 0xde, 0x00, 0xff}))
+	}
+
+	for i, chip := range cs.Hand {
+		chipNode := &draw.OptionsNode{}
+		chipNode.Opts.GeoM.Translate(float64(slotX(i)+2), 4)
+		if cs.IsSelected(i) || (!cs.CanSelect(i) && !cs.Confirmed) {
+			chipNode.Opts.ColorM.Scale(0.5, 0.5, 0.5, 1.0)
+		}
+		chipNode.Children = append(chipNode.Children, draw.ImageWithFrame(g.bundle.ChipIconSprites.Image, g.bundle.ChipIconSprites.Animations[chip.Chip.Index].Frames[0]))
+		rootNode.Children = append(rootNode.Children, chipNode)
+
+		if cs.IsSelected(i) {
+			continue
+		}
+		codeNode := &draw.OptionsNode{}
+		codeNode.Opts.GeoM.Translate(float64(slotX(i)+customSlotWidth/2), 28)
+		codeNode.Children = append(codeNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: chip.Code.String(), Background: chipCodeTextGradient}}, styledtext.AnchorCenter|styledtext.AnchorMiddle, g.bundle.TallFont, styledtext.BorderNone, color.RGBA{}))
+		rootNode.Children = append(rootNode.Children, codeNode)
+	}
+
+	for n, i := range cs.Selected {
+		orderNode := &draw.OptionsNode{}
+		orderNode.Opts.GeoM.Translate(float64(slotX(i)+customSlotWidth/2), 28)
+		orderNode.Children = append(orderNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: strconv.Itoa(n + 1), Background: whiteTextGradient}}, styledtext.AnchorCenter|styledtext.AnchorMiddle, g.bundle.TallFont, styledtext.BorderNone, color.RGBA{}))
+		rootNode.Children = append(rootNode.Children, orderNode)
+	}
+
+	okNode := &draw.OptionsNode{}
+	okNode.Opts.GeoM.Translate(float64(slotX(len(cs.Hand))+customSlotWidth/2), 11)
+	okText := "OK"
+	if cs.Confirmed {
+		okText = "READY"
+	}
+	okNode.Children = append(okNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: okText, Background: whiteTextGradient}}, styledtext.AnchorCenter|styledtext.AnchorMiddle, g.bundle.TallFont, styledtext.BorderRightBottom, color.RGBA{0, 0, 0, 0xff}))
+	rootNode.Children = append(rootNode.Children, okNode)
+
+	if !cs.Confirmed && cs.Cursor < len(cs.Hand) {
+		anchor := styledtext.AnchorLeft | styledtext.AnchorBottom
+		plaqueX := 0
+		if mirrored {
+			anchor = styledtext.AnchorRight | styledtext.AnchorBottom
+			plaqueX = customPanelWidth
+		}
+		plaqueNode := &draw.OptionsNode{}
+		plaqueNode.Opts.GeoM.Translate(float64(plaqueX), -2)
+		plaqueNode.Children = append(plaqueNode.Children, chipPlaqueApperance(g.bundle, cs.Hand[cs.Cursor].Chip, 0, false, anchor))
+		rootNode.Children = append(rootNode.Children, plaqueNode)
+	}
+
+	return rootNode
+}
+
+// customUIAppearance only shows the player's own custom screen, unless both
+// players are looking at the same screen.
+func (g *Game) customUIAppearance() draw.Node {
+	m := g.cs.dirtyState.Match
+	rootNode := &draw.OptionsNode{}
+
+	switch m.Phase {
+	case state.MatchPhaseBattle:
+		rootNode.Children = append(rootNode.Children, g.customGaugeAppearance(m))
+	case state.MatchPhaseCustom:
+		switch g.selfSide() {
+		case match.SideNone:
+			rootNode.Children = append(rootNode.Children, g.customScreenAppearance(g.cs.dirtyState.Entities[g.cs.OffererEntityID], 2, false))
+			rootNode.Children = append(rootNode.Children, g.customScreenAppearance(g.cs.dirtyState.Entities[g.cs.AnswererEntityID], sceneWidth-2, true))
+		default:
+			rootNode.Children = append(rootNode.Children, g.customScreenAppearance(g.cs.dirtyState.Entities[g.cs.SelfEntityID()], 2, false))
+		}
+	}
+
+	return rootNode
+}
@@ -47,10 +47,16 @@ func (g *Game) makeDebugDrawNode() draw.Node {
 		entity = g.cs.dirtyState.Entities[g.cs.AnswererEntityID]
 	}
 
+	var rollbackStats string
+	if g.cs.rollback != nil {
+		stats := g.cs.rollback.Stats()
+		rollbackStats = fmt.Sprintf("mispredictions: %d/%d, rollbacks: %d (max depth: %d, total depth: %d)\n", stats.Mispredictions, stats.PredictedTicks, stats.Rollbacks, stats.MaxRollbackDepth, stats.TotalRollbackDepth)
+	}
+
 	delay := g.medianDelay()
 	rootNode.Children = append(rootNode.Children, &draw.TextNode{
 		Face: mplusNormalFont,
-		Text: fmt.Sprintf("delay: %6.2fms\n%s", float64(delay)/float64(time.Millisecond), litter.Options{
+		Text: fmt.Sprintf("delay: %6.2fms\n%s%s", float64(delay)/float64(time.Millisecond), rollbackStats, litter.Options{
 			HidePrivateFields: false,
 		}.Sdump(entity)),
 	})
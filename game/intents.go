@@ -0,0 +1,46 @@
+package game
+
+import (
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/state"
+)
+
+// unackedIntents keeps local intents to resend until the remote acknowledges
+// them.
+type unackedIntents struct {
+	startTick uint32
+	intents   []state.Intent
+}
+
+func newUnackedIntents() *unackedIntents {
+	return &unackedIntents{startTick: 1}
+}
+
+func (u *unackedIntents) push(intent state.Intent) {
+	u.intents = append(u.intents, intent)
+}
+
+func (u *unackedIntents) ack(tick uint32) {
+	if tick < u.startTick {
+		return
+	}
+	n := int(tick - u.startTick + 1)
+	if n > len(u.intents) {
+		n = len(u.intents)
+	}
+	u.intents = u.intents[n:]
+	u.startTick += uint32(n)
+}
+
+func (u *unackedIntents) ackedThrough(tick uint32) bool {
+	return u.startTick > tick
+}
+
+func (u *unackedIntents) packet(ackTick uint32) packets.Intent {
+	p := packets.Intent{
+		AckTick:   ackTick,
+		StartTick: u.startTick,
+	}
+	p.NumIntents = uint8(copy(p.Intents[:], u.intents))
+	return p
+}
@@ -0,0 +1,137 @@
+package game
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/murkland/nbarena/agent"
+	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/input"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+)
+
+type sourceAgent struct {
+	source input.Source
+}
+
+func (a sourceAgent) Intent(s *state.State, self state.EntityID) state.Intent {
+	return a.source.Intent()
+}
+
+type localState struct {
+	offerer  agent.Agent
+	answerer agent.Agent
+
+	tick int
+}
+
+// rematchingAgent always agrees to a rematch, so the player isn't left waiting.
+type rematchingAgent struct {
+	agent.Agent
+}
+
+func (a rematchingAgent) Intent(s *state.State, self state.EntityID) state.Intent {
+	if s.Match.Phase == state.MatchPhaseOver {
+		// Confirm goes by rising edges, so let go of it every other tick.
+		return state.Intent{Confirm: s.Match.PhaseElapsedTime%2 == 0}
+	}
+	return a.Agent.Intent(s, self)
+}
+
+func newLocal(b *bundle.Bundle, randSeed []byte, bestOf int, offererFolder state.Folder, answererFolder state.Folder, offerer agent.Agent, answerer agent.Agent, replayW io.Writer) (*Game, error) {
+	cs, err := newClientState(randSeed, bestOf, offererFolder, answererFolder, replayW)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newGame(b, cs)
+	g.local = &localState{
+		offerer:  offerer,
+		answerer: answerer,
+	}
+	g.bestOf = bestOf
+	g.offererFolder = offererFolder
+	g.answererFolder = answererFolder
+	return g, nil
+}
+
+// NewLocal creates a game with both players on this machine. replayW may be
+// nil.
+func NewLocal(b *bundle.Bundle, randSeed []byte, bestOf int, offererFolder state.Folder, answererFolder state.Folder, offererInput string, answererInput string, inputConfig *input.Config, replayW io.Writer) (*Game, error) {
+	offererSource, err := inputConfig.Source(offererInput)
+	if err != nil {
+		return nil, err
+	}
+	answererSource, err := inputConfig.Source(answererInput)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := newLocal(b, randSeed, bestOf, offererFolder, answererFolder, sourceAgent{offererSource}, sourceAgent{answererSource}, replayW)
+	if err != nil {
+		return nil, err
+	}
+	g.inputConfig = inputConfig
+	g.inputProfile = offererInput
+	return g, nil
+}
+
+// NewVersusAgent creates a game against an agent in the answerer's place.
+// replayW may be nil.
+func NewVersusAgent(b *bundle.Bundle, randSeed []byte, bestOf int, offererFolder state.Folder, answererFolder state.Folder, inputConfig *input.Config, answerer agent.Agent, replayW io.Writer) (*Game, error) {
+	offererSource, err := inputConfig.Source(input.DefaultProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := newLocal(b, randSeed, bestOf, offererFolder, answererFolder, sourceAgent{offererSource}, rematchingAgent{answerer}, replayW)
+	if err != nil {
+		return nil, err
+	}
+	g.inputConfig = inputConfig
+	g.inputProfile = input.DefaultProfile
+	return g, nil
+}
+
+func (g *Game) updateLocal() error {
+	g.csMu.Lock()
+	defer g.csMu.Unlock()
+
+	s := g.cs.committedState
+
+	// Both players look at the screen from the offerer's side, so the answerer's
+	// directions are not flipped.
+	offererIntent := g.local.offerer.Intent(s, g.cs.OffererEntityID)
+	answererIntent := g.local.answerer.Intent(s, g.cs.AnswererEntityID)
+
+	s.Entities[g.cs.OffererEntityID].Intent = offererIntent
+	s.Entities[g.cs.AnswererEntityID].Intent = answererIntent
+	step.Step(s, g.bundle.Data)
+	g.local.tick++
+
+	if g.cs.replayWriter != nil {
+		if err := g.cs.replayWriter.WriteIntents(offererIntent, answererIntent); err != nil {
+			return err
+		}
+	}
+	if g.cs.spectators != nil {
+		g.cs.spectators.Commit(g.local.tick, offererIntent, answererIntent, s)
+	}
+
+	if match.RematchAgreed(s) {
+		// Nobody else has a say in the seed.
+		seed := make([]byte, 32)
+		if _, err := rand.Read(seed); err != nil {
+			return err
+		}
+		cs, err := g.nextClientState(seed)
+		if err != nil {
+			return err
+		}
+		g.cs = cs
+		g.local.tick = 0
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/draw/styledtext"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+)
+
+// selfSide is SideNone in a local match, a replay or while spectating, where
+// the result is shown for both sides.
+func (g *Game) selfSide() match.Side {
+	if g.local != nil || g.replayPlayer != nil || g.watcher != nil {
+		return match.SideNone
+	}
+	if g.cs.isAnswerer {
+		return match.SideAnswerer
+	}
+	return match.SideOfferer
+}
+
+func (g *Game) resultText(winner match.Side) string {
+	if winner == match.SideNone {
+		return "DRAW"
+	}
+	switch g.selfSide() {
+	case match.SideNone:
+		if winner == match.SideOfferer {
+			return "LEFT WINS"
+		}
+		return "RIGHT WINS"
+	case winner:
+		return "YOU WIN"
+	}
+	return "YOU LOSE"
+}
+
+// scoreText puts the player's own rounds first.
+func (g *Game) scoreText(m *state.Match) string {
+	if g.selfSide() == match.SideAnswerer {
+		return fmt.Sprintf("%d - %d", m.AnswererWins, m.OffererWins)
+	}
+	return fmt.Sprintf("%d - %d", m.OffererWins, m.AnswererWins)
+}
+
+func (g *Game) centeredTextAppearance(text string, y int) draw.Node {
+	textNode := &draw.OptionsNode{}
+	textNode.Opts.GeoM.Translate(float64(sceneWidth/2), float64(y))
+	textNode.Children = append(textNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: text, Background: whiteTextGradient}}, styledtext.AnchorCenter|styledtext.AnchorMiddle, g.bundle.TallFont, styledtext.BorderRightBottom, color.RGBA{0, 0, 0, 0xff}))
+	return textNode
+}
+
+func (g *Game) matchUIAppearance() draw.Node {
+	m := g.cs.dirtyState.Match
+	rootNode := &draw.OptionsNode{}
+
+	switch m.Phase {
+	case state.MatchPhaseIntro:
+		rootNode.Children = append(rootNode.Children, g.centeredTextAppearance(fmt.Sprintf("ROUND %d", m.Round), sceneHeight/2))
+
+	case state.MatchPhaseKO:
+		rootNode.Children = append(rootNode.Children, g.centeredTextAppearance("DELETED!", sceneHeight/2))
+
+	case state.MatchPhaseResult:
+		winner, _ := match.RoundWinner(g.cs.dirtyState)
+		rootNode.Children = append(rootNode.Children, g.centeredTextAppearance(g.resultText(winner), sceneHeight/2-8))
+		rootNode.Children = append(rootNode.Children, g.centeredTextAppearance(g.scoreText(m), sceneHeight/2+8))
+
+	case state.MatchPhaseOver:
+		winner, _ := match.Winner(g.cs.dirtyState)
+		rootNode.Children = append(rootNode.Children, g.centeredTextAppearance(g.resultText(winner), sceneHeight/2-8))
+		rootNode.Children = append(rootNode.Children, g.centeredTextAppearance(g.scoreText(m), sceneHeight/2+8))
+
+		if g.replayPlayer == nil && g.watcher == nil {
+			text := "CONFIRM: REMATCH"
+			if (g.selfSide() == match.SideOfferer && m.OffererWantsRematch) || (g.selfSide() == match.SideAnswerer && m.AnswererWantsRematch) {
+				text = "WAITING FOR OPPONENT..."
+			}
+			rootNode.Children = append(rootNode.Children, g.centeredTextAppearance(text, sceneHeight/2+32))
+		}
+	}
+
+	return rootNode
+}
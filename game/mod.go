@@ -2,10 +2,12 @@ package game
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"image"
 	"image/color"
+	"io"
 	"strconv"
 	"sync"
 	"time"
@@ -16,21 +18,24 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/keegancsmith/nth"
-	"github.com/murkland/ctxwebrtc"
 	"github.com/murkland/nbarena/behaviors"
 	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/desync"
 	"github.com/murkland/nbarena/draw"
 	"github.com/murkland/nbarena/draw/styledtext"
 	"github.com/murkland/nbarena/input"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/netsyncrand"
 	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/render"
+	"github.com/murkland/nbarena/replay"
+	"github.com/murkland/nbarena/rollback"
 	"github.com/murkland/nbarena/sound"
+	"github.com/murkland/nbarena/spectate"
 	"github.com/murkland/nbarena/state"
-	"github.com/murkland/nbarena/step"
+	"github.com/murkland/nbarena/transport"
 	"github.com/murkland/ringbuf"
-	"github.com/murkland/syncrand"
 	"golang.org/x/exp/constraints"
-	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -51,8 +56,15 @@ type clientState struct {
 	committedState *state.State
 	dirtyState     *state.State
 
-	incomingIntents *ringbuf.RingBuf[state.Intent]
-	outgoingIntents *ringbuf.RingBuf[state.Intent]
+	rollback *rollback.Engine
+
+	checksums *desync.Checker
+
+	unackedIntents *unackedIntents
+
+	replayWriter *replay.Writer
+
+	spectators *spectate.Broadcaster
 }
 
 func (cs *clientState) SelfEntityID() state.EntityID {
@@ -69,71 +81,47 @@ func (cs *clientState) OpponentEntityID() state.EntityID {
 	return cs.AnswererEntityID
 }
 
-func (cs *clientState) fastForward(b *bundle.Bundle) error {
-	n := cs.outgoingIntents.Used()
-	if cs.incomingIntents.Used() < n {
-		n = cs.incomingIntents.Used()
+func (cs *clientState) commit(tick int, localIntent state.Intent, remoteIntent state.Intent, s *state.State) error {
+	offererIntent, answererIntent := localIntent, remoteIntent
+	if cs.isAnswerer {
+		offererIntent, answererIntent = remoteIntent, localIntent
 	}
-
-	ourIntents := make([]state.Intent, cs.outgoingIntents.Used())
-	if err := cs.outgoingIntents.Peek(ourIntents, 0); err != nil {
-		return err
+	if cs.replayWriter != nil {
+		if err := cs.replayWriter.WriteIntents(offererIntent, answererIntent); err != nil {
+			return err
+		}
 	}
-	if err := cs.outgoingIntents.Advance(n); err != nil {
-		return err
+	if cs.spectators != nil {
+		cs.spectators.Commit(tick, offererIntent, answererIntent, s)
 	}
+	cs.checksums.AddLocal(uint32(tick), s.Checksum())
+	return nil
+}
 
-	theirIntents := make([]state.Intent, n)
-	if err := cs.incomingIntents.Peek(theirIntents, 0); err != nil {
-		return err
-	}
-	if err := cs.incomingIntents.Advance(n); err != nil {
+func (cs *clientState) syncStates() {
+	cs.committedState = cs.rollback.CommittedState()
+	cs.dirtyState = cs.rollback.HeadState()
+	cs.checksums.Verify()
+}
+
+func (cs *clientState) addLocalIntent(intent state.Intent) error {
+	if err := cs.rollback.AddLocalIntent(intent); err != nil {
 		return err
 	}
+	cs.syncStates()
+	return nil
+}
 
-	for i := 0; i < n; i++ {
-		ourIntent := ourIntents[i]
-		theirIntent := theirIntents[i]
-
-		var offererIntent state.Intent
-		var answererIntent state.Intent
-		if cs.isAnswerer {
-			offererIntent = theirIntent
-			answererIntent = ourIntent
-		} else {
-			offererIntent = ourIntent
-			answererIntent = theirIntent
-		}
-
-		cs.committedState.Entities[cs.OffererEntityID].Intent = offererIntent
-		cs.committedState.Entities[cs.AnswererEntityID].Intent = answererIntent
-		step.Step(cs.committedState, b)
-	}
-
-	cs.dirtyState = cs.committedState.Clone()
-	for _, intent := range ourIntents[n:] {
-		var offererIntent state.Intent
-		var answererIntent state.Intent
-		if cs.isAnswerer {
-			offererIntent = cs.committedState.Entities[cs.OffererEntityID].LastIntent
-			offererIntent.Direction = state.DirectionNone
-			answererIntent = intent
-		} else {
-			offererIntent = intent
-			answererIntent = cs.committedState.Entities[cs.AnswererEntityID].LastIntent
-			answererIntent.Direction = state.DirectionNone
-		}
-
-		cs.dirtyState.Entities[cs.OffererEntityID].Intent = offererIntent
-		cs.dirtyState.Entities[cs.AnswererEntityID].Intent = answererIntent
-		step.Step(cs.dirtyState, b)
+func (cs *clientState) addRemoteIntent(intent state.Intent) error {
+	if err := cs.rollback.AddRemoteIntent(intent); err != nil {
+		return err
 	}
-
+	cs.syncStates()
 	return nil
 }
 
 type Game struct {
-	dc *ctxwebrtc.DataChannel
+	conn transport.Transport
 
 	compositor *draw.Compositor
 
@@ -151,11 +139,46 @@ type Game struct {
 
 	delayRingbuf   *ringbuf.RingBuf[time.Duration]
 	delayRingbufMu sync.RWMutex
+
+	replayPlayer *replay.Player
+
+	watcher *spectate.Watcher
+
+	local *localState
+
+	input        input.Source
+	inputConfig  *input.Config
+	inputProfile string
+	rebinder     *input.Rebinder
+
+	disconnectTimeout time.Duration
+	reconnect         ReconnectFunc
+	reconnecting      bool
+
+	stalledSince time.Time
+
+	// Rematches are set up the same as the first match.
+	bestOf         int
+	offererFolder  state.Folder
+	answererFolder state.Folder
+
+	predictor rollback.Predictor
+
+	// matchNumber is sent with every packet, so that packets still in flight
+	// from the last match can be dropped.
+	matchNumber uint8
+
+	// rematchTick is 0 until a rematch is agreed to.
+	rematchTick int
+
+	// negotiation is kept once its match starts, so that the remote's resent
+	// commits are still answered.
+	negotiation *netsyncrand.Negotiation
 }
 
 var sampleRate = beep.SampleRate(48000)
 
-func New(b *bundle.Bundle, dc *ctxwebrtc.DataChannel, rng *syncrand.Source, isAnswerer bool, delaysWindowSize int, inputFrameDelay int) *Game {
+func newGame(b *bundle.Bundle, cs *clientState) *Game {
 	speaker.Init(sampleRate, 128)
 	mixer := &beep.Mixer{}
 	volume := &effects.Volume{Streamer: mixer}
@@ -172,83 +195,71 @@ func New(b *bundle.Bundle, dc *ctxwebrtc.DataChannel, rng *syncrand.Source, isAn
 	mixer.Add(&effects.Gain{Streamer: sfxMixer, Gain: -0.1})
 	soundScheduler := sound.NewScheduler(sampleRate, sfxMixer)
 
-	s := state.New(rng)
-	var offererEntityID state.EntityID
-	{
-		e := &state.Entity{
-			HP:        1000,
-			MaxHP:     1000,
-			DisplayHP: 1000,
-
-			Chips: []*state.Chip{chips.Recov200},
-
-			PowerShotChargeTime: state.Ticks(50),
-
-			TilePos:       state.TilePosXY(2, 2),
-			FutureTilePos: state.TilePosXY(2, 2),
-
-			BehaviorState: state.EntityBehaviorState{
-				Behavior: &behaviors.Idle{},
-			},
-
-			Traits: state.EntityTraits{
-				ExtendsTileOwnership: true,
-			},
-		}
-		s.AttachEntity(e)
-		offererEntityID = e.ID()
+	return &Game{
+		bundle:         b,
+		volume:         volume,
+		soundScheduler: soundScheduler,
+		cs:             cs,
 	}
+}
 
-	var answererEntityID state.EntityID
-	{
-		e := &state.Entity{
-			HP:        1000,
-			MaxHP:     1000,
-			DisplayHP: 1000,
-
-			Chips: []*state.Chip{chips.Recov200},
-
-			PowerShotChargeTime: state.Ticks(50),
-
-			IsFlipped:            true,
-			IsAlliedWithAnswerer: true,
-
-			TilePos:       state.TilePosXY(5, 2),
-			FutureTilePos: state.TilePosXY(5, 2),
-
-			BehaviorState: state.EntityBehaviorState{
-				Behavior: &behaviors.Idle{},
-			},
+func newClientState(randSeed []byte, bestOf int, offererFolder state.Folder, answererFolder state.Folder, replayW io.Writer) (*clientState, error) {
+	s, offererEntityID, answererEntityID := match.NewState(randSeed, bestOf, offererFolder, answererFolder)
 
-			Traits: state.EntityTraits{
-				ExtendsTileOwnership: true,
-			},
+	var replayWriter *replay.Writer
+	if replayW != nil {
+		var err error
+		replayWriter, err = replay.NewWriter(replayW, randSeed, s, offererEntityID, answererEntityID)
+		if err != nil {
+			return nil, err
 		}
-		s.AttachEntity(e)
-		answererEntityID = e.ID()
 	}
 
-	g := &Game{
-		bundle:         b,
-		dc:             dc,
-		volume:         volume,
-		soundScheduler: soundScheduler,
-		cs: &clientState{
-			OffererEntityID:  offererEntityID,
-			AnswererEntityID: answererEntityID,
+	return &clientState{
+		OffererEntityID:  offererEntityID,
+		AnswererEntityID: answererEntityID,
 
-			isAnswerer: isAnswerer,
+		committedState: s,
+		dirtyState:     s,
 
-			committedState: s,
-			dirtyState:     s.Clone(),
+		checksums: desync.New(),
 
-			incomingIntents: ringbuf.New[state.Intent](maxPendingIntents),
-			outgoingIntents: ringbuf.New[state.Intent](maxPendingIntents),
-		},
-		inputFrameDelay: inputFrameDelay,
-		delayRingbuf:    ringbuf.New[time.Duration](delaysWindowSize),
-	}
-	return g
+		replayWriter: replayWriter,
+	}, nil
+}
+
+// New creates a game for a networked match. replayW may be nil.
+//
+// If nothing is received for disconnectTimeout, reconnect is used to resume
+// the match where it left off, or RunBackgroundTasks fails if it is nil.
+func New(b *bundle.Bundle, conn transport.Transport, randSeed []byte, isAnswerer bool, bestOf int, offererFolder state.Folder, answererFolder state.Folder, delaysWindowSize int, inputFrameDelay int, predictor rollback.Predictor, replayW io.Writer, inputConfig *input.Config, disconnectTimeout time.Duration, reconnect ReconnectFunc) (*Game, error) {
+	inputSource, err := inputConfig.Source(input.DefaultProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := newClientState(randSeed, bestOf, offererFolder, answererFolder, replayW)
+	if err != nil {
+		return nil, err
+	}
+	cs.isAnswerer = isAnswerer
+	cs.unackedIntents = newUnackedIntents()
+	cs.rollback = rollback.New(cs.committedState, b.Data, cs.SelfEntityID(), cs.OpponentEntityID(), maxPendingIntents, predictor, cs.commit)
+
+	g := newGame(b, cs)
+	g.conn = conn
+	g.input = inputSource
+	g.inputConfig = inputConfig
+	g.inputProfile = input.DefaultProfile
+	g.disconnectTimeout = disconnectTimeout
+	g.reconnect = reconnect
+	g.inputFrameDelay = inputFrameDelay
+	g.delayRingbuf = ringbuf.New[time.Duration](delaysWindowSize)
+	g.bestOf = bestOf
+	g.offererFolder = offererFolder
+	g.answererFolder = answererFolder
+	g.predictor = predictor
+	return g, nil
 }
 
 type orderableSlice[T constraints.Ordered] []T
@@ -283,10 +294,10 @@ func (g *Game) medianDelay() time.Duration {
 	return delays[i]
 }
 
-func (g *Game) sendPings(ctx context.Context) error {
+func (g *Game) sendPings(ctx context.Context, conn transport.Transport) error {
 	for {
 		now := time.Now()
-		if err := packets.Send(ctx, g.dc, packets.Ping{
+		if err := packets.Send(ctx, conn, packets.Ping{
 			ID: uint64(now.UnixMicro()),
 		}); err != nil {
 			return err
@@ -300,16 +311,16 @@ func (g *Game) sendPings(ctx context.Context) error {
 	}
 }
 
-func (g *Game) handleConn(ctx context.Context) error {
+func (g *Game) handleConn(ctx context.Context, conn transport.Transport) error {
 	for {
-		packet, err := packets.Recv(ctx, g.dc)
+		packet, err := g.recv(ctx, conn)
 		if err != nil {
 			return err
 		}
 
 		switch p := packet.(type) {
 		case packets.Ping:
-			if err := packets.Send(ctx, g.dc, packets.Pong{ID: p.ID}); err != nil {
+			if err := packets.Send(ctx, conn, packets.Pong{ID: p.ID}); err != nil {
 				return err
 			}
 		case packets.Pong:
@@ -329,21 +340,47 @@ func (g *Game) handleConn(ctx context.Context) error {
 			})(); err != nil {
 				return err
 			}
+		case packets.Commit:
+			if err := g.handleNegotiation(ctx, conn, p.Match, p); err != nil {
+				return err
+			}
+		case packets.Reveal:
+			if err := g.handleNegotiation(ctx, conn, p.Match, p); err != nil {
+				return err
+			}
 		case packets.Intent:
 			if err := (func() error {
 				g.csMu.Lock()
 				defer g.csMu.Unlock()
 
-				nextTick := uint32(int(g.cs.committedState.ElapsedTime) + g.cs.incomingIntents.Used() + 1)
-				if p.ForTick != nextTick {
-					return fmt.Errorf("expected intent for %d but it was for %d", nextTick, p.ForTick)
+				if p.Match != g.matchNumber {
+					return nil
 				}
 
-				if err := g.cs.incomingIntents.Push([]state.Intent{p.Intent}); err != nil {
-					return err
+				if int(p.NumIntents) > len(p.Intents) {
+					return fmt.Errorf("intent packet has too many intents: %d", p.NumIntents)
 				}
 
-				if err := g.cs.fastForward(g.bundle); err != nil {
+				g.cs.unackedIntents.ack(p.AckTick)
+
+				// The remote resends everything we haven't acknowledged, so anything
+				// before the next tick we need is a duplicate.
+				nextTick := uint32(g.cs.rollback.LastRemoteIntentTick() + 1)
+				if p.StartTick > nextTick {
+					return fmt.Errorf("expected intents from %d but they started from %d", nextTick, p.StartTick)
+				}
+
+				for i := int(nextTick - p.StartTick); i < int(p.NumIntents); i++ {
+					if err := g.cs.addRemoteIntent(p.Intents[i]); err != nil {
+						if errors.Is(err, rollback.ErrTooManyPendingIntents) {
+							// The rest will be resent, as we won't acknowledge them.
+							break
+						}
+						return err
+					}
+				}
+
+				if err := g.sendChecksums(ctx); err != nil {
 					return err
 				}
 
@@ -351,7 +388,23 @@ func (g *Game) handleConn(ctx context.Context) error {
 			})(); err != nil {
 				return err
 			}
+		case packets.Checksums:
+			if err := (func() error {
+				g.csMu.Lock()
+				defer g.csMu.Unlock()
 
+				if p.Match != g.matchNumber {
+					return nil
+				}
+
+				if err := g.cs.checksums.AddRemote(p); err != nil {
+					return err
+				}
+				g.cs.checksums.Verify()
+				return nil
+			})(); err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -386,6 +439,7 @@ func makeTextGradient(color0 color.Color, color1 color.Color, color2 color.Color
 var (
 	whiteTextGradient      = makeTextGradient(color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF})
 	chipDamageTextGradient = makeTextGradient(color.RGBA{0xFF, 0xA5, 0x00, 0xFF}, color.RGBA{0xFF, 0xDE, 0x00, 0xFF}, color.RGBA{0xFF, 0xDE, 0x00, 0xFF})
+	chipCodeTextGradient   = makeTextGradient(color.RGBA{0xFF, 0xFF, 0x00, 0xFF}, color.RGBA{0xFF, 0xFF, 0x84, 0xFF}, color.RGBA{0xFF, 0xFF, 0xC6, 0xFF})
 	hpNeutralTextGradient  = makeTextGradient(color.RGBA{0xCE, 0xE7, 0xFF, 0xFF}, color.RGBA{0xE7, 0xEF, 0xFF, 0xFF}, color.RGBA{0xF7, 0xF7, 0xF7, 0xFF})
 	hpLossTextGradient     = makeTextGradient(color.RGBA{0xFF, 0xA5, 0x21, 0xFF}, color.RGBA{0xFF, 0xC6, 0x63, 0xFF}, color.RGBA{0xFF, 0xEF, 0xAD, 0xFF})
 	hpGainTextGradient     = makeTextGradient(color.RGBA{0x39, 0xFF, 0x94, 0xFF}, color.RGBA{0x84, 0xFF, 0xC6, 0xFF}, color.RGBA{0xD7, 0xFF, 0xF7, 0xFF})
@@ -414,7 +468,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	rootNode := &draw.OptionsNode{}
 	sceneNode := &draw.OptionsNode{}
 	sceneNode.Opts.GeoM.Scale(float64(k), float64(k))
-	sceneNode.Children = append(sceneNode.Children, state.Appearance(g.bundle))
+	sceneNode.Children = append(sceneNode.Children, render.StateAppearance(state, g.bundle))
 	if state.Timestop != nil {
 		timestopOverlay := &draw.OptionsNode{Layer: 1}
 		overlay := ebiten.NewImage(sceneWidth, sceneHeight)
@@ -461,30 +515,34 @@ var (
 	}()
 )
 
-func (g *Game) uiAppearance() draw.Node {
-	rootNode := &draw.OptionsNode{Layer: 9}
-	{
-		self := g.cs.dirtyState.Entities[g.cs.SelfEntityID()]
+func (g *Game) hpPlaqueAppearance(e *state.Entity, x int) draw.Node {
+	gradientImage := hpNeutralTextGradient
+	if e.DisplayHP > e.HP {
+		gradientImage = hpLossTextGradient
+	} else if e.DisplayHP < e.HP {
+		gradientImage = hpGainTextGradient
+	}
 
-		gradientImage := hpNeutralTextGradient
-		if self.DisplayHP > self.HP {
-			gradientImage = hpLossTextGradient
-		} else if self.DisplayHP < self.HP {
-			gradientImage = hpGainTextGradient
-		}
+	hpPlaqueNode := &draw.OptionsNode{}
+	hpPlaqueNode.Opts.GeoM.Translate(float64(x), float64(0))
 
-		hpPlaqueNode := &draw.OptionsNode{}
-		hpPlaqueNode.Opts.GeoM.Translate(float64(2), float64(0))
-		rootNode.Children = append(rootNode.Children, hpPlaqueNode)
+	hpPlaqueBgNode := &draw.OptionsNode{}
+	hpPlaqueNode.Children = append(hpPlaqueNode.Children, hpPlaqueBgNode)
+	hpPlaqueBgNode.Children = append(hpPlaqueBgNode.Children, &draw.ImageNode{Image: hpBoxImage})
 
-		hpPlaqueBgNode := &draw.OptionsNode{}
-		hpPlaqueNode.Children = append(hpPlaqueNode.Children, hpPlaqueBgNode)
-		hpPlaqueBgNode.Children = append(hpPlaqueBgNode.Children, &draw.ImageNode{Image: hpBoxImage})
+	hpPlaqueTextNode := &draw.OptionsNode{}
+	hpPlaqueTextNode.Opts.GeoM.Translate(float64(38), float64(3))
+	hpPlaqueNode.Children = append(hpPlaqueNode.Children, hpPlaqueTextNode)
+	hpPlaqueTextNode.Children = append(hpPlaqueTextNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: strconv.Itoa(e.DisplayHP), Background: gradientImage}}, styledtext.AnchorRight|styledtext.AnchorTop, g.bundle.TallFont, styledtext.BorderNone, color.RGBA{}))
+	return hpPlaqueNode
+}
 
-		hpPlaqueTextNode := &draw.OptionsNode{}
-		hpPlaqueTextNode.Opts.GeoM.Translate(float64(38), float64(3))
-		hpPlaqueNode.Children = append(hpPlaqueNode.Children, hpPlaqueTextNode)
-		hpPlaqueTextNode.Children = append(hpPlaqueTextNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: strconv.Itoa(self.DisplayHP), Background: gradientImage}}, styledtext.AnchorRight|styledtext.AnchorTop, g.bundle.TallFont, styledtext.BorderNone, color.RGBA{}))
+func (g *Game) uiAppearance() draw.Node {
+	rootNode := &draw.OptionsNode{Layer: 9}
+	rootNode.Children = append(rootNode.Children, g.hpPlaqueAppearance(g.cs.dirtyState.Entities[g.cs.SelfEntityID()], 2))
+	if g.local != nil {
+		// Both players are looking at the same screen.
+		rootNode.Children = append(rootNode.Children, g.hpPlaqueAppearance(g.cs.dirtyState.Entities[g.cs.OpponentEntityID()], sceneWidth-hpBoxImage.Bounds().Dx()-2))
 	}
 
 	if g.cs.dirtyState.CounterPlaqueTimeLeft > 0 {
@@ -504,6 +562,31 @@ func (g *Game) uiAppearance() draw.Node {
 		}
 	}
 
+	rootNode.Children = append(rootNode.Children, g.customUIAppearance())
+	rootNode.Children = append(rootNode.Children, g.matchUIAppearance())
+
+	if d := g.cs.checksums.Desync(); d != nil {
+		desyncNode := &draw.OptionsNode{}
+		desyncNode.Opts.GeoM.Translate(float64(sceneWidth/2), float64(sceneHeight/2))
+		rootNode.Children = append(rootNode.Children, desyncNode)
+		desyncNode.Children = append(desyncNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: fmt.Sprintf("DESYNC AT TICK %d", d.Tick), Background: hpLossTextGradient}}, styledtext.AnchorCenter|styledtext.AnchorMiddle, g.bundle.TallFont, styledtext.BorderRightBottom, color.RGBA{0, 0, 0, 0xff}))
+	}
+
+	if g.replayPlayer != nil {
+		rootNode.Children = append(rootNode.Children, g.replayUIAppearance())
+	}
+
+	if g.watcher != nil {
+		rootNode.Children = append(rootNode.Children, g.spectatorUIAppearance())
+	}
+
+	if text := g.connStatusText(); text != "" {
+		connStatusNode := &draw.OptionsNode{}
+		connStatusNode.Opts.GeoM.Translate(float64(sceneWidth/2), float64(sceneHeight/2+16))
+		rootNode.Children = append(rootNode.Children, connStatusNode)
+		connStatusNode.Children = append(connStatusNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: text, Background: whiteTextGradient}}, styledtext.AnchorCenter|styledtext.AnchorMiddle, g.bundle.TallFont, styledtext.BorderRightBottom, color.RGBA{0, 0, 0, 0xff}))
+	}
+
 	// TODO: Render chip. Must be not in chip use lockout.
 	self := g.cs.dirtyState.Entities[g.cs.SelfEntityID()]
 	if self.ChipUseLockoutTimeLeft == 0 && len(self.Chips) > 0 &&
@@ -517,21 +600,44 @@ func (g *Game) uiAppearance() draw.Node {
 		chipTextNode.Children = append(chipTextNode.Children, chipPlaqueApperance(g.bundle, chip, 0, self.DoubleDamage(), styledtext.AnchorLeft|styledtext.AnchorTop))
 	}
 
+	if g.rebinder != nil {
+		rootNode.Children = append(rootNode.Children, g.rebinderAppearance())
+	}
+
 	return rootNode
 }
 
 func (g *Game) Update() error {
-	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+	rebinding := g.updateRebinder()
+
+	if !rebinding && inpututil.IsKeyJustPressed(ebiten.KeyM) {
 		g.volume.Silent = !g.volume.Silent
 	}
-	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+
+	if g.replayPlayer != nil {
+		return g.updateReplay()
+	}
+
+	if g.watcher != nil {
+		return g.updateSpectator()
+	}
+
+	if !rebinding && inpututil.IsKeyJustPressed(ebiten.KeyP) {
 		g.paused = !g.paused
 	}
 
-	if g.paused && !inpututil.IsKeyJustPressed(ebiten.KeyPeriod) {
+	if g.paused && (rebinding || !inpututil.IsKeyJustPressed(ebiten.KeyPeriod)) {
 		return nil
 	}
 
+	if g.local != nil {
+		if rebinding {
+			// Nobody is waiting on us, so the match can wait for the players.
+			return nil
+		}
+		return g.updateLocal()
+	}
+
 	g.csMu.Lock()
 	defer g.csMu.Unlock()
 
@@ -540,45 +646,50 @@ func (g *Game) Update() error {
 		highWaterMark = 1
 	}
 
-	if g.cs.outgoingIntents.Used() >= highWaterMark {
-		// Pause until we have enough space.
+	ctx := context.Background()
+
+	if err := g.updateRematch(ctx); err != nil {
+		return err
+	}
+
+	// Errors sending are not fatal here: RunBackgroundTasks monitors the
+	// connection, and intents are resent until they are acknowledged.
+	if g.cs.rollback.PendingLocalIntents() >= highWaterMark {
+		if g.stalledSince.IsZero() {
+			g.stalledSince = time.Now()
+		}
+		// Keep resending, or if the remote is also waiting on us, neither of us
+		// would ever send anything again.
+		g.sendIntents(ctx)
 		return nil
 	}
+	g.stalledSince = time.Time{}
 
-	intent := input.CurrentIntent()
+	// The match can't wait while rebinding, so stand still in the meantime.
+	var intent state.Intent
+	if !rebinding {
+		intent = g.input.Intent()
+	}
 	if g.cs.isAnswerer {
 		intent.Direction = intent.Direction.FlipH()
 	}
 
-	forTick := uint32(g.cs.dirtyState.ElapsedTime + 1)
-
-	ctx := context.Background()
-
-	if err := packets.Send(ctx, g.dc, packets.Intent{ForTick: forTick, Intent: intent}); err != nil {
-		return err
-	}
-
-	if err := g.cs.outgoingIntents.Push([]state.Intent{intent}); err != nil {
+	if err := g.cs.addLocalIntent(intent); err != nil {
 		return err
 	}
 
-	if err := g.cs.fastForward(g.bundle); err != nil {
-		return err
-	}
+	g.cs.unackedIntents.push(intent)
+	g.sendIntents(ctx)
+	g.sendChecksums(ctx)
 
 	return nil
 }
 
-func (g *Game) RunBackgroundTasks(ctx context.Context) error {
-	errg, ctx := errgroup.WithContext(ctx)
-
-	errg.Go(func() error {
-		return g.handleConn(ctx)
-	})
-
-	errg.Go(func() error {
-		return g.sendPings(ctx)
-	})
-
-	return errg.Wait()
+func (g *Game) sendIntents(ctx context.Context) error {
+	if g.reconnecting {
+		return nil
+	}
+	p := g.cs.unackedIntents.packet(uint32(g.cs.rollback.LastRemoteIntentTick()))
+	p.Match = g.matchNumber
+	return packets.Send(ctx, g.conn, p)
 }
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/draw/styledtext"
+	"github.com/murkland/nbarena/input"
+)
+
+// updateRebinder returns true if the rebinding screen is open, in which case
+// nothing else should read input.
+func (g *Game) updateRebinder() bool {
+	if g.inputConfig == nil {
+		return false
+	}
+
+	if g.rebinder == nil {
+		if !inpututil.IsKeyJustPressed(input.RebindKey) {
+			return false
+		}
+		g.rebinder = input.NewRebinder(g.inputConfig, g.inputProfile)
+		return true
+	}
+
+	if g.rebinder.Update() {
+		g.rebinder = nil
+		if err := g.inputConfig.Save(); err != nil {
+			log.Printf("failed to save input config: %s", err)
+		}
+	}
+	return true
+}
+
+func bindingText(binding input.Binding) string {
+	var names []string
+	for _, k := range binding.Keys {
+		names = append(names, input.KeyName(k))
+	}
+	for _, b := range binding.GamepadButtons {
+		names = append(names, "Pad"+input.GamepadButtonName(b))
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, " ")
+}
+
+func (g *Game) rebinderAppearance() draw.Node {
+	const lineHeight = 13
+
+	rebinderNode := &draw.OptionsNode{}
+
+	overlay := ebiten.NewImage(sceneWidth, sceneHeight)
+	overlay.Fill(color.Black)
+	overlayNode := &draw.OptionsNode{}
+	overlayNode.Opts.ColorM.Scale(1.0, 1.0, 1.0, 0.75)
+	overlayNode.Children = append(overlayNode.Children, &draw.ImageNode{Image: overlay})
+	rebinderNode.Children = append(rebinderNode.Children, overlayNode)
+
+	text := func(x int, y int, s string, gradient *ebiten.Image) {
+		textNode := &draw.OptionsNode{}
+		textNode.Opts.GeoM.Translate(float64(x), float64(y))
+		textNode.Children = append(textNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: s, Background: gradient}}, styledtext.AnchorLeft|styledtext.AnchorTop, g.bundle.TallFont, styledtext.BorderRightBottom, color.RGBA{0, 0, 0, 0xff}))
+		rebinderNode.Children = append(rebinderNode.Children, textNode)
+	}
+
+	text(4, 2, fmt.Sprintf("< %s >", strings.ToUpper(g.rebinder.Profile())), whiteTextGradient)
+
+	bindings := g.rebinder.Bindings()
+	for a := input.Action(0); a < input.NumActions; a++ {
+		gradient := hpNeutralTextGradient
+		binding := bindingText(bindings[a])
+		if a == g.rebinder.Action() {
+			gradient = chipDamageTextGradient
+			if g.rebinder.Capturing() {
+				binding = "PRESS A KEY OR BUTTON..."
+			}
+		}
+		y := 4 + lineHeight*(int(a)+1)
+		text(4, y, strings.ToUpper(a.String()), gradient)
+		text(90, y, binding, gradient)
+	}
+
+	text(4, sceneHeight-14, "ENTER:ADD BKSP:CLEAR DEL:RESET", whiteTextGradient)
+
+	return rebinderNode
+}
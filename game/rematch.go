@@ -0,0 +1,121 @@
+package game
+
+import (
+	"context"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/netsyncrand"
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/rollback"
+	"github.com/murkland/nbarena/transport"
+)
+
+// nextClientState sets up a rematch. Spectators were disconnected at the end of
+// the last match, but may reconnect.
+func (g *Game) nextClientState(randSeed []byte) (*clientState, error) {
+	cs, err := newClientState(randSeed, g.bestOf, g.offererFolder, g.answererFolder, nil)
+	if err != nil {
+		return nil, err
+	}
+	cs.isAnswerer = g.cs.isAnswerer
+
+	log.Printf("starting rematch, seed: %s", hex.EncodeToString(randSeed))
+	if g.cs.replayWriter != nil {
+		log.Printf("a replay only holds a single match, so the rematch will not be recorded")
+	}
+	if g.cs.spectators != nil {
+		g.cs.spectators.Close()
+		cs.spectators = g.cs.spectators
+	}
+	return cs, nil
+}
+
+// negotiationFor starts the negotiation for the next match if need be. It
+// returns nil for stale matches.
+func (g *Game) negotiationFor(matchNumber uint8) (*netsyncrand.Negotiation, error) {
+	if g.negotiation != nil && g.negotiation.Match() == matchNumber {
+		return g.negotiation, nil
+	}
+	if matchNumber != g.matchNumber+1 {
+		return nil, nil
+	}
+	n, err := netsyncrand.NewNegotiation(matchNumber)
+	if err != nil {
+		return nil, err
+	}
+	g.negotiation = n
+	return n, nil
+}
+
+// handleNegotiation may hear from the remote before we've seen the rematch
+// agreed to: we only reveal our nonce in reply until we're ready.
+func (g *Game) handleNegotiation(ctx context.Context, conn transport.Transport, matchNumber uint8, p packets.Packet) error {
+	g.csMu.Lock()
+	defer g.csMu.Unlock()
+
+	n, err := g.negotiationFor(matchNumber)
+	if err != nil || n == nil {
+		return err
+	}
+
+	reply, err := n.Handle(p)
+	if err != nil {
+		return err
+	}
+	for _, p := range reply {
+		if err := packets.Send(ctx, conn, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateRematch must be called with csMu held.
+func (g *Game) updateRematch(ctx context.Context) error {
+	if g.rematchTick == 0 {
+		if !match.RematchAgreed(g.cs.committedState) {
+			return nil
+		}
+		g.rematchTick = g.cs.rollback.CommittedTick()
+		log.Printf("rematch agreed to at tick %d", g.rematchTick)
+	}
+
+	// Our intents won't be resent once we've moved on, and the remote can't see
+	// the rematch agreed to without them.
+	if !g.cs.unackedIntents.ackedThrough(uint32(g.rematchTick)) {
+		return nil
+	}
+
+	n, err := g.negotiationFor(g.matchNumber + 1)
+	if err != nil {
+		return err
+	}
+
+	if !g.reconnecting {
+		// As with intents, these are resent, so errors aren't fatal.
+		for _, p := range n.Packets() {
+			packets.Send(ctx, g.conn, p)
+		}
+	}
+
+	seed, ok := n.Seed()
+	if !ok {
+		return nil
+	}
+
+	cs, err := g.nextClientState(seed)
+	if err != nil {
+		return err
+	}
+	cs.unackedIntents = newUnackedIntents()
+	cs.rollback = rollback.New(cs.committedState, g.bundle.Data, cs.SelfEntityID(), cs.OpponentEntityID(), maxPendingIntents, g.predictor, cs.commit)
+
+	g.cs = cs
+	g.matchNumber++
+	g.rematchTick = 0
+	g.stalledSince = time.Time{}
+	return nil
+}
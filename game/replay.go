@@ -0,0 +1,88 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/desync"
+	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/draw/styledtext"
+	"github.com/murkland/nbarena/replay"
+)
+
+const (
+	replayFastForwardSpeed = 4
+	replaySeekSeconds      = 5
+)
+
+// NewReplay plays back a recorded match from the offerer's side. P pauses, .
+// steps while paused, F fast-forwards, left/right seek and Home restarts.
+func NewReplay(b *bundle.Bundle, rp *replay.Replay) *Game {
+	s := rp.InitialState.Clone()
+	g := newGame(b, &clientState{
+		OffererEntityID:  rp.OffererEntityID,
+		AnswererEntityID: rp.AnswererEntityID,
+
+		committedState: s,
+		dirtyState:     s,
+
+		checksums: desync.New(),
+	})
+	g.replayPlayer = replay.NewPlayer(rp, b.Data)
+	return g
+}
+
+func (g *Game) updateReplay() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		g.paused = !g.paused
+	}
+
+	g.csMu.Lock()
+	defer g.csMu.Unlock()
+
+	p := g.replayPlayer
+	seekTicks := replaySeekSeconds * ebiten.MaxTPS()
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyHome):
+		p.Seek(0)
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		p.Seek(p.Tick() - seekTicks)
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		p.Seek(p.Tick() + seekTicks)
+	case g.paused:
+		if inpututil.IsKeyJustPressed(ebiten.KeyPeriod) {
+			p.Step()
+		}
+	case ebiten.IsKeyPressed(ebiten.KeyF):
+		for i := 0; i < replayFastForwardSpeed; i++ {
+			p.Step()
+		}
+	default:
+		p.Step()
+	}
+
+	g.cs.committedState = p.State()
+	g.cs.dirtyState = p.State()
+	return nil
+}
+
+func formatReplayTime(ticks int) string {
+	seconds := ticks / ebiten.MaxTPS()
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func (g *Game) replayUIAppearance() draw.Node {
+	p := g.replayPlayer
+	text := fmt.Sprintf("%s / %s", formatReplayTime(p.Tick()), formatReplayTime(p.Len()))
+	if g.paused {
+		text = "PAUSED " + text
+	}
+
+	replayNode := &draw.OptionsNode{}
+	replayNode.Opts.GeoM.Translate(float64(sceneWidth-2), float64(sceneHeight-12))
+	replayNode.Children = append(replayNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: text, Background: whiteTextGradient}}, styledtext.AnchorRight|styledtext.AnchorTop, g.bundle.TallFont, styledtext.BorderRightBottom, color.RGBA{0, 0, 0, 0xff}))
+	return replayNode
+}
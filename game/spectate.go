@@ -0,0 +1,63 @@
+package game
+
+import (
+	"image/color"
+	"net"
+
+	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/desync"
+	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/draw/styledtext"
+	"github.com/murkland/nbarena/spectate"
+)
+
+func (g *Game) ServeSpectators(lis net.Listener) error {
+	g.csMu.Lock()
+	if g.cs.spectators == nil {
+		g.cs.spectators = spectate.NewBroadcaster(g.cs.OffererEntityID, g.cs.AnswererEntityID)
+	}
+	spectators := g.cs.spectators
+	g.csMu.Unlock()
+
+	return spectators.Serve(lis)
+}
+
+// NewSpectator watches a match from the offerer's side.
+func NewSpectator(b *bundle.Bundle, w *spectate.Watcher) *Game {
+	s := w.State()
+	g := newGame(b, &clientState{
+		OffererEntityID:  w.OffererEntityID,
+		AnswererEntityID: w.AnswererEntityID,
+
+		committedState: s,
+		dirtyState:     s,
+
+		checksums: desync.New(),
+	})
+	g.watcher = w
+	return g
+}
+
+func (g *Game) updateSpectator() error {
+	g.csMu.Lock()
+	defer g.csMu.Unlock()
+
+	g.watcher.Update()
+	g.cs.committedState = g.watcher.State()
+	g.cs.dirtyState = g.watcher.State()
+	return nil
+}
+
+func (g *Game) spectatorUIAppearance() draw.Node {
+	text := "SPECTATING"
+	if g.watcher.Ended() {
+		text = "MATCH ENDED"
+	} else if g.watcher.Buffering() {
+		text = "BUFFERING..."
+	}
+
+	spectatorNode := &draw.OptionsNode{}
+	spectatorNode.Opts.GeoM.Translate(float64(sceneWidth-2), float64(sceneHeight-12))
+	spectatorNode.Children = append(spectatorNode.Children, styledtext.MakeNode([]styledtext.Span{{Text: text, Background: whiteTextGradient}}, styledtext.AnchorRight|styledtext.AnchorTop, g.bundle.TallFont, styledtext.BorderRightBottom, color.RGBA{0, 0, 0, 0xff}))
+	return spectatorNode
+}
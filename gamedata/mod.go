@@ -0,0 +1,181 @@
+package gamedata
+
+import (
+	"context"
+	"sort"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/vorbis"
+	"github.com/murkland/moreio"
+	"github.com/murkland/nbarena/loader"
+	"github.com/murkland/pngsheet"
+)
+
+type DecorationType int
+
+const (
+	DecorationTypeNone           DecorationType = 0
+	DecorationTypeDeathExplosion DecorationType = iota
+	DecorationTypeCannonExplosion
+	DecorationTypeBusterPowerShotExplosion
+	DecorationTypeBusterExplosion
+	DecorationTypeVulcanExplosion
+	DecorationTypeSuperVulcanExplosion
+	DecorationTypeUninstallExplosion
+	DecorationTypeChipDeleteExplosion
+	DecorationTypeShieldHitExplosion
+	DecorationTypeNullShortSwordSlash
+	DecorationTypeNullWideSwordSlash
+	DecorationTypeNullLongSwordSlash
+	DecorationTypeNullVeryLongSwordSlash
+	DecorationTypeNullShortBladeSlash
+	DecorationTypeNullWideBladeSlash
+	DecorationTypeNullLongBladeSlash
+	DecorationTypeNullVeryLongBladeSlash
+	DecorationTypeWindSlash
+	DecorationTypeRecov
+)
+
+type SoundType int
+
+const (
+	SoundTypeNone   SoundType = 0
+	SoundTypeBuster SoundType = iota
+	SoundTypeOuch
+	SoundTypeCharging
+	SoundTypeCharged
+	SoundTypeSwordSlash
+	SoundTypeCounterHit
+	SoundTypeDoubleDamageConsumed
+	SoundTypeRecov
+	SoundTypeAreaGrabStart
+	SoundTypeAreaGrabEnd
+)
+
+type DecorationSource struct {
+	Path           string
+	AnimationIndex int
+}
+
+var DecorationSources = map[DecorationType]DecorationSource{
+	DecorationTypeDeathExplosion:           {"assets/sprites/0266.png", 0},
+	DecorationTypeCannonExplosion:          {"assets/sprites/0267.png", 0},
+	DecorationTypeBusterPowerShotExplosion: {"assets/sprites/0270.png", 0},
+	DecorationTypeBusterExplosion:          {"assets/sprites/0271.png", 0},
+	DecorationTypeVulcanExplosion:          {"assets/sprites/0281.png", 0},
+	DecorationTypeSuperVulcanExplosion:     {"assets/sprites/0281.png", 0},
+	DecorationTypeUninstallExplosion:       {"assets/sprites/0290.png", 0},
+	DecorationTypeChipDeleteExplosion:      {"assets/sprites/0278.png", 0},
+	DecorationTypeShieldHitExplosion:       {"assets/sprites/0272.png", 0},
+	DecorationTypeNullShortSwordSlash:      {"assets/sprites/0089.png", 2},
+	DecorationTypeNullWideSwordSlash:       {"assets/sprites/0089.png", 0},
+	DecorationTypeNullLongSwordSlash:       {"assets/sprites/0089.png", 1},
+	DecorationTypeNullVeryLongSwordSlash:   {"assets/sprites/0089.png", 3},
+	DecorationTypeNullShortBladeSlash:      {"assets/sprites/0089.png", 2},
+	DecorationTypeNullWideBladeSlash:       {"assets/sprites/0089.png", 0},
+	DecorationTypeNullLongBladeSlash:       {"assets/sprites/0089.png", 1},
+	DecorationTypeNullVeryLongBladeSlash:   {"assets/sprites/0089.png", 3},
+	DecorationTypeWindSlash:                {"assets/sprites/0109.png", 0},
+	DecorationTypeRecov:                    {"assets/sprites/0087.png", 0},
+}
+
+var SoundPaths = map[SoundType]string{
+	SoundTypeBuster:               "assets/sounds/106.ogg",
+	SoundTypeOuch:                 "assets/sounds/107.ogg",
+	SoundTypeCharging:             "assets/sounds/113.ogg",
+	SoundTypeCharged:              "assets/sounds/114.ogg",
+	SoundTypeCounterHit:           "assets/sounds/134.ogg",
+	SoundTypeDoubleDamageConsumed: "assets/sounds/135.ogg",
+	SoundTypeRecov:                "assets/sounds/138.ogg",
+	SoundTypeAreaGrabStart:        "assets/sounds/161.ogg",
+	SoundTypeAreaGrabEnd:          "assets/sounds/162.ogg",
+	SoundTypeSwordSlash:           "assets/sounds/176.ogg",
+}
+
+// Data is what the simulation needs from the assets: durations, in ticks. It
+// has no media, so it works without a display or audio device.
+type Data struct {
+	DecorationDurations map[DecorationType]int
+	SoundDurations      map[SoundType]int
+}
+
+// NewData returns data for simulating headlessly. Decorations and sounds don't
+// affect the outcome of a match, so they just go away after a tick.
+func NewData() *Data {
+	return &Data{
+		DecorationDurations: map[DecorationType]int{},
+		SoundDurations:      map[SoundType]int{},
+	}
+}
+
+// SoundDuration converts n samples to ticks, rounding up.
+func SoundDuration(sr beep.SampleRate, n int) int {
+	return (n*60 + int(sr) - 1) / int(sr)
+}
+
+func loadSheetInfo(ctx context.Context, f moreio.File) (*pngsheet.Info, error) {
+	defer f.Close()
+	return pngsheet.LoadInfo(f)
+}
+
+func loadSoundDuration(ctx context.Context, f moreio.File) (int, error) {
+	s, fmt, err := vorbis.Decode(f)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+	return SoundDuration(fmt.SampleRate, s.Len()), nil
+}
+
+// Load loads the data without decoding any images or audio.
+func Load(ctx context.Context, loaderCallback loader.Callback) (*Data, error) {
+	l, ctx := loader.New(ctx, loaderCallback)
+
+	sheetPathSet := map[string]struct{}{}
+	for _, src := range DecorationSources {
+		sheetPathSet[src.Path] = struct{}{}
+	}
+	sheetPaths := make([]string, 0, len(sheetPathSet))
+	for path := range sheetPathSet {
+		sheetPaths = append(sheetPaths, path)
+	}
+	sort.Strings(sheetPaths)
+
+	sheetInfos := make([]*pngsheet.Info, len(sheetPaths))
+	for i, path := range sheetPaths {
+		loader.Add(ctx, l, path, &sheetInfos[i], loadSheetInfo)
+	}
+
+	soundTypes := make([]SoundType, 0, len(SoundPaths))
+	for typ := range SoundPaths {
+		soundTypes = append(soundTypes, typ)
+	}
+	sort.Slice(soundTypes, func(i, j int) bool {
+		return soundTypes[i] < soundTypes[j]
+	})
+
+	soundDurations := make([]int, len(soundTypes))
+	for i, typ := range soundTypes {
+		loader.Add(ctx, l, SoundPaths[typ], &soundDurations[i], loadSoundDuration)
+	}
+
+	if err := l.Load(); err != nil {
+		return nil, err
+	}
+
+	d := &Data{
+		DecorationDurations: map[DecorationType]int{},
+		SoundDurations:      map[SoundType]int{},
+	}
+
+	for typ, src := range DecorationSources {
+		info := sheetInfos[sort.SearchStrings(sheetPaths, src.Path)]
+		d.DecorationDurations[typ] = len(info.Animations[src.AnimationIndex].Frames)
+	}
+
+	for i, typ := range soundTypes {
+		d.SoundDurations[typ] = soundDurations[i]
+	}
+
+	return d, nil
+}
@@ -51,7 +51,7 @@ require (
 	github.com/pion/transport v0.13.0 // indirect
 	github.com/pion/turn/v2 v2.0.6 // indirect
 	github.com/pion/udp v0.1.1 // indirect
-	github.com/twitchtv/twirp v8.1.1+incompatible // indirect
+	github.com/twitchtv/twirp v8.1.1+incompatible
 	golang.org/x/crypto v0.0.0-20220213190939-1e6e3497d506 // indirect
 	golang.org/x/exp/shiny v0.0.0-20220218215828-6cf2b201936e // indirect
 	golang.org/x/mobile v0.0.0-20210902104108-5d9a33257ab5 // indirect
@@ -0,0 +1,134 @@
+// Package gym wraps matches in a reinforcement learning environment, in the
+// style of OpenAI Gym.
+package gym
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/rand"
+
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+)
+
+// WinReward is added to the winner's reward and subtracted from the loser's.
+const WinReward = 1.0
+
+type Options struct {
+	Data *gamedata.Data
+
+	OffererFolder  state.Folder
+	AnswererFolder state.Folder
+
+	// MaxTicks cuts episodes off as a draw.
+	MaxTicks int
+}
+
+type StepResult struct {
+	Observation Observation `json:"observation"`
+
+	// Rewards are the HP the opponent lost less the HP lost, as a fraction of
+	// max HP, plus or minus WinReward when the match ends.
+	OffererReward  float64 `json:"offerer_reward"`
+	AnswererReward float64 `json:"answerer_reward"`
+
+	Done bool `json:"done"`
+
+	Winner match.Side `json:"winner"`
+}
+
+var (
+	ErrNotReset = errors.New("gym: environment has not been reset")
+	ErrDone     = errors.New("gym: episode is done, reset to start another")
+)
+
+// Env is not safe for concurrent use, but separate environments may be
+// stepped in parallel.
+type Env struct {
+	opts Options
+
+	s                *state.State
+	offererEntityID  state.EntityID
+	answererEntityID state.EntityID
+	tick             int
+	done             bool
+}
+
+func NewEnv(opts Options) *Env {
+	return &Env{opts: opts}
+}
+
+func SeedBytes(seed int64) []byte {
+	r := rand.New(rand.NewSource(seed))
+	randSeed := make([]byte, 32)
+	for i := 0; i < len(randSeed); i += 8 {
+		binary.LittleEndian.PutUint64(randSeed[i:], r.Uint64())
+	}
+	return randSeed
+}
+
+// Reset starts an episode of a single round on the custom screen, skipping the
+// intro, as nothing can be done during it.
+func (env *Env) Reset(seed int64) Observation {
+	env.s, env.offererEntityID, env.answererEntityID = match.NewState(SeedBytes(seed), 1, env.opts.OffererFolder, env.opts.AnswererFolder)
+	for env.s.Match.Phase == state.MatchPhaseIntro {
+		step.Step(env.s, env.opts.Data)
+	}
+	env.tick = 0
+	env.done = false
+	return env.Observation()
+}
+
+func (env *Env) Observation() Observation {
+	return observe(env.s, env.tick, env.offererEntityID, env.answererEntityID)
+}
+
+// State must not be modified.
+func (env *Env) State() (*state.State, state.EntityID, state.EntityID) {
+	return env.s, env.offererEntityID, env.answererEntityID
+}
+
+func (env *Env) Step(offererIntent state.Intent, answererIntent state.Intent) (StepResult, error) {
+	if env.s == nil {
+		return StepResult{}, ErrNotReset
+	}
+	if env.done {
+		return StepResult{}, ErrDone
+	}
+
+	offerer := env.s.Entities[env.offererEntityID]
+	answerer := env.s.Entities[env.answererEntityID]
+	lastOffererHP := offerer.HP
+	lastAnswererHP := answerer.HP
+
+	offerer.Intent = offererIntent
+	answerer.Intent = answererIntent
+	step.Step(env.s, env.opts.Data)
+	env.tick++
+
+	offererDamage := lastOffererHP - offerer.HP
+	answererDamage := lastAnswererHP - answerer.HP
+
+	var result StepResult
+	result.OffererReward = float64(answererDamage-offererDamage) / float64(offerer.MaxHP)
+	result.AnswererReward = float64(offererDamage-answererDamage) / float64(answerer.MaxHP)
+
+	// Don't wait for the deletion to play out.
+	winner, over := match.RoundWinner(env.s)
+	switch winner {
+	case match.SideOfferer:
+		result.OffererReward += WinReward
+		result.AnswererReward -= WinReward
+	case match.SideAnswerer:
+		result.OffererReward -= WinReward
+		result.AnswererReward += WinReward
+	}
+	env.done = over || env.tick >= env.opts.MaxTicks
+
+	result.Observation = env.Observation()
+	result.Done = env.done
+	result.Winner = winner
+	return result, nil
+}
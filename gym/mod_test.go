@@ -0,0 +1,147 @@
+package gym
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+)
+
+var testOptions = Options{
+	Data:           gamedata.NewData(),
+	OffererFolder:  state.Folder{{Chip: chips.Vulcan1, Code: 'C'}, {Chip: chips.Cannon, Code: 'C'}},
+	AnswererFolder: nil,
+	MaxTicks:       600,
+}
+
+func TestObservation(t *testing.T) {
+	env := NewEnv(testOptions)
+	obs := env.Reset(1)
+
+	if obs.Offerer.X != 2 || obs.Answerer.X != 5 {
+		t.Errorf("unexpected positions: offerer at %d, answerer at %d", obs.Offerer.X, obs.Answerer.X)
+	}
+	if obs.Offerer.Behavior != BehaviorKindIdle {
+		t.Errorf("expected offerer to be idle, got %d", obs.Offerer.Behavior)
+	}
+	if obs.Phase != state.MatchPhaseCustom {
+		t.Errorf("expected to start on the custom screen, got phase %d", obs.Phase)
+	}
+	// The folder is shuffled, so the chips may be drawn in either order.
+	hand := obs.Offerer.Hand
+	if hand[0] == chips.Cannon.Index {
+		hand[0], hand[1] = hand[1], hand[0]
+	}
+	if obs.Offerer.NumChips != 0 || hand[0] != chips.Vulcan1.Index || hand[1] != chips.Cannon.Index || hand[2] != -1 || obs.Offerer.Selected[0] != -1 {
+		t.Errorf("unexpected chips: %d %v %v", obs.Offerer.NumChips, obs.Offerer.Hand, obs.Offerer.Selected)
+	}
+	if obs.Offerer.HandCodes[0] != 'C' || obs.Offerer.HandCodes[2] != -1 {
+		t.Errorf("unexpected chip codes: %v", obs.Offerer.HandCodes)
+	}
+	if obs.Field[state.TilePosXY(1, 1)].Kind != TileKindNormal || obs.Field[state.TilePosXY(0, 0)].Kind != TileKindNone {
+		t.Errorf("unexpected field: %+v", obs.Field)
+	}
+	if !obs.Field[state.TilePosXY(4, 2)].AlliedWithAnswerer || obs.Field[state.TilePosXY(3, 2)].AlliedWithAnswerer {
+		t.Errorf("unexpected ownership: %+v", obs.Field)
+	}
+	if got := len(obs.Vector()); got != VectorSize {
+		t.Errorf("expected vector of size %d, got %d", VectorSize, got)
+	}
+}
+
+func TestStep(t *testing.T) {
+	env := NewEnv(testOptions)
+	if _, err := env.Step(state.Intent{}, state.Intent{}); err != ErrNotReset {
+		t.Fatalf("expected ErrNotReset, got %v", err)
+	}
+
+	env.Reset(1)
+
+	// The answerer picks nothing.
+	for i, intent := range []state.Intent{{UseChip: true}, {}, {Direction: state.DirectionRight}, {}, {UseChip: true}, {}, {Confirm: true}} {
+		if _, err := env.Step(intent, state.Intent{Confirm: i == 0}); err != nil {
+			t.Fatalf("custom screen step %d: %s", i, err)
+		}
+	}
+	if obs := env.Observation(); obs.Phase != state.MatchPhaseBattle || obs.Offerer.NumChips != 2 {
+		t.Fatalf("expected battle with 2 chips, got phase %d with %d chips", obs.Phase, obs.Offerer.NumChips)
+	}
+
+	var reward float64
+	var result StepResult
+	for i := 0; !result.Done; i++ {
+		var err error
+		result, err = env.Step(state.Intent{UseChip: i%2 == 0, ChargeBasicWeapon: i%60 != 0}, state.Intent{})
+		if err != nil {
+			t.Fatalf("step %d: %s", i, err)
+		}
+		if result.OffererReward != -result.AnswererReward {
+			t.Fatalf("step %d: rewards are not zero-sum: %f, %f", i, result.OffererReward, result.AnswererReward)
+		}
+		reward += result.OffererReward
+	}
+
+	if result.Observation.Tick > testOptions.MaxTicks {
+		t.Errorf("episode went on for too long: %d ticks", result.Observation.Tick)
+	}
+	if result.Winner == match.SideOfferer && reward <= WinReward {
+		t.Errorf("expected offerer to be rewarded for damage as well as winning, got %f", reward)
+	}
+	if reward <= 0 {
+		t.Errorf("expected offerer to be rewarded, got %f", reward)
+	}
+	if result.Observation.Offerer.NumChips != 0 {
+		t.Errorf("expected offerer to have used all chips, has %d left", result.Observation.Offerer.NumChips)
+	}
+
+	if _, err := env.Step(state.Intent{}, state.Intent{}); err != ErrDone {
+		t.Errorf("expected ErrDone, got %v", err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	requests := strings.Join([]string{
+		`{"cmd":"reset","seed":1}`,
+		`{"cmd":"step","offerer":{"direction":1},"answerer":{}}`,
+		`{"cmd":"bogus"}`,
+		`not json`,
+		`{"cmd":"close"}`,
+		`{"cmd":"reset","seed":1}`,
+	}, "\n")
+
+	var out strings.Builder
+	if err := NewEnv(testOptions).Serve(strings.NewReader(requests), &out); err != nil {
+		t.Fatalf("Serve: %s", err)
+	}
+
+	var responses []Response
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			t.Fatalf("bad response %q: %s", scanner.Text(), err)
+		}
+		responses = append(responses, resp)
+	}
+
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 responses, got %d", len(responses))
+	}
+	if responses[0].Error != "" || responses[0].Observation == nil || len(responses[0].Vector) != VectorSize {
+		t.Errorf("bad reset response: %+v", responses[0])
+	}
+	if responses[1].Error != "" || responses[1].Observation == nil || responses[1].Observation.Tick != 1 {
+		t.Errorf("bad step response: %+v", responses[1])
+	}
+	for _, resp := range responses[2:] {
+		if resp.Error == "" {
+			t.Errorf("expected error, got %+v", resp)
+		}
+	}
+}
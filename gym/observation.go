@@ -0,0 +1,276 @@
+package gym
+
+import (
+	"reflect"
+
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/state"
+)
+
+const MaxChips = 30
+
+// TileKinds are never renumbered, so that trained models keep working.
+type TileKind int
+
+const (
+	TileKindNone    TileKind = 0
+	TileKindNormal  TileKind = 1
+	TileKindHole    TileKind = 2
+	TileKindBroken  TileKind = 3
+	TileKindCracked TileKind = 4
+	TileKindRoad    TileKind = 5
+	TileKindIce     TileKind = 6
+)
+
+var tileKinds = map[reflect.Type]TileKind{
+	reflect.TypeOf(&state.NormalTileBehavior{}):  TileKindNormal,
+	reflect.TypeOf(&state.HoleTileBehavior{}):    TileKindHole,
+	reflect.TypeOf(&state.BrokenTileBehavior{}):  TileKindBroken,
+	reflect.TypeOf(&state.CrackedTileBehavior{}): TileKindCracked,
+	reflect.TypeOf(&state.RoadTileBehavior{}):    TileKindRoad,
+	reflect.TypeOf(&state.IceTileBehavior{}):     TileKindIce,
+}
+
+// BehaviorKinds are never renumbered either.
+type BehaviorKind int
+
+const (
+	BehaviorKindOther     BehaviorKind = 0
+	BehaviorKindIdle      BehaviorKind = 1
+	BehaviorKindTeleport  BehaviorKind = 2
+	BehaviorKindBuster    BehaviorKind = 3
+	BehaviorKindFlinch    BehaviorKind = 4
+	BehaviorKindParalyzed BehaviorKind = 5
+	BehaviorKindFrozen    BehaviorKind = 6
+	BehaviorKindBubbled   BehaviorKind = 7
+	BehaviorKindCannon    BehaviorKind = 8
+	BehaviorKindSword     BehaviorKind = 9
+	BehaviorKindVulcan    BehaviorKind = 10
+	BehaviorKindAirShot   BehaviorKind = 11
+	BehaviorKindRecov     BehaviorKind = 12
+	BehaviorKindWindFan   BehaviorKind = 13
+	BehaviorKindWindRack  BehaviorKind = 14
+	BehaviorKindAreaGrab  BehaviorKind = 15
+)
+
+var behaviorKinds = map[reflect.Type]BehaviorKind{
+	reflect.TypeOf(&behaviors.Idle{}):      BehaviorKindIdle,
+	reflect.TypeOf(&behaviors.Teleport{}):  BehaviorKindTeleport,
+	reflect.TypeOf(&behaviors.Buster{}):    BehaviorKindBuster,
+	reflect.TypeOf(&behaviors.Flinch{}):    BehaviorKindFlinch,
+	reflect.TypeOf(&behaviors.Paralyzed{}): BehaviorKindParalyzed,
+	reflect.TypeOf(&behaviors.Frozen{}):    BehaviorKindFrozen,
+	reflect.TypeOf(&behaviors.Bubbled{}):   BehaviorKindBubbled,
+	reflect.TypeOf(&behaviors.Cannon{}):    BehaviorKindCannon,
+	reflect.TypeOf(&behaviors.Sword{}):     BehaviorKindSword,
+	reflect.TypeOf(&behaviors.Vulcan{}):    BehaviorKindVulcan,
+	reflect.TypeOf(&behaviors.AirShot{}):   BehaviorKindAirShot,
+	reflect.TypeOf(&behaviors.Recov{}):     BehaviorKindRecov,
+	reflect.TypeOf(&behaviors.WindFan{}):   BehaviorKindWindFan,
+	reflect.TypeOf(&behaviors.WindRack{}):  BehaviorKindWindRack,
+	reflect.TypeOf(&behaviors.AreaGrab{}):  BehaviorKindAreaGrab,
+}
+
+type TileObservation struct {
+	AlliedWithAnswerer bool     `json:"allied_with_answerer"`
+	Kind               TileKind `json:"kind"`
+}
+
+type EntityObservation struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+
+	HP    int `json:"hp"`
+	MaxHP int `json:"max_hp"`
+
+	Behavior            BehaviorKind `json:"behavior"`
+	BehaviorElapsedTime int          `json:"behavior_elapsed_time"`
+
+	ChargingTime        int `json:"charging_time"`
+	PowerShotChargeTime int `json:"power_shot_charge_time"`
+
+	ParalyzedTimeLeft      int `json:"paralyzed_time_left"`
+	FrozenTimeLeft         int `json:"frozen_time_left"`
+	BubbledTimeLeft        int `json:"bubbled_time_left"`
+	ConfusedTimeLeft       int `json:"confused_time_left"`
+	BlindedTimeLeft        int `json:"blinded_time_left"`
+	ImmobilizedTimeLeft    int `json:"immobilized_time_left"`
+	FlashingTimeLeft       int `json:"flashing_time_left"`
+	InvincibleTimeLeft     int `json:"invincible_time_left"`
+	ChipUseLockoutTimeLeft int `json:"chip_use_lockout_time_left"`
+
+	Emotion state.Emotion `json:"emotion"`
+
+	// Chips are indexes, next to be used first, padded with -1.
+	NumChips int           `json:"num_chips"`
+	Chips    [MaxChips]int `json:"chips"`
+
+	// Hand, HandCodes and Selected are padded with -1. Selected are positions
+	// in Hand, in the order they were picked.
+	FolderSize      int                         `json:"folder_size"`
+	Hand            [state.HandSize]int         `json:"hand"`
+	HandCodes       [state.HandSize]int         `json:"hand_codes"`
+	Selected        [state.MaxSelectedChips]int `json:"selected"`
+	Cursor          int                         `json:"cursor"`
+	CustomConfirmed bool                        `json:"custom_confirmed"`
+}
+
+// Observation is as the offerer sees the field: the answerer is on the right.
+type Observation struct {
+	Tick int `json:"tick"`
+
+	// Phase tells the custom screen from battle: the episode ends with the round.
+	Phase       state.MatchPhase `json:"phase"`
+	CustomGauge int              `json:"custom_gauge"`
+
+	// Field is indexed by y*state.TileCols+x, hidden edge tiles included.
+	Field [state.TileRows * state.TileCols]TileObservation `json:"field"`
+
+	Offerer  EntityObservation `json:"offerer"`
+	Answerer EntityObservation `json:"answerer"`
+}
+
+func timeLeft(duration state.Ticks, elapsed state.Ticks) int {
+	if elapsed >= duration {
+		return 0
+	}
+	return int(duration - elapsed)
+}
+
+func observeEntity(e *state.Entity) EntityObservation {
+	x, y := e.TilePos.XY()
+	o := EntityObservation{
+		X: x,
+		Y: y,
+
+		HP:    e.HP,
+		MaxHP: e.MaxHP,
+
+		Behavior:            behaviorKinds[reflect.TypeOf(e.BehaviorState.Behavior)],
+		BehaviorElapsedTime: int(e.BehaviorState.ElapsedTime),
+
+		PowerShotChargeTime: int(e.PowerShotChargeTime),
+
+		ConfusedTimeLeft:       int(e.ConfusedTimeLeft),
+		BlindedTimeLeft:        int(e.BlindedTimeLeft),
+		ImmobilizedTimeLeft:    int(e.ImmobilizedTimeLeft),
+		FlashingTimeLeft:       int(e.Flashing.TimeLeft),
+		InvincibleTimeLeft:     int(e.InvincibleTimeLeft),
+		ChipUseLockoutTimeLeft: int(e.ChipUseLockoutTimeLeft),
+
+		Emotion: e.Emotion,
+
+		NumChips: len(e.Chips),
+	}
+
+	switch b := e.BehaviorState.Behavior.(type) {
+	case *behaviors.Idle:
+		o.ChargingTime = int(b.ChargingElapsedTime)
+	case *behaviors.Teleport:
+		o.ChargingTime = int(b.ChargingElapsedTime)
+	case *behaviors.Paralyzed:
+		o.ParalyzedTimeLeft = timeLeft(b.Duration, e.BehaviorState.ElapsedTime)
+	case *behaviors.Frozen:
+		o.FrozenTimeLeft = timeLeft(b.Duration, e.BehaviorState.ElapsedTime)
+	case *behaviors.Bubbled:
+		o.BubbledTimeLeft = timeLeft(b.Duration, e.BehaviorState.ElapsedTime)
+	}
+
+	for i := range o.Chips {
+		o.Chips[i] = -1
+		if j := len(e.Chips) - 1 - i; j >= 0 {
+			o.Chips[i] = e.Chips[j].Index
+		}
+	}
+
+	o.FolderSize = len(e.Custom.Folder)
+	for i := range o.Hand {
+		o.Hand[i] = -1
+		o.HandCodes[i] = -1
+		if i < len(e.Custom.Hand) {
+			o.Hand[i] = e.Custom.Hand[i].Chip.Index
+			o.HandCodes[i] = int(e.Custom.Hand[i].Code)
+		}
+	}
+	for i := range o.Selected {
+		o.Selected[i] = -1
+		if i < len(e.Custom.Selected) {
+			o.Selected[i] = e.Custom.Selected[i]
+		}
+	}
+	o.Cursor = e.Custom.Cursor
+	o.CustomConfirmed = e.Custom.Confirmed
+
+	return o
+}
+
+func observe(s *state.State, tick int, offererEntityID state.EntityID, answererEntityID state.EntityID) Observation {
+	o := Observation{
+		Tick:        tick,
+		Phase:       s.Match.Phase,
+		CustomGauge: int(s.Match.CustomGauge),
+		Offerer:     observeEntity(s.Entities[offererEntityID]),
+		Answerer:    observeEntity(s.Entities[answererEntityID]),
+	}
+	for i, t := range s.Field.Tiles {
+		o.Field[i] = TileObservation{
+			AlliedWithAnswerer: t.IsAlliedWithAnswerer,
+			Kind:               tileKinds[reflect.TypeOf(t.BehaviorState.Behavior)],
+		}
+	}
+	return o
+}
+
+func (o EntityObservation) appendVector(v []float32) []float32 {
+	v = append(v,
+		float32(o.X), float32(o.Y),
+		float32(o.HP), float32(o.MaxHP),
+		float32(o.Behavior), float32(o.BehaviorElapsedTime),
+		float32(o.ChargingTime), float32(o.PowerShotChargeTime),
+		float32(o.ParalyzedTimeLeft), float32(o.FrozenTimeLeft), float32(o.BubbledTimeLeft),
+		float32(o.ConfusedTimeLeft), float32(o.BlindedTimeLeft), float32(o.ImmobilizedTimeLeft),
+		float32(o.FlashingTimeLeft), float32(o.InvincibleTimeLeft), float32(o.ChipUseLockoutTimeLeft),
+		float32(o.Emotion),
+		float32(o.NumChips),
+	)
+	for _, c := range o.Chips {
+		v = append(v, float32(c))
+	}
+	v = append(v, float32(o.FolderSize))
+	for _, c := range o.Hand {
+		v = append(v, float32(c))
+	}
+	for _, c := range o.HandCodes {
+		v = append(v, float32(c))
+	}
+	for _, i := range o.Selected {
+		v = append(v, float32(i))
+	}
+	confirmed := float32(0)
+	if o.CustomConfirmed {
+		confirmed = 1
+	}
+	v = append(v, float32(o.Cursor), confirmed)
+	return v
+}
+
+const entityVectorSize = 19 + MaxChips + 1 + 2*state.HandSize + state.MaxSelectedChips + 2
+
+const VectorSize = 3 + 2*len(Observation{}.Field) + 2*entityVectorSize
+
+// Vector flattens the fields of Observation in order. Each tile is whether it
+// is allied with the answerer, then its kind.
+func (o Observation) Vector() []float32 {
+	v := make([]float32, 0, VectorSize)
+	v = append(v, float32(o.Tick), float32(o.Phase), float32(o.CustomGauge))
+	for _, t := range o.Field {
+		allied := float32(0)
+		if t.AlliedWithAnswerer {
+			allied = 1
+		}
+		v = append(v, allied, float32(t.Kind))
+	}
+	v = o.Offerer.appendVector(v)
+	v = o.Answerer.appendVector(v)
+	return v
+}
@@ -0,0 +1,115 @@
+package gym
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+)
+
+// IntentMessage's Direction is a bitmask of 1 for up, 2 for down, 4 for left
+// and 8 for right.
+type IntentMessage struct {
+	Direction         int  `json:"direction"`
+	UseChip           bool `json:"use_chip"`
+	Confirm           bool `json:"confirm"`
+	CutIn             bool `json:"cut_in"`
+	EndTurn           bool `json:"end_turn"`
+	ChargeBasicWeapon bool `json:"charge_basic_weapon"`
+}
+
+func (m IntentMessage) Intent() state.Intent {
+	return state.Intent{
+		Direction:         state.Direction(m.Direction) & (state.DirectionUp | state.DirectionDown | state.DirectionLeft | state.DirectionRight),
+		UseChip:           m.UseChip,
+		Confirm:           m.Confirm,
+		CutIn:             m.CutIn,
+		EndTurn:           m.EndTurn,
+		ChargeBasicWeapon: m.ChargeBasicWeapon,
+	}
+}
+
+// Request's Cmd is "reset" with a Seed, "step" with intents, or "close".
+type Request struct {
+	Cmd  string `json:"cmd"`
+	Seed int64  `json:"seed"`
+
+	Offerer  IntentMessage `json:"offerer"`
+	Answerer IntentMessage `json:"answerer"`
+}
+
+// Response only has Error set if the request failed.
+type Response struct {
+	Error string `json:"error,omitempty"`
+
+	Observation *Observation `json:"observation,omitempty"`
+	Vector      []float32    `json:"vector,omitempty"`
+
+	OffererReward  float64    `json:"offerer_reward"`
+	AnswererReward float64    `json:"answerer_reward"`
+	Done           bool       `json:"done"`
+	Winner         match.Side `json:"winner"`
+}
+
+func (env *Env) handle(req Request) (Response, error) {
+	switch req.Cmd {
+	case "reset":
+		obs := env.Reset(req.Seed)
+		return Response{Observation: &obs, Vector: obs.Vector()}, nil
+	case "step":
+		result, err := env.Step(req.Offerer.Intent(), req.Answerer.Intent())
+		if err != nil {
+			return Response{}, err
+		}
+		return Response{
+			Observation:    &result.Observation,
+			Vector:         result.Observation.Vector(),
+			OffererReward:  result.OffererReward,
+			AnswererReward: result.AnswererReward,
+			Done:           result.Done,
+			Winner:         result.Winner,
+		}, nil
+	}
+	return Response{}, fmt.Errorf("gym: unknown command %q", req.Cmd)
+}
+
+// Serve reads and writes one JSON message per line. Bad requests are answered
+// with an error rather than ending the session.
+func (env *Env) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp = Response{Error: fmt.Sprintf("gym: malformed request: %s", err)}
+		} else if req.Cmd == "close" {
+			return bw.Flush()
+		} else if resp, err = env.handle(req); err != nil {
+			resp = Response{Error: err.Error()}
+		}
+
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
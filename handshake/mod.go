@@ -0,0 +1,109 @@
+package handshake
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/transport"
+)
+
+var (
+	ErrProtocolVersionMismatch = errors.New("protocol version mismatch")
+	ErrRulesMismatch           = errors.New("simulation rules mismatch")
+	ErrInvalidFolder           = errors.New("invalid folder")
+	ErrInvalidBestOf           = errors.New("invalid number of rounds")
+)
+
+// MakeHello's folder must be legal under the default folder rules.
+func MakeHello(folder state.Folder, bestOf int) (packets.Hello, error) {
+	var hello packets.Hello
+	if err := validateFolder(folder); err != nil {
+		return hello, err
+	}
+	if bestOf < 1 || bestOf > math.MaxUint8 {
+		return hello, fmt.Errorf("%w: %d", ErrInvalidBestOf, bestOf)
+	}
+	hello.BestOf = uint8(bestOf)
+	hello.ProtocolVersion = packets.ProtocolVersion
+	hello.RulesHash = state.RulesHash()
+	for i, fc := range folder {
+		hello.Folder[i] = packets.FolderChip{Chip: uint16(fc.Chip.Index), Code: uint8(fc.Code)}
+	}
+	return hello, nil
+}
+
+func validateFolder(folder state.Folder) error {
+	if len(folder) != packets.FolderSize {
+		return fmt.Errorf("%w: %d chips, must be %d", ErrInvalidFolder, len(folder), packets.FolderSize)
+	}
+	if err := folder.Validate(state.DefaultFolderRules); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidFolder, err)
+	}
+	return nil
+}
+
+// HelloFolder's folder must be legal under the default folder rules.
+func HelloFolder(hello packets.Hello) (state.Folder, error) {
+	folder := make(state.Folder, len(hello.Folder))
+	for i, fc := range hello.Folder {
+		chip, ok := state.ChipByIndex(int(fc.Chip))
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown chip %d", ErrInvalidFolder, fc.Chip)
+		}
+		folder[i] = state.FolderChip{Chip: chip, Code: state.ChipCode(fc.Code)}
+	}
+	if err := validateFolder(folder); err != nil {
+		return nil, err
+	}
+	return folder, nil
+}
+
+// Exchange must happen before anything else is sent over the transport. If
+// allowRulesMismatch is set, a rules mismatch is only logged, but the match
+// will most likely desync.
+func Exchange(ctx context.Context, t transport.Transport, hello packets.Hello, allowRulesMismatch bool) (packets.Hello, error) {
+	if err := packets.Send(ctx, t, hello); err != nil {
+		return packets.Hello{}, fmt.Errorf("failed to send hello: %w", err)
+	}
+
+	packet, err := packets.Recv(ctx, t)
+	if err != nil {
+		return packets.Hello{}, fmt.Errorf("failed to receive hello: %w", err)
+	}
+	theirHello, ok := packet.(packets.Hello)
+	if !ok {
+		return packets.Hello{}, fmt.Errorf("%w: expected hello, got %T (is the peer running an old build?)", ErrProtocolVersionMismatch, packet)
+	}
+
+	if theirHello.ProtocolVersion != hello.ProtocolVersion {
+		return packets.Hello{}, fmt.Errorf("%w: ours is %d, theirs is %d", ErrProtocolVersionMismatch, hello.ProtocolVersion, theirHello.ProtocolVersion)
+	}
+
+	if theirHello.RulesHash != hello.RulesHash {
+		err := fmt.Errorf("%w: ours is %s, theirs is %s", ErrRulesMismatch, hex.EncodeToString(hello.RulesHash[:]), hex.EncodeToString(theirHello.RulesHash[:]))
+		if !allowRulesMismatch {
+			return packets.Hello{}, err
+		}
+		log.Printf("warning: %s, continuing anyway", err)
+	}
+
+	// Otherwise a side whose own folder is illegal waits on a peer that gave up.
+	if _, err := HelloFolder(hello); err != nil {
+		return packets.Hello{}, fmt.Errorf("our folder: %w", err)
+	}
+	if _, err := HelloFolder(theirHello); err != nil {
+		return packets.Hello{}, fmt.Errorf("their folder: %w", err)
+	}
+
+	if theirHello.BestOf < 1 {
+		return packets.Hello{}, fmt.Errorf("%w: %d", ErrInvalidBestOf, theirHello.BestOf)
+	}
+
+	return theirHello, nil
+}
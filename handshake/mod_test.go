@@ -0,0 +1,115 @@
+package handshake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/transport"
+)
+
+func newTestHello(t *testing.T) packets.Hello {
+	t.Helper()
+	hello, err := MakeHello(chips.DefaultFolder, 3)
+	if err != nil {
+		t.Fatalf("MakeHello: %s", err)
+	}
+	return hello
+}
+
+func exchange(t *testing.T, offererHello packets.Hello, answererHello packets.Hello, allowRulesMismatch bool) (error, error) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a, b := transport.NewLoopbackPair()
+	defer a.Close()
+	defer b.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := Exchange(ctx, b, answererHello, allowRulesMismatch)
+		errs <- err
+	}()
+	_, offererErr := Exchange(ctx, a, offererHello, allowRulesMismatch)
+	answererErr := <-errs
+
+	if errors.Is(offererErr, context.DeadlineExceeded) || errors.Is(answererErr, context.DeadlineExceeded) {
+		t.Fatalf("exchange hung: %v, %v", offererErr, answererErr)
+	}
+	return offererErr, answererErr
+}
+
+func TestExchange(t *testing.T) {
+	offererErr, answererErr := exchange(t, newTestHello(t), newTestHello(t), false)
+	if offererErr != nil || answererErr != nil {
+		t.Errorf("expected no errors, got %v and %v", offererErr, answererErr)
+	}
+}
+
+func TestExchangeMismatches(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		modify             func(hello *packets.Hello)
+		allowRulesMismatch bool
+		want               error
+	}{
+		{
+			name: "protocol version",
+			modify: func(hello *packets.Hello) {
+				hello.ProtocolVersion++
+			},
+			want: ErrProtocolVersionMismatch,
+		},
+		{
+			name: "rules",
+			modify: func(hello *packets.Hello) {
+				hello.RulesHash[0] ^= 0xff
+			},
+			want: ErrRulesMismatch,
+		},
+		{
+			name: "rules allowed",
+			modify: func(hello *packets.Hello) {
+				hello.RulesHash[0] ^= 0xff
+			},
+			allowRulesMismatch: true,
+		},
+		{
+			name: "unknown chip",
+			modify: func(hello *packets.Hello) {
+				hello.Folder[0].Chip = 0xffff
+			},
+			want: ErrInvalidFolder,
+		},
+		{
+			name: "illegal folder",
+			modify: func(hello *packets.Hello) {
+				for i := range hello.Folder {
+					hello.Folder[i] = hello.Folder[0]
+				}
+			},
+			want: ErrInvalidFolder,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			badHello := newTestHello(t)
+			tc.modify(&badHello)
+
+			offererErr, answererErr := exchange(t, newTestHello(t), badHello, tc.allowRulesMismatch)
+			for side, err := range map[string]error{"offerer": offererErr, "answerer": answererErr} {
+				if tc.want == nil {
+					if err != nil {
+						t.Errorf("expected no error for the %s, got %v", side, err)
+					}
+				} else if !errors.Is(err, tc.want) {
+					t.Errorf("expected %v for the %s, got %v", tc.want, side, err)
+				}
+			}
+		})
+	}
+}
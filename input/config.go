@@ -0,0 +1,154 @@
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// DefaultProfile reads from both the keyboard and the first gamepad.
+const DefaultProfile = "default"
+
+var ProfileNames = []string{DefaultProfile, "keyboard_left", "keyboard_right", "gamepad1", "gamepad2"}
+
+var profileGamepadIndexes = map[string]int{
+	DefaultProfile:   0,
+	"keyboard_left":  -1,
+	"keyboard_right": -1,
+	"gamepad1":       0,
+	"gamepad2":       1,
+}
+
+type Config struct {
+	Profiles map[string]*Bindings `json:"profiles"`
+}
+
+var defaultGamepadBindings = Bindings{
+	ActionUp:                {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonLeftTop}},
+	ActionDown:              {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonLeftBottom}},
+	ActionLeft:              {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonLeftLeft}},
+	ActionRight:             {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonLeftRight}},
+	ActionUseChip:           {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightBottom}},
+	ActionConfirm:           {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonCenterRight}},
+	ActionCutIn:             {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightTop}},
+	ActionEndTurn:           {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonFrontTopLeft, ebiten.StandardGamepadButtonFrontTopRight}},
+	ActionChargeBasicWeapon: {GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightRight}},
+}
+
+func keys(ks ...ebiten.Key) Binding {
+	return Binding{Keys: ks}
+}
+
+func withGamepad(b Bindings) Bindings {
+	for a := range b {
+		b[a].GamepadButtons = defaultGamepadBindings[a].GamepadButtons
+	}
+	return b
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Profiles: map[string]*Bindings{
+			DefaultProfile: func() *Bindings {
+				b := withGamepad(Bindings{
+					ActionUp:                keys(ebiten.KeyArrowUp),
+					ActionDown:              keys(ebiten.KeyArrowDown),
+					ActionLeft:              keys(ebiten.KeyArrowLeft),
+					ActionRight:             keys(ebiten.KeyArrowRight),
+					ActionUseChip:           keys(ebiten.KeyZ),
+					ActionConfirm:           keys(ebiten.KeyEnter),
+					ActionCutIn:             keys(ebiten.KeyD),
+					ActionEndTurn:           keys(ebiten.KeyA, ebiten.KeyS),
+					ActionChargeBasicWeapon: keys(ebiten.KeyX),
+				})
+				return &b
+			}(),
+			"keyboard_left": {
+				ActionUp:                keys(ebiten.KeyW),
+				ActionDown:              keys(ebiten.KeyS),
+				ActionLeft:              keys(ebiten.KeyA),
+				ActionRight:             keys(ebiten.KeyD),
+				ActionUseChip:           keys(ebiten.KeyF),
+				ActionConfirm:           keys(ebiten.KeyE),
+				ActionCutIn:             keys(ebiten.KeyT),
+				ActionEndTurn:           keys(ebiten.KeyR),
+				ActionChargeBasicWeapon: keys(ebiten.KeyG),
+			},
+			"keyboard_right": {
+				ActionUp:                keys(ebiten.KeyArrowUp),
+				ActionDown:              keys(ebiten.KeyArrowDown),
+				ActionLeft:              keys(ebiten.KeyArrowLeft),
+				ActionRight:             keys(ebiten.KeyArrowRight),
+				ActionUseChip:           keys(ebiten.KeySlash, ebiten.KeyNumpad1),
+				ActionConfirm:           keys(ebiten.KeyComma, ebiten.KeyNumpad0),
+				ActionCutIn:             keys(ebiten.KeyControlRight, ebiten.KeyNumpad4),
+				ActionEndTurn:           keys(ebiten.KeyEnter, ebiten.KeyNumpad3),
+				ActionChargeBasicWeapon: keys(ebiten.KeyShiftRight, ebiten.KeyNumpad2),
+			},
+			"gamepad1": func() *Bindings { b := defaultGamepadBindings; return &b }(),
+			"gamepad2": func() *Bindings { b := defaultGamepadBindings; return &b }(),
+		},
+	}
+}
+
+func (c *Config) ResetProfile(name string) {
+	*c.Profiles[name] = *DefaultConfig().Profiles[name]
+}
+
+// Source keeps reading the profile's bindings, so rebinding takes effect
+// immediately.
+func (c *Config) Source(name string) (Source, error) {
+	bindings, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown input %q, must be one of %v", name, ProfileNames)
+	}
+	return Device{Bindings: bindings, GamepadIndex: profileGamepadIndexes[name]}, nil
+}
+
+// ParseConfig keeps the defaults of anything missing.
+func ParseConfig(buf []byte) (*Config, error) {
+	c := DefaultConfig()
+	var j struct {
+		Profiles map[string]json.RawMessage `json:"profiles"`
+	}
+	if err := json.Unmarshal(buf, &j); err != nil {
+		return nil, err
+	}
+	for name, raw := range j.Profiles {
+		bindings, ok := c.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q", name)
+		}
+		if err := json.Unmarshal(raw, bindings); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *Config) Marshal() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+var errNoSavedConfig = errors.New("no saved config")
+
+func LoadConfig() (*Config, error) {
+	buf, err := readConfig()
+	if errors.Is(err, errNoSavedConfig) {
+		return DefaultConfig(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(buf)
+}
+
+func (c *Config) Save() error {
+	buf, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+	return writeConfig(buf)
+}
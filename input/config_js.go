@@ -0,0 +1,22 @@
+//go:build js
+
+package input
+
+import (
+	"syscall/js"
+)
+
+const configStorageKey = "nbarena.input"
+
+func readConfig() ([]byte, error) {
+	v := js.Global().Get("localStorage").Call("getItem", configStorageKey)
+	if v.IsNull() {
+		return nil, errNoSavedConfig
+	}
+	return []byte(v.String()), nil
+}
+
+func writeConfig(buf []byte) error {
+	js.Global().Get("localStorage").Call("setItem", configStorageKey, string(buf))
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build !js
+
+package input
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// If ConfigPath is empty, nothing is loaded or saved.
+var ConfigPath = defaultConfigPath()
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "nbarena", "input.json")
+}
+
+func readConfig() ([]byte, error) {
+	if ConfigPath == "" {
+		return nil, errNoSavedConfig
+	}
+	buf, err := os.ReadFile(ConfigPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errNoSavedConfig
+	}
+	return buf, err
+}
+
+func writeConfig(buf []byte) error {
+	if ConfigPath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(ConfigPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(ConfigPath, buf, 0o644)
+}
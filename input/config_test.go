@@ -0,0 +1,96 @@
+package input
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/murkland/nbarena/state"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	c := DefaultConfig()
+	c.Profiles["keyboard_left"][ActionUseChip] = Binding{
+		Keys:           []ebiten.Key{ebiten.KeyQ, ebiten.KeySpace},
+		GamepadButtons: []ebiten.StandardGamepadButton{ebiten.StandardGamepadButtonRightLeft},
+	}
+	c.Profiles["gamepad2"][ActionEndTurn] = Binding{}
+
+	buf, err := c.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+	got, err := ParseConfig(buf)
+	if err != nil {
+		t.Fatalf("ParseConfig: %s", err)
+	}
+
+	for _, name := range ProfileNames {
+		for a := Action(0); a < NumActions; a++ {
+			want := c.Profiles[name][a]
+			binding := got.Profiles[name][a]
+			if len(want.Keys) != len(binding.Keys) || len(want.GamepadButtons) != len(binding.GamepadButtons) ||
+				(len(want.Keys) > 0 && !reflect.DeepEqual(want.Keys, binding.Keys)) ||
+				(len(want.GamepadButtons) > 0 && !reflect.DeepEqual(want.GamepadButtons, binding.GamepadButtons)) {
+				t.Errorf("%s %s: got %+v, want %+v", name, a, binding, want)
+			}
+		}
+	}
+}
+
+func TestParsePartialConfig(t *testing.T) {
+	c, err := ParseConfig([]byte(`{"profiles": {"keyboard_left": {"use_chip": {"keys": ["Q"]}}}}`))
+	if err != nil {
+		t.Fatalf("ParseConfig: %s", err)
+	}
+
+	defaults := DefaultConfig()
+	for _, name := range ProfileNames {
+		for a := Action(0); a < NumActions; a++ {
+			if name == "keyboard_left" && a == ActionUseChip {
+				continue
+			}
+			if !reflect.DeepEqual(c.Profiles[name][a], defaults.Profiles[name][a]) {
+				t.Errorf("%s %s: expected the default %+v, got %+v", name, a, defaults.Profiles[name][a], c.Profiles[name][a])
+			}
+		}
+	}
+
+	binding := c.Profiles["keyboard_left"][ActionUseChip]
+	if !reflect.DeepEqual(binding.Keys, []ebiten.Key{ebiten.KeyQ}) || len(binding.GamepadButtons) != 0 {
+		t.Errorf("expected use_chip to be bound to Q alone, got %+v", binding)
+	}
+}
+
+func TestParseConfigRejectsUnknownNames(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		want string
+	}{
+		{"profile", `{"profiles": {"keyboard_middle": {}}}`, `unknown profile "keyboard_middle"`},
+		{"action", `{"profiles": {"default": {"jump": {"keys": ["Space"]}}}}`, `unknown action "jump"`},
+		{"key", `{"profiles": {"default": {"up": {"keys": ["Hyper"]}}}}`, `unknown key "Hyper"`},
+		{"gamepad button", `{"profiles": {"default": {"up": {"gamepad_buttons": ["Turbo"]}}}}`, `unknown gamepad button "Turbo"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseConfig([]byte(tc.json))
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected an error containing %q, got %q", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestPressedKeysToIntent(t *testing.T) {
+	b := DefaultConfig().Profiles["keyboard_left"]
+	got := b.PressedKeysToIntent([]ebiten.Key{ebiten.KeyW, ebiten.KeyD, ebiten.KeyG, ebiten.KeyArrowUp})
+	want := state.Intent{Direction: state.DirectionUp | state.DirectionRight, ChargeBasicWeapon: true}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -2,33 +2,151 @@ package input
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/murkland/nbarena/state"
 )
 
-func PressedKeysToIntent(keys []ebiten.Key) state.Intent {
+type Source interface {
+	Intent() state.Intent
+}
+
+type Action int
+
+const (
+	ActionUp Action = iota
+	ActionDown
+	ActionLeft
+	ActionRight
+	ActionUseChip
+	ActionConfirm
+	ActionCutIn
+	ActionEndTurn
+	ActionChargeBasicWeapon
+
+	NumActions
+)
+
+var actionNames = [NumActions]string{
+	ActionUp:                "up",
+	ActionDown:              "down",
+	ActionLeft:              "left",
+	ActionRight:             "right",
+	ActionUseChip:           "use_chip",
+	ActionConfirm:           "confirm",
+	ActionCutIn:             "cut_in",
+	ActionEndTurn:           "end_turn",
+	ActionChargeBasicWeapon: "charge_basic_weapon",
+}
+
+func (a Action) String() string {
+	if a < 0 || a >= NumActions {
+		return ""
+	}
+	return actionNames[a]
+}
+
+type Binding struct {
+	Keys           []ebiten.Key
+	GamepadButtons []ebiten.StandardGamepadButton
+}
+
+type Bindings [NumActions]Binding
+
+func (b *Bindings) intent(isPressed func(Binding) bool) state.Intent {
 	var intent state.Intent
+	if isPressed(b[ActionUp]) {
+		intent.Direction |= state.DirectionUp
+	}
+	if isPressed(b[ActionDown]) {
+		intent.Direction |= state.DirectionDown
+	}
+	if isPressed(b[ActionLeft]) {
+		intent.Direction |= state.DirectionLeft
+	}
+	if isPressed(b[ActionRight]) {
+		intent.Direction |= state.DirectionRight
+	}
+	intent.UseChip = isPressed(b[ActionUseChip])
+	intent.Confirm = isPressed(b[ActionConfirm])
+	intent.CutIn = isPressed(b[ActionCutIn])
+	intent.EndTurn = isPressed(b[ActionEndTurn])
+	intent.ChargeBasicWeapon = isPressed(b[ActionChargeBasicWeapon])
+	return intent
+}
+
+func (b *Bindings) PressedKeysToIntent(keys []ebiten.Key) state.Intent {
+	pressed := make(map[ebiten.Key]bool, len(keys))
 	for _, k := range keys {
-		switch k {
-		case ebiten.KeyUp:
+		pressed[k] = true
+	}
+	return b.intent(func(binding Binding) bool {
+		for _, k := range binding.Keys {
+			if pressed[k] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+const gamepadAxisThreshold = 0.5
+
+// Device only reads from a gamepad if GamepadIndex is not negative. Bindings
+// may be changed while it is in use.
+type Device struct {
+	Bindings     *Bindings
+	GamepadIndex int
+}
+
+func (d Device) gamepadID() (ebiten.GamepadID, bool) {
+	if d.GamepadIndex < 0 {
+		return 0, false
+	}
+	ids := ebiten.GamepadIDs()
+	if d.GamepadIndex >= len(ids) {
+		return 0, false
+	}
+	id := ids[d.GamepadIndex]
+	if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+		return 0, false
+	}
+	return id, true
+}
+
+func (d Device) Intent() state.Intent {
+	id, hasGamepad := d.gamepadID()
+
+	intent := d.Bindings.intent(func(binding Binding) bool {
+		for _, k := range binding.Keys {
+			if ebiten.IsKeyPressed(k) {
+				return true
+			}
+		}
+		if !hasGamepad {
+			return false
+		}
+		for _, b := range binding.GamepadButtons {
+			if ebiten.IsStandardGamepadButtonPressed(id, b) {
+				return true
+			}
+		}
+		return false
+	})
+
+	if hasGamepad {
+		x := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		y := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+		if y <= -gamepadAxisThreshold {
 			intent.Direction |= state.DirectionUp
-		case ebiten.KeyDown:
+		}
+		if y >= gamepadAxisThreshold {
 			intent.Direction |= state.DirectionDown
-		case ebiten.KeyLeft:
+		}
+		if x <= -gamepadAxisThreshold {
 			intent.Direction |= state.DirectionLeft
-		case ebiten.KeyRight:
+		}
+		if x >= gamepadAxisThreshold {
 			intent.Direction |= state.DirectionRight
-		case ebiten.KeyZ:
-			intent.UseChip = true
-		case ebiten.KeyA, ebiten.KeyS:
-			intent.EndTurn = true
-		case ebiten.KeyX:
-			intent.ChargeBasicWeapon = true
 		}
 	}
 	return intent
 }
-
-func CurrentIntent() state.Intent {
-	return PressedKeysToIntent(inpututil.PressedKeys())
-}
@@ -0,0 +1,130 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var gamepadButtonNames = map[ebiten.StandardGamepadButton]string{
+	ebiten.StandardGamepadButtonRightBottom:      "RightBottom",
+	ebiten.StandardGamepadButtonRightRight:       "RightRight",
+	ebiten.StandardGamepadButtonRightLeft:        "RightLeft",
+	ebiten.StandardGamepadButtonRightTop:         "RightTop",
+	ebiten.StandardGamepadButtonFrontTopLeft:     "FrontTopLeft",
+	ebiten.StandardGamepadButtonFrontTopRight:    "FrontTopRight",
+	ebiten.StandardGamepadButtonFrontBottomLeft:  "FrontBottomLeft",
+	ebiten.StandardGamepadButtonFrontBottomRight: "FrontBottomRight",
+	ebiten.StandardGamepadButtonCenterLeft:       "CenterLeft",
+	ebiten.StandardGamepadButtonCenterRight:      "CenterRight",
+	ebiten.StandardGamepadButtonLeftStick:        "LeftStick",
+	ebiten.StandardGamepadButtonRightStick:       "RightStick",
+	ebiten.StandardGamepadButtonLeftTop:          "LeftTop",
+	ebiten.StandardGamepadButtonLeftBottom:       "LeftBottom",
+	ebiten.StandardGamepadButtonLeftLeft:         "LeftLeft",
+	ebiten.StandardGamepadButtonLeftRight:        "LeftRight",
+	ebiten.StandardGamepadButtonCenterCenter:     "CenterCenter",
+}
+
+var (
+	keysByName           = map[string]ebiten.Key{}
+	gamepadButtonsByName = map[string]ebiten.StandardGamepadButton{}
+)
+
+func init() {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if name := k.String(); name != "" {
+			keysByName[name] = k
+		}
+	}
+	for b, name := range gamepadButtonNames {
+		gamepadButtonsByName[name] = b
+	}
+}
+
+func KeyName(k ebiten.Key) string {
+	return k.String()
+}
+
+func GamepadButtonName(b ebiten.StandardGamepadButton) string {
+	return gamepadButtonNames[b]
+}
+
+type bindingJSON struct {
+	Keys           []string `json:"keys"`
+	GamepadButtons []string `json:"gamepad_buttons"`
+}
+
+func (b Binding) MarshalJSON() ([]byte, error) {
+	j := bindingJSON{
+		Keys:           []string{},
+		GamepadButtons: []string{},
+	}
+	for _, k := range b.Keys {
+		j.Keys = append(j.Keys, KeyName(k))
+	}
+	for _, gb := range b.GamepadButtons {
+		j.GamepadButtons = append(j.GamepadButtons, GamepadButtonName(gb))
+	}
+	return json.Marshal(j)
+}
+
+func (b *Binding) UnmarshalJSON(buf []byte) error {
+	var j bindingJSON
+	if err := json.Unmarshal(buf, &j); err != nil {
+		return err
+	}
+
+	var b2 Binding
+	for _, name := range j.Keys {
+		k, ok := keysByName[name]
+		if !ok {
+			return fmt.Errorf("unknown key %q", name)
+		}
+		b2.Keys = append(b2.Keys, k)
+	}
+	for _, name := range j.GamepadButtons {
+		gb, ok := gamepadButtonsByName[name]
+		if !ok {
+			return fmt.Errorf("unknown gamepad button %q", name)
+		}
+		b2.GamepadButtons = append(b2.GamepadButtons, gb)
+	}
+	*b = b2
+	return nil
+}
+
+func (b *Bindings) MarshalJSON() ([]byte, error) {
+	m := make(map[string]Binding, NumActions)
+	for a, binding := range b {
+		m[Action(a).String()] = binding
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON keeps the defaults of actions added after the config was
+// saved.
+func (b *Bindings) UnmarshalJSON(buf []byte) error {
+	var m map[string]Binding
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return err
+	}
+	for name, binding := range m {
+		a, ok := actionByName(name)
+		if !ok {
+			return fmt.Errorf("unknown action %q", name)
+		}
+		b[a] = binding
+	}
+	return nil
+}
+
+func actionByName(name string) (Action, bool) {
+	for a, n := range actionNames {
+		if n == name {
+			return Action(a), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,117 @@
+package input
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// RebindKey and Escape can't be bound.
+const RebindKey = ebiten.KeyF1
+
+// Rebinder is only navigated with the keyboard: up and down select an action,
+// left and right a profile, Enter captures a key or button, Backspace clears
+// the action and Delete resets the profile.
+type Rebinder struct {
+	config *Config
+
+	profile   int
+	action    Action
+	capturing bool
+}
+
+func NewRebinder(config *Config, profile string) *Rebinder {
+	r := &Rebinder{config: config}
+	for i, name := range ProfileNames {
+		if name == profile {
+			r.profile = i
+		}
+	}
+	return r
+}
+
+func (r *Rebinder) Profile() string {
+	return ProfileNames[r.profile]
+}
+
+func (r *Rebinder) Bindings() *Bindings {
+	return r.config.Profiles[r.Profile()]
+}
+
+func (r *Rebinder) Action() Action {
+	return r.action
+}
+
+func (r *Rebinder) Capturing() bool {
+	return r.capturing
+}
+
+func (r *Rebinder) capture() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(RebindKey) {
+		r.capturing = false
+		return
+	}
+
+	binding := &r.Bindings()[r.action]
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if !inpututil.IsKeyJustPressed(k) {
+			continue
+		}
+		for _, k2 := range binding.Keys {
+			if k2 == k {
+				r.capturing = false
+				return
+			}
+		}
+		binding.Keys = append(append([]ebiten.Key(nil), binding.Keys...), k)
+		r.capturing = false
+		return
+	}
+
+	for _, id := range ebiten.GamepadIDs() {
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			continue
+		}
+		for b := ebiten.StandardGamepadButton(0); b <= ebiten.StandardGamepadButtonMax; b++ {
+			if !inpututil.IsStandardGamepadButtonJustPressed(id, b) {
+				continue
+			}
+			for _, b2 := range binding.GamepadButtons {
+				if b2 == b {
+					r.capturing = false
+					return
+				}
+			}
+			binding.GamepadButtons = append(append([]ebiten.StandardGamepadButton(nil), binding.GamepadButtons...), b)
+			r.capturing = false
+			return
+		}
+	}
+}
+
+// Update returns true when the screen is closed.
+func (r *Rebinder) Update() bool {
+	if r.capturing {
+		r.capture()
+		return false
+	}
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape), inpututil.IsKeyJustPressed(RebindKey):
+		return true
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		r.action = (r.action + NumActions - 1) % NumActions
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		r.action = (r.action + 1) % NumActions
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft):
+		r.profile = (r.profile + len(ProfileNames) - 1) % len(ProfileNames)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowRight):
+		r.profile = (r.profile + 1) % len(ProfileNames)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		r.capturing = true
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		r.Bindings()[r.action] = Binding{}
+	case inpututil.IsKeyJustPressed(ebiten.KeyDelete):
+		r.config.ResetProfile(r.Profile())
+	}
+	return false
+}
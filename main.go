@@ -2,20 +2,37 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/murkland/clone"
-	"github.com/murkland/ctxwebrtc"
 	"github.com/murkland/moreflag"
+	"github.com/murkland/nbarena/agent"
 	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/chips"
 	"github.com/murkland/nbarena/game"
+	"github.com/murkland/nbarena/handshake"
+	"github.com/murkland/nbarena/input"
 	"github.com/murkland/nbarena/netsyncrand"
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/replay"
+	"github.com/murkland/nbarena/rollback"
+	"github.com/murkland/nbarena/spectate"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/transport"
+	"github.com/murkland/nbarena/transport/datachannel"
 	signorclient "github.com/murkland/signor/client"
 	"github.com/pion/webrtc/v3"
 )
@@ -47,43 +64,129 @@ var defaultWebRTCConfig = (func() string {
 })()
 
 var (
-	connectAddr      = flag.String("connect_addr", "http://localhost:12345", "address to connect to")
-	answer           = flag.Bool("answer", false, "if true, answers a session instead of offers")
-	sessionID        = flag.String("session_id", "test-session", "session to join to")
-	webrtcConfig     = flag.String("webrtc_config", defaultWebRTCConfig, "webrtc configuration")
-	delaysWindowSize = flag.Int("delays_window_size", 5, "size of window for calculating delay")
-	inputFrameDelay  = flag.Int("input_frame_delay", 0, "additional input frame delay, if any")
+	connectAddr        = flag.String("connect_addr", "http://localhost:12345", "address of the signaling server to connect to")
+	signalingMode      = flag.String("signaling", "signor", "how to exchange session descriptions for the webrtc transport: signor, to use the signaling server at -connect_addr, or manual, to copy and paste them between players")
+	answer             = flag.Bool("answer", false, "if true, answers a session instead of offers")
+	sessionID          = flag.String("session_id", "test-session", "session to join to")
+	webrtcConfig       = flag.String("webrtc_config", defaultWebRTCConfig, "webrtc configuration")
+	delaysWindowSize   = flag.Int("delays_window_size", 5, "size of window for calculating delay")
+	inputFrameDelay    = flag.Int("input_frame_delay", 0, "additional input frame delay, if any")
+	predictorName      = flag.String("predictor", "release_direction", "how to predict the opponent's inputs: one of "+strings.Join(rollback.PredictorNames, ", "))
+	recordReplay       = flag.String("record_replay", "", "if set, path to record a replay of the match to")
+	replayPath         = flag.String("replay", "", "if set, path of a replay to play back instead of connecting")
+	transportType      = flag.String("transport", "webrtc", "transport to play over: one of webrtc, tcp, udp. The udp transport is set up over tcp on the same address")
+	lanAddr            = flag.String("lan_addr", "localhost:12346", "for the tcp and udp transports, address to listen on if answering, or to connect to if offering")
+	simLatency         = flag.Duration("sim_latency", 0, "simulated latency to add to outgoing packets")
+	simJitter          = flag.Duration("sim_jitter", 0, "simulated jitter to add to outgoing packets")
+	simLossRate        = flag.Float64("sim_loss_rate", 0, "probability of dropping an outgoing packet")
+	simDuplicateRate   = flag.Float64("sim_duplicate_rate", 0, "probability of duplicating an outgoing packet")
+	simReorderRate     = flag.Float64("sim_reorder_rate", 0, "probability of letting an outgoing packet overtake earlier ones")
+	unreliableIntents  = flag.Bool("unreliable_intents", false, "for the webrtc transport, if true, sends match traffic over an unordered, unreliable data channel so that a lost packet doesn't stall both players while it's retransmitted")
+	bestOf             = flag.Int("best_of", 3, "how many rounds a match is played over: the first to win more than half of them wins. When playing over the network, the offerer's choice is used")
+	folderFlag         = flag.String("folder", "", "the chips in your folder, comma-separated, each a chip name and a code, e.g. \"Cannon A,Sword *\". It must have 30 chips. If empty, a default folder is used")
+	disconnectTimeout  = flag.Duration("disconnect_timeout", 5*time.Second, "how long the opponent may be silent for before the connection is considered lost")
+	reconnectTimeout   = flag.Duration("reconnect_timeout", 60*time.Second, "how long to keep trying to reconnect to the opponent after losing the connection, or 0 to not try at all")
+	spectateAddr       = flag.String("spectate", "", "if set, address of a player to watch the match of instead of playing")
+	spectateDelay      = flag.Duration("spectate_delay", 1*time.Second, "how far behind the players to watch, so that playback is smooth")
+	spectateListenAddr = flag.String("spectate_listen_addr", "", "if set, address to listen on for spectators to connect to")
+	local              = flag.Bool("local", false, "if true, plays a match with both players on this machine instead of connecting")
+	localOffererInput  = flag.String("local_offerer_input", "keyboard_left", "in a local match, input for the player on the left: one of "+strings.Join(input.ProfileNames, ", "))
+	localAnswererInput = flag.String("local_answerer_input", "keyboard_right", "in a local match, input for the player on the right: one of "+strings.Join(input.ProfileNames, ", "))
+	agentName          = flag.String("agent", "", "if set, plays against a computer-controlled opponent instead of connecting: one of "+strings.Join(agent.Names, ", "))
+	chipCatalog        = flag.String("chip_catalog", "", "if set, path to a chip catalog to use in place of the built-in one. Both players must use the same one")
+	allowRulesMismatch = flag.Bool("allow_rules_mismatch", false, "if true, only warns instead of refusing to play when the opponent's simulation rules differ")
 )
 
-func main() {
-	moreflag.Parse()
-	ctx := context.Background()
+func parseFolder(s string) (state.Folder, error) {
+	if s == "" {
+		return chips.DefaultFolder, nil
+	}
+	return state.ParseFolder(s)
+}
 
-	b, err := bundle.Load(ctx, loaderCallback)
+// connect returns a reliable, ordered transport for setting up the match, and
+// one for the match itself, which may be the same one.
+func connect(ctx context.Context, isAnswerer bool) (transport.Transport, transport.Transport, error) {
+	var conn transport.Transport
+	var err error
+	switch *transportType {
+	case "webrtc":
+		return connectWebRTC(ctx, isAnswerer)
+	case "tcp":
+		if isAnswerer {
+			log.Printf("waiting for tcp connection on %s", *lanAddr)
+			conn, err = transport.AcceptTCP(ctx, *lanAddr)
+		} else {
+			log.Printf("connecting over tcp to %s", *lanAddr)
+			conn, err = transport.DialTCP(ctx, *lanAddr)
+		}
+	case "udp":
+		return connectUDP(ctx, isAnswerer)
+	default:
+		return nil, nil, fmt.Errorf("unknown transport %q", *transportType)
+	}
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			log.Fatalf("you are missing assets from BN6. please see README.md in the assets directory for instructions on how to dump assets from a ROM of BN6.")
+		return nil, nil, err
+	}
+	return conn, conn, nil
+}
+
+// connectUDP sets the match up over TCP, as setting up doesn't survive lost or
+// reordered packets.
+func connectUDP(ctx context.Context, isAnswerer bool) (transport.Transport, transport.Transport, error) {
+	var setupConn *transport.Stream
+	var matchConn *transport.UDP
+	var err error
+	if isAnswerer {
+		log.Printf("waiting for tcp and udp connections on %s", *lanAddr)
+		if setupConn, err = transport.AcceptTCP(ctx, *lanAddr); err != nil {
+			return nil, nil, err
 		}
-		log.Fatalf("failed to load bundle: %s", err)
+		matchConn, err = transport.AcceptUDP(ctx, *lanAddr)
+	} else {
+		log.Printf("connecting over tcp and udp to %s", *lanAddr)
+		if setupConn, err = transport.DialTCP(ctx, *lanAddr); err != nil {
+			return nil, nil, err
+		}
+		matchConn, err = transport.DialUDP(ctx, *lanAddr)
+	}
+	if err != nil {
+		setupConn.Close()
+		return nil, nil, err
 	}
+	return setupConn, setupTransport{matchConn, setupConn}, nil
+}
+
+// setupTransport also closes the transport the match was set up over.
+type setupTransport struct {
+	transport.Transport
+	setupConn transport.Transport
+}
 
+func (t setupTransport) Close() error {
+	err := t.Transport.Close()
+	if err := t.setupConn.Close(); err != nil {
+		return err
+	}
+	return err
+}
+
+func connectWebRTC(ctx context.Context, isAnswerer bool) (transport.Transport, transport.Transport, error) {
 	var peerConnConfig webrtc.Configuration
 	if err := json.Unmarshal([]byte(*webrtcConfig), &peerConnConfig); err != nil {
-		log.Fatalf("failed to parse webrtc config: %s", err)
+		return nil, nil, fmt.Errorf("failed to parse webrtc config: %w", err)
 	}
 
-	log.Printf("connecting to %s, answer = %t, session_id = %s (using peer config: %+v)", *connectAddr, *answer, *sessionID, peerConnConfig)
-
-	signorClient := signorclient.New(*connectAddr)
+	log.Printf("connecting with %s signaling, answer = %t (using peer config: %+v)", *signalingMode, isAnswerer, peerConnConfig)
 
 	api, err := webRTCAPI()
 	if err != nil {
-		log.Fatalf("failed to get WebRTC API: %s", err)
+		return nil, nil, fmt.Errorf("failed to get WebRTC API: %w", err)
 	}
 
 	peerConn, err := api.NewPeerConnection(peerConnConfig)
 	if err != nil {
-		log.Fatalf("failed to create RTC peer connection: %s", err)
+		return nil, nil, fmt.Errorf("failed to create RTC peer connection: %w", err)
 	}
 
 	rtcDc, err := peerConn.CreateDataChannel("game", &webrtc.DataChannelInit{
@@ -92,34 +195,324 @@ func main() {
 		Ordered:    clone.P(true),
 	})
 	if err != nil {
-		log.Fatalf("failed to create RTC peer connection: %s", err)
+		return nil, nil, fmt.Errorf("failed to create RTC data channel: %w", err)
 	}
 
-	dc := ctxwebrtc.WrapDataChannel(rtcDc)
-
-	isAnswerer := *answer
-	if !isAnswerer {
-		if err := signorClient.Offer(ctx, []byte(*sessionID), peerConn); err != nil {
-			log.Fatalf("failed to offer: %s", err)
-		}
-	} else {
-		if err := signorClient.Answer(ctx, []byte(*sessionID), peerConn); err != nil {
-			log.Fatalf("failed to answer: %s", err)
+	matchRTCDc := rtcDc
+	if *unreliableIntents {
+		matchRTCDc, err = peerConn.CreateDataChannel("match", &webrtc.DataChannelInit{
+			ID:             clone.P(uint16(2)),
+			Negotiated:     clone.P(true),
+			Ordered:        clone.P(false),
+			MaxRetransmits: clone.P(uint16(0)),
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create RTC data channel: %w", err)
 		}
 	}
 
+	if err := signal(ctx, isAnswerer, peerConn); err != nil {
+		return nil, nil, err
+	}
+
 	log.Printf("signaling complete!")
 	log.Printf("local SDP: %s", peerConn.LocalDescription().SDP)
 	log.Printf("remote SDP: %s", peerConn.RemoteDescription().SDP)
 
-	randSource, seed, err := netsyncrand.Negotiate(ctx, dc)
+	conn := datachannel.Wrap(rtcDc)
+	matchConn := conn
+	if matchRTCDc != rtcDc {
+		matchConn = datachannel.Wrap(matchRTCDc)
+	}
+	return conn, peerConnTransport{matchConn, peerConn}, nil
+}
+
+func signal(ctx context.Context, isAnswerer bool, peerConn *webrtc.PeerConnection) error {
+	switch *signalingMode {
+	case "signor":
+		log.Printf("signaling via %s, session_id = %s", *connectAddr, *sessionID)
+		signorClient := signorclient.New(*connectAddr)
+		if !isAnswerer {
+			if err := signorClient.Offer(ctx, []byte(*sessionID), peerConn); err != nil {
+				return fmt.Errorf("failed to offer: %w", err)
+			}
+		} else {
+			if err := signorClient.Answer(ctx, []byte(*sessionID), peerConn); err != nil {
+				return fmt.Errorf("failed to answer: %w", err)
+			}
+		}
+	case "manual":
+		if !isAnswerer {
+			if err := manualOffer(ctx, peerConn); err != nil {
+				return fmt.Errorf("failed to offer: %w", err)
+			}
+		} else {
+			if err := manualAnswer(ctx, peerConn); err != nil {
+				return fmt.Errorf("failed to answer: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown signaling %q", *signalingMode)
+	}
+	return nil
+}
+
+// peerConnTransport also closes the whole peer connection.
+type peerConnTransport struct {
+	transport.Transport
+	peerConn *webrtc.PeerConnection
+}
+
+func (t peerConnTransport) Close() error {
+	err := t.Transport.Close()
+	if err := t.peerConn.Close(); err != nil {
+		return err
+	}
+	return err
+}
+
+func simulateConditions(conn transport.Transport) transport.Transport {
+	conditions := transport.Conditions{
+		Latency:       *simLatency,
+		Jitter:        *simJitter,
+		LossRate:      *simLossRate,
+		DuplicateRate: *simDuplicateRate,
+		ReorderRate:   *simReorderRate,
+		Seed:          time.Now().UnixNano(),
+	}
+	if conditions.IsZero() {
+		return conn
+	}
+	log.Printf("simulating network conditions: %+v", conditions)
+	return transport.Simulate(conn, conditions)
+}
+
+const reconnectRetryInterval = 1 * time.Second
+
+func makeReconnectFunc(isAnswerer bool, hello packets.Hello) game.ReconnectFunc {
+	if *reconnectTimeout <= 0 {
+		return nil
+	}
+	return func(ctx context.Context) (transport.Transport, error) {
+		ctx, cancel := context.WithTimeout(ctx, *reconnectTimeout)
+		defer cancel()
+
+		for {
+			conn, matchConn, err := connect(ctx, isAnswerer)
+			if err == nil {
+				// In case someone else took the opponent's place.
+				if _, err = handshake.Exchange(ctx, conn, hello, *allowRulesMismatch); err == nil {
+					return simulateConditions(matchConn), nil
+				}
+				matchConn.Close()
+				conn.Close()
+			}
+			log.Printf("failed to reconnect: %s", err)
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(reconnectRetryInterval):
+			}
+		}
+	}
+}
+
+func createReplay() (io.Writer, func()) {
+	if *recordReplay == "" {
+		return nil, func() {}
+	}
+	f, err := os.Create(*recordReplay)
+	if err != nil {
+		log.Fatalf("failed to create replay: %s", err)
+	}
+	return f, func() { f.Close() }
+}
+
+func serveSpectators(g *game.Game) {
+	if *spectateListenAddr == "" {
+		return
+	}
+	lis, err := net.Listen("tcp", *spectateListenAddr)
+	if err != nil {
+		log.Fatalf("failed to listen for spectators: %s", err)
+	}
+	log.Printf("listening for spectators on %s", lis.Addr())
+	go func() {
+		if err := g.ServeSpectators(lis); err != nil {
+			log.Printf("stopped accepting spectators: %s", err)
+		}
+	}()
+}
+
+func runLocal(b *bundle.Bundle, folder state.Folder, inputConfig *input.Config) {
+	seed := make([]byte, 32)
+	if _, err := rand.Read(seed); err != nil {
+		log.Fatalf("failed to generate seed: %s", err)
+	}
+	log.Printf("starting local match, seed: %s", hex.EncodeToString(seed))
+
+	replayW, closeReplay := createReplay()
+	defer closeReplay()
+
+	var g *game.Game
+	var err error
+	if *agentName != "" {
+		var a agent.Agent
+		a, err = agent.New(*agentName, int64(binary.LittleEndian.Uint64(seed)))
+		if err != nil {
+			log.Fatalf("failed to create agent: %s", err)
+		}
+		g, err = game.NewVersusAgent(b, seed, *bestOf, folder, folder, inputConfig, a, replayW)
+	} else {
+		g, err = game.NewLocal(b, seed, *bestOf, folder, folder, *localOffererInput, *localAnswererInput, inputConfig, replayW)
+	}
+	if err != nil {
+		log.Fatalf("failed to create game: %s", err)
+	}
+	serveSpectators(g)
+	if err := ebiten.RunGame(g); err != nil {
+		log.Fatalf("failed to run game: %s", err)
+	}
+}
+
+func main() {
+	moreflag.Parse()
+	if *chipCatalog != "" {
+		catalog, err := chips.LoadCatalog(*chipCatalog)
+		if err != nil {
+			log.Fatalf("failed to load chip catalog: %s", err)
+		}
+		if err := catalog.Install(); err != nil {
+			log.Fatalf("failed to install chip catalog: %s", err)
+		}
+	}
+	ctx := context.Background()
+
+	b, err := bundle.Load(ctx, loaderCallback)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("you are missing assets from BN6. please see README.md in the assets directory for instructions on how to dump assets from a ROM of BN6.")
+		}
+		log.Fatalf("failed to load bundle: %s", err)
+	}
+
+	if *replayPath != "" {
+		f, err := os.Open(*replayPath)
+		if err != nil {
+			log.Fatalf("failed to open replay: %s", err)
+		}
+		rp, err := replay.Read(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("failed to read replay: %s", err)
+		}
+		log.Printf("playing replay %s: %d ticks, seed: %s", *replayPath, len(rp.Intents), hex.EncodeToString(rp.Seed))
+		if err := ebiten.RunGame(game.NewReplay(b, rp)); err != nil {
+			log.Fatalf("failed to run game: %s", err)
+		}
+		return
+	}
+
+	if *spectateAddr != "" {
+		log.Printf("connecting to %s to spectate", *spectateAddr)
+		conn, err := transport.DialTCP(ctx, *spectateAddr)
+		if err != nil {
+			log.Fatalf("failed to connect: %s", err)
+		}
+		w, err := spectate.Watch(ctx, conn, b.Data, int(*spectateDelay*time.Duration(ebiten.MaxTPS())/time.Second))
+		if err != nil {
+			log.Fatalf("failed to start spectating: %s", err)
+		}
+		log.Printf("spectating from tick %d", w.Tick())
+		if err := ebiten.RunGame(game.NewSpectator(b, w)); err != nil {
+			log.Fatalf("failed to run game: %s", err)
+		}
+		return
+	}
+
+	predictor, err := rollback.NewPredictor(*predictorName)
+	if err != nil {
+		log.Fatalf("failed to create predictor: %s", err)
+	}
+
+	folder, err := parseFolder(*folderFlag)
+	if err != nil {
+		log.Fatalf("failed to parse folder: %s", err)
+	}
+	if err := folder.Validate(state.DefaultFolderRules); err != nil {
+		log.Fatalf("bad folder: %s", err)
+	}
+
+	inputConfig, err := input.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load input config: %s", err)
+	}
+
+	if *bestOf < 1 {
+		log.Fatalf("-best_of must be at least 1, got %d", *bestOf)
+	}
+
+	if *local || *agentName != "" {
+		runLocal(b, folder, inputConfig)
+		return
+	}
+
+	hello, err := handshake.MakeHello(folder, *bestOf)
+	if err != nil {
+		log.Fatalf("failed to make hello: %s", err)
+	}
+
+	isAnswerer, stopAnnouncing, err := setUpDiscovery(ctx, *answer)
+	if err != nil {
+		log.Fatalf("failed to set up discovery: %s", err)
+	}
+
+	conn, matchConn, err := connect(ctx, isAnswerer)
+	stopAnnouncing()
+	if err != nil {
+		log.Fatalf("failed to connect: %s", err)
+	}
+
+	theirHello, err := handshake.Exchange(ctx, conn, hello, *allowRulesMismatch)
+	if err != nil {
+		log.Fatalf("handshake failed: %s", err)
+	}
+	theirFolder, err := handshake.HelloFolder(theirHello)
+	if err != nil {
+		log.Fatalf("handshake failed: %s", err)
+	}
+	log.Printf("handshake complete, opponent brought a folder of %d MB", theirFolder.MB())
+	if *signalingMode == "manual" {
+		manualSignalingDone()
+	}
+
+	offererFolder, answererFolder := folder, theirFolder
+	matchBestOf := int(hello.BestOf)
+	if isAnswerer {
+		offererFolder, answererFolder = theirFolder, folder
+		matchBestOf = int(theirHello.BestOf)
+	}
+	log.Printf("playing best of %d", matchBestOf)
+
+	_, seed, err := netsyncrand.Negotiate(ctx, conn)
 	if err != nil {
 		log.Fatalf("failed to negotiate randSource: %s", err)
 	}
 
 	log.Printf("negotiated rng, seed: %s", hex.EncodeToString(seed))
 
-	g := game.New(b, dc, randSource, isAnswerer, *delaysWindowSize, *inputFrameDelay)
+	// Setting up the match relies on a reliable transport.
+	matchConn = simulateConditions(matchConn)
+
+	replayW, closeReplay := createReplay()
+	defer closeReplay()
+
+	g, err := game.New(b, matchConn, seed, isAnswerer, matchBestOf, offererFolder, answererFolder, *delaysWindowSize, *inputFrameDelay, predictor, replayW, inputConfig, *disconnectTimeout, makeReconnectFunc(isAnswerer, hello))
+	if err != nil {
+		log.Fatalf("failed to create game: %s", err)
+	}
+	serveSpectators(g)
 	go func() {
 		if err := g.RunBackgroundTasks(ctx); err != nil {
 			log.Fatalf("error running background tasks: %s", err)
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"syscall/js"
 
 	"github.com/pion/webrtc/v3"
@@ -17,3 +18,30 @@ func loaderCallback(path string, i int, n int) {
 	cb := global.Get("loaderCallback")
 	cb.Invoke(path, i, n)
 }
+
+func showSDP(ctx context.Context, label string, sdp string) error {
+	js.Global().Call("showSDP", label, sdp)
+	return nil
+}
+
+func readSDP(ctx context.Context, prompt string) (string, error) {
+	ch := make(chan string, 1)
+	cb := js.FuncOf(func(this js.Value, args []js.Value) any {
+		ch <- args[0].String()
+		return nil
+	})
+	defer cb.Release()
+	js.Global().Call("readSDP", prompt, cb)
+
+	select {
+	case <-ctx.Done():
+		js.Global().Call("hideSDP")
+		return "", ctx.Err()
+	case s := <-ch:
+		return s, nil
+	}
+}
+
+func manualSignalingDone() {
+	js.Global().Call("hideSDP")
+}
@@ -3,10 +3,16 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"net"
+	"os"
+	"sync"
 
+	"github.com/murkland/nbarena/input"
 	"github.com/pion/webrtc/v3"
 )
 
@@ -14,6 +20,10 @@ var (
 	webRTCListenAddr = flag.String("webrtc_listen_addr", "", "address to listen on for WebRTC")
 )
 
+func init() {
+	flag.StringVar(&input.ConfigPath, "input_config", input.ConfigPath, "path to load and save key and button bindings from, or empty to not save them")
+}
+
 func webRTCAPI() (*webrtc.API, error) {
 	var opts []func(*webrtc.API)
 
@@ -35,3 +45,50 @@ func webRTCAPI() (*webrtc.API, error) {
 func loaderCallback(path string, i int, n int) {
 	log.Printf("loaded %d/%d: %s", i, n, path)
 }
+
+type stdinLine struct {
+	line string
+	err  error
+}
+
+var (
+	stdinLines     = make(chan stdinLine)
+	stdinLinesOnce sync.Once
+)
+
+// readStdinLine reads in the background, as reads from stdin can't be
+// interrupted. A cancelled read's line goes to the next reader instead.
+func readStdinLine(ctx context.Context) (string, error) {
+	stdinLinesOnce.Do(func() {
+		go func() {
+			r := bufio.NewReader(os.Stdin)
+			for {
+				line, err := r.ReadString('\n')
+				stdinLines <- stdinLine{line, err}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	})
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case l := <-stdinLines:
+		return l.line, l.err
+	}
+}
+
+func showSDP(ctx context.Context, label string, sdp string) error {
+	fmt.Printf("%s:\n\n%s\n\n", label, sdp)
+	return nil
+}
+
+func readSDP(ctx context.Context, prompt string) (string, error) {
+	fmt.Printf("%s: ", prompt)
+	return readStdinLine(ctx)
+}
+
+func manualSignalingDone() {
+}
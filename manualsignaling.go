@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/murkland/nbarena/signaling"
+	"github.com/pion/webrtc/v3"
+)
+
+// waitForGathering is needed as there is no way to trickle ICE candidates when
+// signaling by hand.
+func waitForGathering(ctx context.Context, peerConn *webrtc.PeerConnection) error {
+	gatherComplete := webrtc.GatheringCompletePromise(peerConn)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-gatherComplete:
+		return nil
+	}
+}
+
+func readRemoteDescription(ctx context.Context, prompt string) (string, error) {
+	for {
+		s, err := readSDP(ctx, prompt)
+		if err != nil {
+			return "", err
+		}
+		sdp, err := signaling.DecodeSDP(s)
+		if err == nil {
+			return sdp, nil
+		}
+		prompt = fmt.Sprintf("%s, please try again", err)
+	}
+}
+
+func manualOffer(ctx context.Context, peerConn *webrtc.PeerConnection) error {
+	offer, err := peerConn.CreateOffer(nil)
+	if err != nil {
+		return err
+	}
+	if err := peerConn.SetLocalDescription(offer); err != nil {
+		return err
+	}
+	if err := waitForGathering(ctx, peerConn); err != nil {
+		return err
+	}
+
+	if err := showSDP(ctx, "send this offer to your opponent", signaling.EncodeSDP(peerConn.LocalDescription().SDP)); err != nil {
+		return err
+	}
+
+	answerSDP, err := readRemoteDescription(ctx, "paste your opponent's answer")
+	if err != nil {
+		return err
+	}
+	return peerConn.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: answerSDP})
+}
+
+func manualAnswer(ctx context.Context, peerConn *webrtc.PeerConnection) error {
+	offerSDP, err := readRemoteDescription(ctx, "paste your opponent's offer")
+	if err != nil {
+		return err
+	}
+	if err := peerConn.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: offerSDP}); err != nil {
+		return err
+	}
+
+	answer, err := peerConn.CreateAnswer(nil)
+	if err != nil {
+		return err
+	}
+	if err := peerConn.SetLocalDescription(answer); err != nil {
+		return err
+	}
+	if err := waitForGathering(ctx, peerConn); err != nil {
+		return err
+	}
+
+	return showSDP(ctx, "send this answer to your opponent", signaling.EncodeSDP(peerConn.LocalDescription().SDP))
+}
@@ -0,0 +1,114 @@
+package match
+
+import (
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/state"
+)
+
+// NewState puts the offerer on the left and the answerer on the right.
+func NewState(randSeed []byte, bestOf int, offererFolder state.Folder, answererFolder state.Folder) (*state.State, state.EntityID, state.EntityID) {
+	s := state.New(randSeed)
+	var offererEntityID state.EntityID
+	{
+		e := &state.Entity{
+			HP:        1000,
+			MaxHP:     1000,
+			DisplayHP: 1000,
+
+			Custom: state.CustomState{
+				Folder: append(state.Folder(nil), offererFolder...),
+			},
+
+			PowerShotChargeTime: state.Ticks(50),
+
+			TilePos:       state.TilePosXY(2, 2),
+			FutureTilePos: state.TilePosXY(2, 2),
+
+			BehaviorState: state.EntityBehaviorState{
+				Behavior: &behaviors.Idle{},
+			},
+
+			Traits: state.EntityTraits{
+				ExtendsTileOwnership: true,
+			},
+		}
+		s.AttachEntity(e)
+		offererEntityID = e.ID()
+	}
+
+	var answererEntityID state.EntityID
+	{
+		e := &state.Entity{
+			HP:        1000,
+			MaxHP:     1000,
+			DisplayHP: 1000,
+
+			Custom: state.CustomState{
+				Folder: append(state.Folder(nil), answererFolder...),
+			},
+
+			PowerShotChargeTime: state.Ticks(50),
+
+			IsFlipped:            true,
+			IsAlliedWithAnswerer: true,
+
+			TilePos:       state.TilePosXY(5, 2),
+			FutureTilePos: state.TilePosXY(5, 2),
+
+			BehaviorState: state.EntityBehaviorState{
+				Behavior: &behaviors.Idle{},
+			},
+
+			Traits: state.EntityTraits{
+				ExtendsTileOwnership: true,
+			},
+		}
+		s.AttachEntity(e)
+		answererEntityID = e.ID()
+	}
+
+	s.StartMatch(bestOf, offererEntityID, answererEntityID)
+
+	return s, offererEntityID, answererEntityID
+}
+
+type Side int
+
+const (
+	SideNone     Side = 0
+	SideOfferer  Side = 1
+	SideAnswerer Side = 2
+)
+
+// Winner returns SideNone for a draw.
+func Winner(s *state.State) (Side, bool) {
+	m := s.Match
+	if m.Phase != state.MatchPhaseOver {
+		return SideNone, false
+	}
+	switch {
+	case m.OffererWins > m.AnswererWins:
+		return SideOfferer, true
+	case m.AnswererWins > m.OffererWins:
+		return SideAnswerer, true
+	}
+	return SideNone, true
+}
+
+func RoundWinner(s *state.State) (Side, bool) {
+	m := s.Match
+	switch {
+	case m.OffererKO && m.AnswererKO:
+		return SideNone, true
+	case m.AnswererKO:
+		return SideOfferer, true
+	case m.OffererKO:
+		return SideAnswerer, true
+	}
+	return SideNone, false
+}
+
+func RematchAgreed(s *state.State) bool {
+	m := s.Match
+	return m.Phase == state.MatchPhaseOver && m.OffererWantsRematch && m.AnswererWantsRematch
+}
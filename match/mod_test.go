@@ -0,0 +1,269 @@
+package match
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+)
+
+var testData = gamedata.NewData()
+
+// stepUntil confirms any custom screen on the way without picking anything.
+func stepUntil(t *testing.T, s *state.State, phase state.MatchPhase) {
+	t.Helper()
+	for i := 0; s.Match.Phase != phase; i++ {
+		if i >= 1000 {
+			t.Fatalf("match stuck in phase %d, expected %d", s.Match.Phase, phase)
+		}
+		for _, id := range []state.EntityID{s.Match.OffererEntityID, s.Match.AnswererEntityID} {
+			e := s.Entities[id]
+			e.Intent = state.Intent{Confirm: s.Match.Phase == state.MatchPhaseCustom && !e.LastIntent.Confirm}
+		}
+		step.Step(s, testData)
+	}
+}
+
+func press(s *state.State, id state.EntityID, intent state.Intent) {
+	s.Entities[id].Intent = intent
+	step.Step(s, testData)
+	s.Entities[id].Intent = state.Intent{}
+	step.Step(s, testData)
+}
+
+// knockOut steps s through a round where the given fighters run out of HP.
+func knockOut(t *testing.T, s *state.State, ids ...state.EntityID) {
+	t.Helper()
+	stepUntil(t, s, state.MatchPhaseBattle)
+	for _, id := range ids {
+		s.Entities[id].HP = 0
+	}
+	step.Step(s, testData)
+	if s.Match.Phase != state.MatchPhaseKO {
+		t.Fatalf("expected a KO, got phase %d", s.Match.Phase)
+	}
+	for _, id := range ids {
+		e := s.Entities[id]
+		if !e.IsDead || !state.BehaviorIs[*behaviors.Deleted](e.BehaviorState.Behavior) {
+			t.Errorf("expected entity %d to be deleted", id)
+		}
+	}
+	stepUntil(t, s, state.MatchPhaseResult)
+}
+
+func TestMatch(t *testing.T) {
+	s, offererID, answererID := NewState([]byte("match"), 3, nil, nil)
+
+	// Round 1: the answerer goes down.
+	knockOut(t, s, answererID)
+	if winner, ok := RoundWinner(s); !ok || winner != SideOfferer {
+		t.Errorf("expected the offerer to win round 1, got %d (%t)", winner, ok)
+	}
+
+	// The next round starts over at full HP with the same fighters.
+	stepUntil(t, s, state.MatchPhaseIntro)
+	if s.Match.Round != 2 {
+		t.Errorf("expected round 2, got %d", s.Match.Round)
+	}
+	if len(s.Entities) != 2 {
+		t.Errorf("expected only the fighters to be left, got %d entities", len(s.Entities))
+	}
+	for _, id := range []state.EntityID{offererID, answererID} {
+		if e := s.Entities[id]; e == nil || e.HP != e.MaxHP || e.IsDead {
+			t.Errorf("expected entity %d to be back at full HP, got %+v", id, e)
+		}
+	}
+
+	// Round 2: both go down together, and nobody wins.
+	knockOut(t, s, offererID, answererID)
+	if winner, ok := RoundWinner(s); !ok || winner != SideNone {
+		t.Errorf("expected round 2 to be a draw, got %d (%t)", winner, ok)
+	}
+	if s.Match.OffererWins != 1 || s.Match.AnswererWins != 0 {
+		t.Errorf("expected a score of 1 - 0, got %d - %d", s.Match.OffererWins, s.Match.AnswererWins)
+	}
+
+	// Round 3 is the last one.
+	stepUntil(t, s, state.MatchPhaseIntro)
+	knockOut(t, s, answererID)
+	if _, ok := Winner(s); ok {
+		t.Errorf("match over before the result was shown")
+	}
+	stepUntil(t, s, state.MatchPhaseOver)
+	if winner, ok := Winner(s); !ok || winner != SideOfferer {
+		t.Errorf("expected the offerer to win the match, got %d (%t)", winner, ok)
+	}
+
+	// Both sides must confirm for a rematch.
+	s.Entities[offererID].Intent.Confirm = true
+	step.Step(s, testData)
+	if RematchAgreed(s) {
+		t.Errorf("rematch agreed to by only one side")
+	}
+	s.Entities[answererID].Intent.Confirm = true
+	step.Step(s, testData)
+	if !RematchAgreed(s) {
+		t.Errorf("expected a rematch to be agreed to")
+	}
+}
+
+func TestMatchDraw(t *testing.T) {
+	s, offererID, answererID := NewState([]byte("draw"), 1, nil, nil)
+	knockOut(t, s, offererID, answererID)
+	stepUntil(t, s, state.MatchPhaseOver)
+	if winner, ok := Winner(s); !ok || winner != SideNone {
+		t.Errorf("expected the match to be a draw, got %d (%t)", winner, ok)
+	}
+}
+
+func anyCode(chips ...*state.Chip) state.Folder {
+	f := make(state.Folder, len(chips))
+	for i, chip := range chips {
+		f[i] = state.FolderChip{Chip: chip, Code: state.ChipCodeAny}
+	}
+	return f
+}
+
+func TestCustomScreen(t *testing.T) {
+	folder := anyCode(chips.Cannon, chips.AirShot, chips.Sword, chips.Vulcan1, chips.Recov10, chips.WideSwrd, chips.Recov200)
+	s, offererID, answererID := NewState([]byte("custom"), 1, folder, nil)
+
+	// Every round starts on the custom screen.
+	for s.Match.Phase == state.MatchPhaseIntro {
+		step.Step(s, testData)
+	}
+	if s.Match.Phase != state.MatchPhaseCustom {
+		t.Fatalf("expected the custom screen after the intro, got phase %d", s.Match.Phase)
+	}
+	offerer := s.Entities[offererID]
+	if len(offerer.Custom.Hand) != state.HandSize || len(offerer.Custom.Folder) != len(folder)-state.HandSize {
+		t.Fatalf("expected a full hand, got %d in hand and %d in folder", len(offerer.Custom.Hand), len(offerer.Custom.Folder))
+	}
+	hand := slices.Clone(offerer.Custom.Hand)
+	rest := slices.Clone(offerer.Custom.Folder)
+
+	// Pick the first and third chips, putting back the second in between.
+	press(s, offererID, state.Intent{UseChip: true})
+	press(s, offererID, state.Intent{Direction: state.DirectionRight})
+	press(s, offererID, state.Intent{UseChip: true})
+	press(s, offererID, state.Intent{ChargeBasicWeapon: true})
+	press(s, offererID, state.Intent{Direction: state.DirectionRight})
+	press(s, offererID, state.Intent{UseChip: true})
+	press(s, offererID, state.Intent{Confirm: true})
+	if s.Match.Phase != state.MatchPhaseCustom {
+		t.Fatalf("custom screen closed before both sides confirmed")
+	}
+	press(s, answererID, state.Intent{Confirm: true})
+	if s.Match.Phase != state.MatchPhaseBattle {
+		t.Fatalf("expected battle once both sides confirmed, got phase %d", s.Match.Phase)
+	}
+
+	if want := []*state.Chip{hand[2].Chip, hand[0].Chip}; !slices.Equal(offerer.Chips, want) {
+		t.Errorf("expected chips %v, got %v", want, offerer.Chips)
+	}
+	if want := []state.FolderChip{hand[1], hand[3], hand[4]}; !slices.Equal(offerer.Custom.Hand, want) {
+		t.Errorf("expected the rest to stay in hand, got %v", offerer.Custom.Hand)
+	}
+
+	// The turn can't be ended until the custom gauge has filled.
+	press(s, answererID, state.Intent{EndTurn: true})
+	if s.Match.Phase != state.MatchPhaseBattle {
+		t.Fatalf("custom screen opened before the gauge was full")
+	}
+	for !s.Match.IsCustomGaugeFull() {
+		step.Step(s, testData)
+	}
+	press(s, answererID, state.Intent{EndTurn: true})
+	if s.Match.Phase != state.MatchPhaseCustom {
+		t.Fatalf("expected the custom screen once the turn was ended, got phase %d", s.Match.Phase)
+	}
+	if want := []state.FolderChip{hand[1], hand[3], hand[4], rest[0], rest[1]}; !slices.Equal(offerer.Custom.Hand, want) {
+		t.Errorf("expected the hand to be filled back up, got %v", offerer.Custom.Hand)
+	}
+	if len(offerer.Chips) != 0 {
+		t.Errorf("expected unused chips to be lost, got %v", offerer.Chips)
+	}
+}
+
+func TestFolderShuffle(t *testing.T) {
+	hands := func(seed string) [][]state.FolderChip {
+		s, offererID, answererID := NewState([]byte(seed), 1, chips.DefaultFolder, chips.DefaultFolder)
+		stepUntil(t, s, state.MatchPhaseCustom)
+		return [][]state.FolderChip{s.Entities[offererID].Custom.Hand, s.Entities[answererID].Custom.Hand}
+	}
+
+	a := hands("shuffle")
+	if b := hands("shuffle"); !slices.Equal(a[0], b[0]) || !slices.Equal(a[1], b[1]) {
+		t.Errorf("expected the same seed to draw the same hands, got %v and %v", a, b)
+	}
+	if slices.Equal(a[0], chips.DefaultFolder[:state.HandSize]) && slices.Equal(a[1], chips.DefaultFolder[:state.HandSize]) {
+		t.Errorf("expected folders to be shuffled, got %v", a)
+	}
+}
+
+func TestSelectionRules(t *testing.T) {
+	cs := state.CustomState{Hand: []state.FolderChip{
+		{Chip: chips.Cannon, Code: 'A'},
+		{Chip: chips.Sword, Code: 'S'},
+		{Chip: chips.Cannon, Code: 'B'},
+		{Chip: chips.AirShot, Code: state.ChipCodeAny},
+		{Chip: chips.Recov10, Code: 'A'},
+	}}
+
+	cs.Selected = []int{0}
+	for i, want := range []bool{false, false, true, true, true} {
+		if got := cs.CanSelect(i); got != want {
+			t.Errorf("with Cannon A picked, expected CanSelect(%d) = %t, got %t", i, want, got)
+		}
+	}
+
+	// Once a second code is picked, only the same chip goes.
+	cs.Selected = []int{0, 2}
+	for i, want := range []bool{false, false, false, false, false} {
+		if got := cs.CanSelect(i); got != want {
+			t.Errorf("with Cannon A and Cannon B picked, expected CanSelect(%d) = %t, got %t", i, want, got)
+		}
+	}
+
+	// The wildcard goes with any code, and doesn't decide it.
+	cs.Selected = []int{3}
+	for i, want := range []bool{true, true, true, false, true} {
+		if got := cs.CanSelect(i); got != want {
+			t.Errorf("with AirShot * picked, expected CanSelect(%d) = %t, got %t", i, want, got)
+		}
+	}
+}
+
+func TestFolderValidate(t *testing.T) {
+	if err := chips.DefaultFolder.Validate(state.DefaultFolderRules); err != nil {
+		t.Errorf("expected the default folder to be legal, got %s", err)
+	}
+
+	for name, modify := range map[string]func(f state.Folder) state.Folder{
+		"too few chips": func(f state.Folder) state.Folder { return f[1:] },
+		"wrong code":    func(f state.Folder) state.Folder { f[0].Code = 'Z'; return f },
+		"too many copies": func(f state.Folder) state.Folder {
+			for i := range f[:5] {
+				f[i] = state.FolderChip{Chip: chips.Sword, Code: 'S'}
+			}
+			return f
+		},
+	} {
+		f := modify(slices.Clone(chips.DefaultFolder))
+		if err := f.Validate(state.DefaultFolderRules); !errors.Is(err, state.ErrIllegalFolder) {
+			t.Errorf("%s: expected ErrIllegalFolder, got %v", name, err)
+		}
+	}
+
+	rules := state.DefaultFolderRules
+	rules.MaxMB = chips.DefaultFolder.MB() - 1
+	if err := chips.DefaultFolder.Validate(rules); !errors.Is(err, state.ErrIllegalFolder) {
+		t.Errorf("expected a folder over the MB limit to be illegal, got %v", err)
+	}
+}
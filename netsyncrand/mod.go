@@ -6,12 +6,13 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/murkland/ctxwebrtc"
 	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/transport"
 	"github.com/murkland/syncrand"
 )
 
-func Negotiate(ctx context.Context, dc *ctxwebrtc.DataChannel) (*syncrand.Source, []byte, error) {
+// Negotiate needs a reliable, ordered transport.
+func Negotiate(ctx context.Context, t transport.Transport) (*syncrand.Source, []byte, error) {
 	var nonce [16]byte
 	if _, err := rand.Read(nonce[:]); err != nil {
 		return nil, nil, fmt.Errorf("failed to generate rng seed part: %w", err)
@@ -20,25 +21,33 @@ func Negotiate(ctx context.Context, dc *ctxwebrtc.DataChannel) (*syncrand.Source
 	commitment := syncrand.Commit(nonce[:])
 	var commitPacket packets.Commit
 	copy(commitPacket.Commitment[:], commitment)
-	if err := packets.Send(ctx, dc, commitPacket); err != nil {
+	if err := packets.Send(ctx, t, commitPacket); err != nil {
 		return nil, nil, fmt.Errorf("failed to send commit: %w", err)
 	}
 
-	theirCommitReply, err := packets.Recv(ctx, dc)
+	theirCommitReply, err := packets.Recv(ctx, t)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to receive commit: %w", err)
 	}
-	theirCommitment := theirCommitReply.(packets.Commit).Commitment
+	theirCommit, ok := theirCommitReply.(packets.Commit)
+	if !ok {
+		return nil, nil, fmt.Errorf("expected commit, got %T", theirCommitReply)
+	}
+	theirCommitment := theirCommit.Commitment
 
-	if err := packets.Send(ctx, dc, packets.Reveal{Nonce: nonce}); err != nil {
+	if err := packets.Send(ctx, t, packets.Reveal{Nonce: nonce}); err != nil {
 		return nil, nil, fmt.Errorf("failed to send reveal: %w", err)
 	}
 
-	theirRevealReply, err := packets.Recv(ctx, dc)
+	theirRevealReply, err := packets.Recv(ctx, t)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to receive reveal: %w", err)
 	}
-	theirNonce := theirRevealReply.(packets.Reveal).Nonce
+	theirReveal, ok := theirRevealReply.(packets.Reveal)
+	if !ok {
+		return nil, nil, fmt.Errorf("expected reveal, got %T", theirRevealReply)
+	}
+	theirNonce := theirReveal.Nonce
 
 	if !syncrand.Verify(commitment, theirCommitment[:], theirNonce[:]) {
 		return nil, nil, errors.New("failed to verify rng commitment")
@@ -0,0 +1,78 @@
+package netsyncrand
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/syncrand"
+)
+
+// Negotiation negotiates a seed like Negotiate, but without blocking on a
+// transport. Packets must be resent until Seed is done.
+type Negotiation struct {
+	match uint8
+
+	nonce      [16]byte
+	commitment []byte
+
+	theirCommitment []byte
+	theirNonce      []byte
+}
+
+func NewNegotiation(match uint8) (*Negotiation, error) {
+	n := &Negotiation{match: match}
+	if _, err := rand.Read(n.nonce[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate rng seed part: %w", err)
+	}
+	n.commitment = syncrand.Commit(n.nonce[:])
+	return n, nil
+}
+
+func (n *Negotiation) Match() uint8 {
+	return n.match
+}
+
+// Packets only reveals our nonce once we have the remote's commitment, so that
+// they can't pick theirs to suit.
+func (n *Negotiation) Packets() []packets.Packet {
+	commit := packets.Commit{Match: n.match}
+	copy(commit.Commitment[:], n.commitment)
+	if n.theirCommitment == nil {
+		return []packets.Packet{commit}
+	}
+	return []packets.Packet{commit, packets.Reveal{Match: n.match, Nonce: n.nonce}}
+}
+
+// Handle answers every Commit with our Reveal, even once Seed is done, as the
+// remote resends its Commit until it has our Reveal. A Reveal that arrives
+// before its Commit is dropped: it will be resent.
+func (n *Negotiation) Handle(p packets.Packet) ([]packets.Packet, error) {
+	switch p := p.(type) {
+	case packets.Commit:
+		if n.theirCommitment == nil {
+			n.theirCommitment = append([]byte(nil), p.Commitment[:]...)
+		} else if !bytes.Equal(n.theirCommitment, p.Commitment[:]) {
+			return nil, errors.New("remote changed its rng commitment")
+		}
+		return []packets.Packet{packets.Reveal{Match: n.match, Nonce: n.nonce}}, nil
+	case packets.Reveal:
+		if n.theirCommitment == nil || n.theirNonce != nil {
+			return nil, nil
+		}
+		if !syncrand.Verify(n.commitment, n.theirCommitment, p.Nonce[:]) {
+			return nil, errors.New("failed to verify rng commitment")
+		}
+		n.theirNonce = append([]byte(nil), p.Nonce[:]...)
+	}
+	return nil, nil
+}
+
+func (n *Negotiation) Seed() ([]byte, bool) {
+	if n.theirNonce == nil {
+		return nil, false
+	}
+	return syncrand.MakeSeed(n.nonce[:], n.theirNonce), true
+}
@@ -0,0 +1,67 @@
+package netsyncrand
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/murkland/nbarena/packets"
+)
+
+// exchange delivers packets from one side to the other, and the replies back.
+func exchange(t *testing.T, from *Negotiation, to *Negotiation, ps []packets.Packet) {
+	for _, p := range ps {
+		reply, err := to.Handle(p)
+		if err != nil {
+			t.Fatalf("Handle: %s", err)
+		}
+		exchange(t, to, from, reply)
+	}
+}
+
+func TestNegotiation(t *testing.T) {
+	a, err := NewNegotiation(1)
+	if err != nil {
+		t.Fatalf("NewNegotiation: %s", err)
+	}
+	b, err := NewNegotiation(1)
+	if err != nil {
+		t.Fatalf("NewNegotiation: %s", err)
+	}
+
+	if _, ok := a.Seed(); ok {
+		t.Fatalf("seed negotiated before anything was exchanged")
+	}
+
+	// A reveal that overtakes its commit is dropped.
+	exchange(t, a, b, []packets.Packet{packets.Reveal{Match: 1, Nonce: a.nonce}})
+	if _, ok := b.Seed(); ok {
+		t.Fatalf("reveal accepted before commit")
+	}
+
+	// Both sides send their packets, as they would periodically.
+	exchange(t, a, b, a.Packets())
+	exchange(t, b, a, b.Packets())
+
+	seedA, ok := a.Seed()
+	if !ok {
+		t.Fatalf("a did not finish")
+	}
+	seedB, ok := b.Seed()
+	if !ok {
+		t.Fatalf("b did not finish")
+	}
+	if !bytes.Equal(seedA, seedB) {
+		t.Errorf("seeds differ: %x != %x", seedA, seedB)
+	}
+}
+
+func TestNegotiationRejectsBadReveal(t *testing.T) {
+	a, _ := NewNegotiation(0)
+	b, _ := NewNegotiation(0)
+	exchange(t, b, a, b.Packets())
+
+	var nonce [16]byte
+	if _, err := a.Handle(packets.Reveal{Nonce: nonce}); err == nil {
+		t.Errorf("expected a reveal not matching the commitment to be rejected")
+	}
+}
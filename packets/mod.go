@@ -9,8 +9,8 @@ import (
 	"io"
 	"log"
 
-	"github.com/murkland/ctxwebrtc"
 	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/transport"
 )
 
 var (
@@ -22,13 +22,18 @@ var ErrUnknownPacket = errors.New("unknown packet")
 type packetType uint8
 
 const (
-	packetTypePing   packetType = 0
-	packetTypePong   packetType = 1
-	packetTypeCommit packetType = 2
-	packetTypeReveal packetType = 3
-	packetTypeIntent packetType = 4
+	packetTypePing      packetType = 0
+	packetTypePong      packetType = 1
+	packetTypeCommit    packetType = 2
+	packetTypeReveal    packetType = 3
+	packetTypeIntent    packetType = 4
+	packetTypeChecksums packetType = 5
+	packetTypeHello     packetType = 6
 )
 
+// ProtocolVersion must be bumped whenever the packet format changes.
+const ProtocolVersion = 5
+
 type Packet interface {
 	packetType() packetType
 }
@@ -45,25 +50,69 @@ type Pong struct {
 
 func (Pong) packetType() packetType { return packetTypePong }
 
+// Commit and Reveal negotiate the seed of a match. Match counts rematches.
 type Commit struct {
+	Match      uint8
 	Commitment [32]uint8
 }
 
 func (Commit) packetType() packetType { return packetTypeCommit }
 
 type Reveal struct {
+	Match uint8
 	Nonce [16]uint8
 }
 
 func (Reveal) packetType() packetType { return packetTypeReveal }
 
+const MaxIntentsPerPacket = 64
+
+// Intent carries every intent the peer hasn't acknowledged yet.
+// Ticks start over with every rematch, so Match tells apart packets still in
+// flight from the last one.
 type Intent struct {
-	ForTick uint32
-	Intent  state.Intent
+	Match uint8
+
+	// AckTick is the last tick the sender has every intent up to.
+	AckTick    uint32
+	StartTick  uint32
+	NumIntents uint8
+	Intents    [MaxIntentsPerPacket]state.Intent
 }
 
 func (Intent) packetType() packetType { return packetTypeIntent }
 
+const ChecksumsPerPacket = 30
+
+type Checksums struct {
+	Match uint8
+
+	// AckTick is the last tick the sender has every checksum up to.
+	AckTick      uint32
+	StartTick    uint32
+	NumChecksums uint8
+	Checksums    [ChecksumsPerPacket]uint64
+}
+
+func (Checksums) packetType() packetType { return packetTypeChecksums }
+
+const FolderSize = 30
+
+type FolderChip struct {
+	Chip uint16
+	Code uint8
+}
+
+type Hello struct {
+	ProtocolVersion uint32
+	RulesHash       [32]uint8
+	Folder          [FolderSize]FolderChip
+	// Only the offerer's BestOf is used.
+	BestOf uint8
+}
+
+func (Hello) packetType() packetType { return packetTypeHello }
+
 func Marshal(packet Packet) []byte {
 	var buf bytes.Buffer
 	if err := binary.Write(&buf, binary.LittleEndian, packet.packetType()); err != nil {
@@ -101,20 +150,24 @@ func Unmarshal(raw []byte) (Packet, error) {
 		return unmarshal[Reveal](r)
 	case packetTypeIntent:
 		return unmarshal[Intent](r)
+	case packetTypeChecksums:
+		return unmarshal[Checksums](r)
+	case packetTypeHello:
+		return unmarshal[Hello](r)
 	default:
 		return nil, ErrUnknownPacket
 	}
 }
 
-func Send(ctx context.Context, dc *ctxwebrtc.DataChannel, packet Packet) error {
+func Send(ctx context.Context, t transport.Transport, packet Packet) error {
 	if *debugLogPackets {
 		log.Printf("--> %#v", packet)
 	}
-	return dc.Send(ctx, Marshal(packet))
+	return t.Send(ctx, Marshal(packet))
 }
 
-func Recv(ctx context.Context, dc *ctxwebrtc.DataChannel) (Packet, error) {
-	raw, err := dc.Recv(ctx)
+func Recv(ctx context.Context, t transport.Transport) (Packet, error) {
+	raw, err := t.Recv(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,288 @@
+package render
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/pngsheet"
+)
+
+func entityBehaviorAppearance(e *state.Entity, b *bundle.Bundle) draw.Node {
+	switch eb := e.BehaviorState.Behavior.(type) {
+	case *behaviors.AirShot:
+		return airShotAppearance(eb, e, b)
+	case *behaviors.AreaGrabBall:
+		return areaGrabBallAppearance(eb, e, b)
+	case *behaviors.Bubbled:
+		return bubbledAppearance(eb, e, b)
+	case *behaviors.Buster:
+		return busterAppearance(eb, e, b)
+	case *behaviors.Cannon:
+		return cannonAppearance(eb, e, b)
+	case *behaviors.Deleted:
+		return deletedAppearance(eb, e, b)
+	case *behaviors.Flinch:
+		return flinchAppearance(eb, e, b)
+	case *behaviors.Frozen:
+		return frozenAppearance(eb, e, b)
+	case *behaviors.Gust:
+		return gustAppearance(eb, e, b)
+	case *behaviors.Idle:
+		return idleAppearance(eb, e, b)
+	case *behaviors.Paralyzed:
+		return paralyzedAppearance(eb, e, b)
+	case *behaviors.Sword:
+		return swordAppearance(eb, e, b)
+	case *behaviors.Teleport:
+		return teleportAppearance(eb, e, b)
+	case *behaviors.Vulcan:
+		return vulcanAppearance(eb, e, b)
+	case *behaviors.WindFan:
+		return windFanAppearance(eb, e, b)
+	case *behaviors.WindRack:
+		return windRackAppearance(eb, e, b)
+	}
+	return nil
+}
+
+func airShotAppearance(eb *behaviors.AirShot, e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.RecoilShotAnimation, int(e.BehaviorState.ElapsedTime)))
+
+	airShooterNode := &draw.OptionsNode{Layer: 6}
+	airShooterNode.Opts.GeoM.Translate(float64(16), float64(-24))
+	rootNode.Children = append(rootNode.Children, airShooterNode)
+	airShooterNode.Children = append(airShooterNode.Children, draw.ImageWithAnimation(b.AirShooterSprites.Image, b.AirShooterSprites.Animations[0], int(e.BehaviorState.ElapsedTime)))
+	return rootNode
+}
+
+func areaGrabBallAppearance(eb *behaviors.AreaGrabBall, e *state.Entity, b *bundle.Bundle) draw.Node {
+	ballNode := &draw.OptionsNode{Layer: 7}
+
+	if e.BehaviorState.ElapsedTime < 32 {
+		frames := b.AreaGrabSprites.Animations[0].Frames
+		ballNode.Opts.GeoM.Translate(0, float64(-9*(31-e.BehaviorState.ElapsedTime-1)))
+		ballNode.Children = append(ballNode.Children, draw.ImageWithFrame(b.AreaGrabSprites.Image, frames[int(e.BehaviorState.ElapsedTime)%len(frames)]))
+	} else {
+		frames := b.AreaGrabSprites.Animations[1].Frames
+		ballNode.Children = append(ballNode.Children, draw.ImageWithFrame(b.AreaGrabSprites.Image, frames[int(e.BehaviorState.ElapsedTime)-31]))
+	}
+
+	return ballNode
+}
+
+func bubbledAppearance(eb *behaviors.Bubbled, e *state.Entity, b *bundle.Bundle) draw.Node {
+	// TODO: Renber bubble.
+	return draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.StuckAnimation, int(e.BehaviorState.ElapsedTime))
+}
+
+func busterAppearance(eb *behaviors.Buster, e *state.Entity, b *bundle.Bundle) draw.Node {
+	realElapsedTime := eb.RealElapsedTime(e)
+
+	if realElapsedTime < 0 {
+		return draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.IdleAnimation, int(e.ElapsedTime))
+	}
+
+	rootNode := &draw.OptionsNode{}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.BusterAnimation, int(realElapsedTime)))
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.BusterSprites.Image, b.BusterSprites.BaseAnimation, int(realElapsedTime)))
+
+	if !eb.IsJammed {
+		muzzleFlashAnimTime := int(realElapsedTime) - 1
+		if muzzleFlashAnimTime > 0 && muzzleFlashAnimTime < len(b.MuzzleFlashSprites.Animations[0].Frames) {
+			muzzleFlashNode := &draw.OptionsNode{Layer: 7}
+			muzzleFlashNode.Opts.GeoM.Translate(float64(state.TileRenderedWidth), float64(-26))
+			muzzleFlashNode.Children = append(muzzleFlashNode.Children, draw.ImageWithAnimation(b.MuzzleFlashSprites.Image, b.MuzzleFlashSprites.Animations[0], muzzleFlashAnimTime))
+			rootNode.Children = append(rootNode.Children, muzzleFlashNode)
+		}
+	}
+
+	return rootNode
+}
+
+func cannonAppearance(eb *behaviors.Cannon, e *state.Entity, b *bundle.Bundle) draw.Node {
+	if e.BehaviorState.ElapsedTime >= 29 {
+		return draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.BraceAnimation.Frames[int(e.BehaviorState.ElapsedTime-29)])
+	}
+
+	rootNode := &draw.OptionsNode{}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.CannonAnimation.Frames[e.BehaviorState.ElapsedTime]))
+
+	cannonNode := &draw.OptionsNode{Layer: 6}
+	cannonNode.Opts.GeoM.Translate(float64(16), float64(-24))
+	rootNode.Children = append(rootNode.Children, cannonNode)
+	var img *ebiten.Image
+	switch eb.Style {
+	case behaviors.CannonStyleCannon:
+		img = b.CannonSprites.CannonImage
+	case behaviors.CannonStyleHiCannon:
+		img = b.CannonSprites.HiCannonImage
+	case behaviors.CannonStyleMCannon:
+		img = b.CannonSprites.MCannonImage
+	}
+	cannonNode.Children = append(cannonNode.Children, draw.ImageWithFrame(img, b.CannonSprites.Animation.Frames[e.BehaviorState.ElapsedTime]))
+	return rootNode
+}
+
+func deletedAppearance(eb *behaviors.Deleted, e *state.Entity, b *bundle.Bundle) draw.Node {
+	if e.BehaviorState.ElapsedTime >= behaviors.DeletedTicks {
+		return nil
+	}
+
+	rootNode := &draw.OptionsNode{}
+	if (e.BehaviorState.ElapsedTime/2)%2 == 0 {
+		rootNode.Opts.ColorM.Translate(1.0, 1.0, 1.0, 0.0)
+	}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.FlinchAnimation.Frames[0]))
+	return rootNode
+}
+
+func flinchAppearance(eb *behaviors.Flinch, e *state.Entity, b *bundle.Bundle) draw.Node {
+	return draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.FlinchAnimation.Frames[int(e.BehaviorState.ElapsedTime)])
+}
+
+func frozenAppearance(eb *behaviors.Frozen, e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	rootNode.Opts.ColorM.Translate(float64(0xa5)/float64(0xff), float64(0xa5)/float64(0xff), float64(0xff)/float64(0xff), 0.0)
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.StuckAnimation, int(e.BehaviorState.ElapsedTime)))
+	return rootNode
+}
+
+func gustAppearance(eb *behaviors.Gust, e *state.Entity, b *bundle.Bundle) draw.Node {
+	if e.IsPendingDestruction {
+		return nil
+	}
+
+	var gustImg *ebiten.Image = nil
+	switch eb.Style {
+	case behaviors.GustStyleWind:
+		gustImg = b.GustSprites.WindImage
+	case behaviors.GustStyleFan:
+		gustImg = b.GustSprites.FanImage
+	}
+
+	dx := (int(e.BehaviorState.ElapsedTime)-1+4)%4 - 2
+
+	rootNode := &draw.OptionsNode{}
+	rootNode.Opts.GeoM.Translate(float64(dx*state.TileRenderedWidth/4), 0)
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(gustImg, b.GustSprites.Animation, int(e.BehaviorState.ElapsedTime)))
+	return rootNode
+}
+
+func idleAppearance(eb *behaviors.Idle, e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.IdleAnimation, int(e.ElapsedTime)))
+
+	if eb.ChargingElapsedTime >= 10 {
+		chargingNode := &draw.OptionsNode{}
+		rootNode.Children = append(rootNode.Children, chargingNode)
+
+		frames := b.ChargingSprites.ChargingAnimation.Frames
+		if eb.ChargingElapsedTime >= e.PowerShotChargeTime {
+			frames = b.ChargingSprites.ChargedAnimation.Frames
+		}
+		frame := frames[int(eb.ChargingElapsedTime)%len(frames)]
+		chargingNode.Children = append(chargingNode.Children, draw.ImageWithFrame(b.ChargingSprites.Image, frame))
+	}
+
+	return rootNode
+}
+
+func paralyzedAppearance(eb *behaviors.Paralyzed, e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	if (e.ElapsedTime/2)%2 == 1 {
+		rootNode.Opts.ColorM.Translate(1.0, 1.0, 0.0, 0.0)
+	}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.StuckAnimation, int(e.BehaviorState.ElapsedTime)))
+	return rootNode
+}
+
+func swordAppearance(eb *behaviors.Sword, e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.SlashAnimation, int(e.BehaviorState.ElapsedTime)))
+
+	swordNode := &draw.OptionsNode{Layer: 6}
+	rootNode.Children = append(rootNode.Children, swordNode)
+	swordNode.Children = append(swordNode.Children, draw.ImageWithAnimation(b.SwordSprites.Image, b.SwordSprites.BaseAnimation, int(e.BehaviorState.ElapsedTime)))
+
+	return rootNode
+}
+
+func teleportAppearance(eb *behaviors.Teleport, e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+
+	var frame *pngsheet.Frame
+	if e.BehaviorState.ElapsedTime < 3 {
+		frame = b.MegamanSprites.TeleportStartAnimation.Frames[e.BehaviorState.ElapsedTime]
+	} else if e.BehaviorState.ElapsedTime < 6 {
+		frame = b.MegamanSprites.TeleportEndAnimation.Frames[e.BehaviorState.ElapsedTime-3]
+	} else {
+		frame = b.MegamanSprites.TeleportEndAnimation.Frames[len(b.MegamanSprites.TeleportEndAnimation.Frames)-1]
+	}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithFrame(b.MegamanSprites.Image, frame))
+
+	if eb.ChargingElapsedTime >= 10 {
+		chargingNode := &draw.OptionsNode{}
+		rootNode.Children = append(rootNode.Children, chargingNode)
+
+		frames := b.ChargingSprites.ChargingAnimation.Frames
+		if eb.ChargingElapsedTime >= e.PowerShotChargeTime {
+			frames = b.ChargingSprites.ChargedAnimation.Frames
+		}
+		frame := frames[int(eb.ChargingElapsedTime)%len(frames)]
+		chargingNode.Children = append(chargingNode.Children, draw.ImageWithFrame(b.ChargingSprites.Image, frame))
+	}
+
+	return rootNode
+}
+
+func vulcanAppearance(eb *behaviors.Vulcan, e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	var megamanImageNode draw.Node
+
+	if e.BehaviorState.ElapsedTime < 2 {
+		megamanImageNode = draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.HoldInFrontAnimation.Frames[int(e.BehaviorState.ElapsedTime)%len(b.MegamanSprites.HoldInFrontAnimation.Frames)])
+	} else {
+		megamanImageNode = draw.ImageWithFrame(b.MegamanSprites.Image, b.MegamanSprites.GattlingAnimation.Frames[int(e.BehaviorState.ElapsedTime-2)%len(b.MegamanSprites.GattlingAnimation.Frames)])
+	}
+	rootNode.Children = append(rootNode.Children, megamanImageNode)
+
+	vulcanNode := &draw.OptionsNode{Layer: 6}
+	rootNode.Children = append(rootNode.Children, vulcanNode)
+	vulcanNode.Opts.GeoM.Translate(float64(24), float64(-24))
+	var vulcanImageNode draw.Node
+	if e.BehaviorState.ElapsedTime < 2 {
+		vulcanImageNode = draw.ImageWithFrame(b.VulcanSprites.Image, b.VulcanSprites.Animations[0].Frames[e.BehaviorState.ElapsedTime])
+	} else {
+		vulcanFrames := b.VulcanSprites.Animations[1].Frames
+		vulcanImageNode = draw.ImageWithFrame(b.VulcanSprites.Image, vulcanFrames[int(e.BehaviorState.ElapsedTime-2)%len(vulcanFrames)])
+	}
+	vulcanNode.Children = append(vulcanNode.Children, vulcanImageNode)
+
+	return rootNode
+}
+
+func windFanAppearance(eb *behaviors.WindFan, e *state.Entity, b *bundle.Bundle) draw.Node {
+	img := b.WindFanSprites.WindImage
+	if eb.IsFan {
+		img = b.WindFanSprites.FanImage
+	}
+
+	rootNode := &draw.OptionsNode{}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(img, b.WindFanSprites.Animation, int(e.BehaviorState.ElapsedTime)))
+	return rootNode
+}
+
+func windRackAppearance(eb *behaviors.WindRack, e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	rootNode.Children = append(rootNode.Children, draw.ImageWithAnimation(b.MegamanSprites.Image, b.MegamanSprites.SlashAnimation, int(e.BehaviorState.ElapsedTime)))
+
+	swordNode := &draw.OptionsNode{Layer: 6}
+	rootNode.Children = append(rootNode.Children, swordNode)
+	swordNode.Children = append(swordNode.Children, draw.ImageWithAnimation(b.WindRackSprites.Image, b.WindRackSprites.Animations[0], int(e.BehaviorState.ElapsedTime)))
+
+	return rootNode
+}
@@ -0,0 +1,262 @@
+package render
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"strconv"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/state"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	debugDrawEntityMarker = flag.Bool("debug_draw_entity_markers", false, "draw entity markers")
+)
+
+const (
+	fieldOffsetTopFull = 87
+	fieldOffsetTop     = 72
+)
+
+func StateAppearance(s *state.State, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	rootNode.Opts.GeoM.Translate(0, fieldOffsetTop)
+	{
+		tilesNode := &draw.OptionsNode{}
+		tilesNode.Children = append(tilesNode.Children, fieldAppearance(s.Field, b))
+		rootNode.Children = append(rootNode.Children, tilesNode)
+	}
+	{
+		entitiesNode := &draw.OptionsNode{}
+		entities := maps.Values(s.Entities)
+		slices.SortFunc(entities, func(a *state.Entity, b *state.Entity) bool {
+			x1, y1 := a.TilePos.XY()
+			x2, y2 := b.TilePos.XY()
+			if y1 != y2 {
+				return y1 < y2
+			}
+			if x1 != x2 {
+				return x1 < x2
+			}
+			return a.ID() > b.ID()
+		})
+		for _, entity := range entities {
+			node := entityAppearance(entity, b)
+			if node == nil {
+				continue
+			}
+			entitiesNode.Children = append(entitiesNode.Children, node)
+		}
+		rootNode.Children = append(rootNode.Children, entitiesNode)
+	}
+	{
+		decorationsNode := &draw.OptionsNode{}
+		decorations := maps.Values(s.Decorations)
+		slices.SortFunc(decorations, func(a *state.Decoration, b *state.Decoration) bool {
+			x1, y1 := a.TilePos.XY()
+			x2, y2 := b.TilePos.XY()
+			if y1 != y2 {
+				return y1 < y2
+			}
+			if x1 != x2 {
+				return x1 < x2
+			}
+			return a.ID() > b.ID()
+		})
+		for _, decoration := range decorations {
+			node := decorationAppearance(decoration, b)
+			if node == nil {
+				continue
+			}
+			decorationsNode.Children = append(decorationsNode.Children, node)
+		}
+		rootNode.Children = append(rootNode.Children, decorationsNode)
+	}
+	return rootNode
+}
+
+func fieldAppearance(f *state.Field, b *bundle.Bundle) draw.Node {
+	optsNode := &draw.OptionsNode{}
+	for i, tile := range f.Tiles {
+		x, y := state.TilePos(i).XY()
+		node := tileAppearance(tile, y, b)
+		if node == nil {
+			continue
+		}
+
+		childNode := &draw.OptionsNode{}
+		childNode.Opts.GeoM.Translate(float64((x-1)*state.TileRenderedWidth), float64((y-1)*state.TileRenderedHeight))
+		childNode.Children = append(childNode.Children, node)
+		optsNode.Children = append(optsNode.Children, childNode)
+	}
+
+	for x := 1; x < 7; x++ {
+		childNode := &draw.OptionsNode{}
+		childNode.Opts.GeoM.Translate(float64((x-1)*state.TileRenderedWidth), float64((4-1)*state.TileRenderedHeight))
+		frame := b.Battletiles.Info.Animations[len(b.Battletiles.Info.Animations)-1].Frames[0]
+		tiles := b.Battletiles.OffererTiles
+		if f.Tiles[state.TilePosXY(x, 3)].IsAlliedWithAnswerer {
+			tiles = b.Battletiles.AnswererTiles
+		}
+		childNode.Children = append(childNode.Children, draw.ImageWithFrame(tiles, frame))
+		optsNode.Children = append(optsNode.Children, childNode)
+	}
+
+	return optsNode
+}
+
+func tileAppearance(t *state.Tile, y int, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	if t.BehaviorState.Behavior == nil {
+		return nil
+	}
+	tiles := b.Battletiles.OffererTiles
+	if t.IsAlliedWithAnswerer {
+		tiles = b.Battletiles.AnswererTiles
+	}
+	rootNode.Children = append(rootNode.Children, tileBehaviorAppearance(t, y, b, tiles))
+	if t.IsHighlighted {
+		rootNode.Opts.ColorM.Translate(1.0, 1.0, 1.0, 1.0)
+		rootNode.Opts.ColorM.Scale(1.0, 1.0, 0.0, 1.0)
+	}
+	return rootNode
+}
+
+var debugEntityMarkerImage *ebiten.Image
+var debugEntityMarkerImageOnce sync.Once
+
+func entityAppearance(e *state.Entity, b *bundle.Bundle) draw.Node {
+	rootNode := &draw.OptionsNode{}
+	x, y := e.TilePos.XY()
+
+	dx, dy := e.ForcedMovementState.ForcedMovement.Direction.XY()
+	offset := (int(e.ForcedMovementState.ElapsedTime)+2+4)%4 - 2
+	dx *= offset
+	dy *= offset
+
+	rootNode.Opts.GeoM.Translate(
+		float64((x-1)*state.TileRenderedWidth+state.TileRenderedWidth/2+dx*state.TileRenderedWidth/4),
+		float64((y-1)*state.TileRenderedHeight+state.TileRenderedHeight/2+dy*state.TileRenderedHeight/4),
+	)
+
+	rootCharacterNode := &draw.OptionsNode{}
+	rootNode.Children = append(rootNode.Children, rootCharacterNode)
+
+	if e.IsFlipped {
+		rootCharacterNode.Opts.GeoM.Scale(-1, 1)
+	}
+
+	characterNode := &draw.OptionsNode{}
+	rootCharacterNode.Children = append(rootCharacterNode.Children, characterNode)
+
+	characterNode.Children = append(characterNode.Children, entityBehaviorAppearance(e, b))
+
+	if e.Flashing.TimeLeft > 0 && (e.ElapsedTime/2)%2 == 0 {
+		characterNode.Opts.ColorM.Translate(0.0, 0.0, 0.0, -1.0)
+	}
+	if e.PerTickState.WasHit {
+		characterNode.Opts.ColorM.Translate(1.0, 1.0, 1.0, 0.0)
+	}
+	if e.Emotion == state.EmotionFullSynchro {
+		characterNode.Opts.ColorM.Translate(float64(0x29)/float64(0xff), float64(0x29)/float64(0xff), float64(0x29)/float64(0xff), 0.0)
+
+		fullSynchroNode := &draw.OptionsNode{Layer: 8}
+		fullSynchroNode.Children = append(fullSynchroNode.Children, draw.ImageWithAnimation(b.FullSynchroSprites.Image, b.FullSynchroSprites.Animations[0], int(e.ElapsedTime)))
+		rootCharacterNode.Children = append(rootCharacterNode.Children, fullSynchroNode)
+	} else if e.Emotion == state.EmotionAngry {
+		characterNode.Opts.ColorM.Translate(float64(0x80)/float64(0xff), float64(0)/float64(0xff), float64(0)/float64(0xff), 0.0)
+	}
+
+	if *debugDrawEntityMarker {
+		debugEntityMarkerImageOnce.Do(func() {
+			debugEntityMarkerImage = ebiten.NewImage(5, 5)
+			for x := 0; x < 5; x++ {
+				debugEntityMarkerImage.Set(x, 2, color.RGBA{255, 255, 255, 255})
+			}
+			for y := 0; y < 5; y++ {
+				debugEntityMarkerImage.Set(2, y, color.RGBA{255, 255, 255, 255})
+			}
+		})
+		debugEntityMarkerNode := &draw.OptionsNode{}
+		debugEntityMarkerNode.Children = append(debugEntityMarkerNode.Children, draw.ImageWithOrigin(debugEntityMarkerImage, image.Point{2, 2}))
+		debugEntityMarkerNode.Opts.ColorM.Scale(1.0, 0.0, 1.0, 0.5)
+		rootNode.Children = append(rootNode.Children, debugEntityMarkerNode)
+	}
+
+	if e.IsAlliedWithAnswerer {
+		if e.DisplayHP != 0 {
+			hpNode := &draw.OptionsNode{}
+			rootNode.Children = append(rootNode.Children, hpNode)
+
+			// Render HP.
+			hpText := strconv.Itoa(int(e.DisplayHP))
+			hpNode.Opts.GeoM.Translate(float64(0), float64(4))
+
+			for dx := -1; dx <= 1; dx++ {
+				for dy := -1; dy <= 1; dy++ {
+					strokeNode := &draw.OptionsNode{}
+					hpNode.Children = append(hpNode.Children, strokeNode)
+					strokeNode.Opts.GeoM.Translate(float64(dx), float64(dy))
+					strokeNode.Opts.ColorM.Scale(float64(0x31)/float64(0xFF), float64(0x39)/float64(0xFF), float64(0x52)/float64(0xFF), 1.0)
+					strokeNode.Children = append(strokeNode.Children, &draw.TextNode{Text: hpText, Face: b.TinyNumFont, Anchor: draw.TextAnchorCenter | draw.TextAnchorBottom})
+				}
+
+				fillNode := &draw.OptionsNode{}
+				hpNode.Children = append(hpNode.Children, fillNode)
+				if e.DisplayHP > e.HP {
+					fillNode.Opts.ColorM.Scale(float64(0xFF)/float64(0xFF), float64(0x84)/float64(0xFF), float64(0x5A)/float64(0xFF), 1.0)
+				} else if e.DisplayHP < e.HP {
+					fillNode.Opts.ColorM.Scale(float64(0x73)/float64(0xFF), float64(0xFF)/float64(0xFF), float64(0x4A)/float64(0xFF), 1.0)
+				}
+				fillNode.Children = append(fillNode.Children, &draw.TextNode{Text: hpText, Face: b.TinyNumFont, Anchor: draw.TextAnchorCenter | draw.TextAnchorBottom})
+			}
+		}
+	} else {
+		chipsNode := &draw.OptionsNode{}
+		chipsNode.Opts.GeoM.Translate(0, float64(-56))
+		rootNode.Children = append(rootNode.Children, chipsNode)
+
+		for i, chip := range e.Chips {
+			chipNode := &draw.OptionsNode{Layer: 8}
+			j := len(e.Chips) - i - 1
+			chipNode.Opts.GeoM.Translate(float64(-j*2), float64(-j*2))
+			chipsNode.Children = append(chipsNode.Children, chipNode)
+
+			chipNode.Children = append(chipNode.Children, draw.ImageWithFrame(b.ChipIconSprites.Image, b.ChipIconSprites.Animations[chip.Index].Frames[0]))
+		}
+	}
+
+	return rootNode
+}
+
+func decorationAppearance(d *state.Decoration, b *bundle.Bundle) draw.Node {
+	if d.ElapsedTime < 0 {
+		return nil
+	}
+
+	rootNode := &draw.OptionsNode{}
+	x, y := d.TilePos.XY()
+
+	rootNode.Opts.GeoM.Translate(
+		float64((x-1)*state.TileRenderedWidth+state.TileRenderedWidth/2),
+		float64((y-1)*state.TileRenderedHeight+state.TileRenderedHeight/2),
+	)
+
+	spriteNode := &draw.OptionsNode{}
+	rootNode.Children = append(rootNode.Children, spriteNode)
+
+	sprite := b.DecorationSprites[d.Type]
+	spriteNode.Children = append(spriteNode.Children, draw.ImageWithAnimation(sprite.Image, sprite.Animation, int(d.ElapsedTime)))
+	spriteNode.Opts.GeoM.Translate(float64(d.Offset.X), float64(d.Offset.Y))
+	if d.IsFlipped {
+		spriteNode.Opts.GeoM.Scale(-1, 1)
+	}
+
+	return rootNode
+}
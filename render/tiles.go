@@ -0,0 +1,35 @@
+package render
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/state"
+)
+
+func tileBehaviorAppearance(t *state.Tile, y int, b *bundle.Bundle, tiles *ebiten.Image) draw.Node {
+	switch tb := t.BehaviorState.Behavior.(type) {
+	case *state.BrokenTileBehavior:
+		return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[1*3+(y-1)], int(t.BehaviorState.ElapsedTime))
+	case *state.NormalTileBehavior:
+		return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[2*3+(y-1)], int(t.BehaviorState.ElapsedTime))
+	case *state.CrackedTileBehavior:
+		return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[3*3+(y-1)], int(t.BehaviorState.ElapsedTime))
+	case *state.RoadTileBehavior:
+		var offset int
+		switch tb.Direction {
+		case state.DirectionUp:
+			offset = 0
+		case state.DirectionDown:
+			offset = 1
+		case state.DirectionLeft:
+			offset = 2
+		case state.DirectionRight:
+			offset = 3
+		}
+		return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[(9+offset)*3+(y-1)], int(t.BehaviorState.ElapsedTime))
+	case *state.IceTileBehavior:
+		return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[7*3+(y-1)], int(t.BehaviorState.ElapsedTime))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package replay
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/murkland/nbarena/state"
+)
+
+const Version = 1
+
+var magic = [4]byte{'N', 'B', 'R', 'P'}
+
+var ErrVersionMismatch = errors.New("replay version mismatch")
+
+var ErrRulesMismatch = errors.New("replay rules mismatch")
+
+type IntentPair struct {
+	Offerer  state.Intent
+	Answerer state.Intent
+}
+
+type Replay struct {
+	Seed []byte
+
+	InitialState     *state.State
+	OffererEntityID  state.EntityID
+	AnswererEntityID state.EntityID
+
+	// Intents[i] are the intents used to step from tick i to tick i + 1.
+	Intents []IntentPair
+}
+
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter writes the header straight away, so an interrupted match still
+// leaves a playable replay.
+func NewWriter(w io.Writer, seed []byte, initialState *state.State, offererEntityID state.EntityID, answererEntityID state.EntityID) (*Writer, error) {
+	snapshot, err := initialState.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(magic[:]); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(Version)); err != nil {
+		return nil, err
+	}
+	rulesHash := state.RulesHash()
+	if _, err := w.Write(rulesHash[:]); err != nil {
+		return nil, err
+	}
+	if err := writeBytes(w, seed); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(offererEntityID)); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(answererEntityID)); err != nil {
+		return nil, err
+	}
+	if err := writeBytes(w, snapshot); err != nil {
+		return nil, err
+	}
+	return &Writer{w}, nil
+}
+
+func (w *Writer) WriteIntents(offererIntent state.Intent, answererIntent state.Intent) error {
+	return binary.Write(w.w, binary.LittleEndian, IntentPair{offererIntent, answererIntent})
+}
+
+func writeBytes(w io.Writer, buf []byte) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(buf))); err != nil {
+		return err
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func readBytes(r io.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Read ignores a trailing partial intent pair, e.g. from a client that crashed
+// mid-write.
+func Read(r io.Reader) (*Replay, error) {
+	var gotMagic [4]byte
+	if _, err := io.ReadFull(r, gotMagic[:]); err != nil {
+		return nil, err
+	}
+	if gotMagic != magic {
+		return nil, errors.New("not a replay")
+	}
+
+	var version uint32
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != Version {
+		return nil, fmt.Errorf("%w: expected %d, got %d", ErrVersionMismatch, Version, version)
+	}
+
+	var rulesHash [32]byte
+	if _, err := io.ReadFull(r, rulesHash[:]); err != nil {
+		return nil, err
+	}
+	if rulesHash != state.RulesHash() {
+		return nil, ErrRulesMismatch
+	}
+
+	rp := &Replay{}
+
+	var err error
+	rp.Seed, err = readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var offererEntityID uint64
+	if err := binary.Read(r, binary.LittleEndian, &offererEntityID); err != nil {
+		return nil, err
+	}
+	rp.OffererEntityID = state.EntityID(offererEntityID)
+
+	var answererEntityID uint64
+	if err := binary.Read(r, binary.LittleEndian, &answererEntityID); err != nil {
+		return nil, err
+	}
+	rp.AnswererEntityID = state.EntityID(answererEntityID)
+
+	snapshot, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	rp.InitialState = &state.State{}
+	if err := rp.InitialState.UnmarshalBinary(snapshot); err != nil {
+		return nil, err
+	}
+
+	for {
+		var ip IntentPair
+		if err := binary.Read(r, binary.LittleEndian, &ip); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+		rp.Intents = append(rp.Intents, ip)
+	}
+
+	return rp, nil
+}
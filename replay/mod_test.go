@@ -0,0 +1,142 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+)
+
+var testData = gamedata.NewData()
+
+var testFolder = state.Folder{{Chip: chips.Recov200, Code: 'C'}, {Chip: chips.Vulcan1, Code: 'C'}, {Chip: chips.Cannon, Code: 'C'}, {Chip: chips.Sword, Code: 'C'}, {Chip: chips.Cannon, Code: 'C'}}
+
+func newTestState() (*state.State, state.EntityID, state.EntityID) {
+	return match.NewState([]byte("replay"), 1, testFolder, testFolder)
+}
+
+func testIntent(tick int, salt int) state.Intent {
+	return state.Intent{
+		Direction:         []state.Direction{state.DirectionNone, state.DirectionUp, state.DirectionLeft, state.DirectionDown, state.DirectionRight}[(tick/15+salt)%5],
+		UseChip:           (tick+salt)%45 == 0,
+		ChargeBasicWeapon: (tick+salt)%40 < 20,
+		Confirm:           (tick+salt)%150 == 0,
+	}
+}
+
+func writeTestReplay(t *testing.T, ticks int) []byte {
+	t.Helper()
+
+	s, offererEntityID, answererEntityID := newTestState()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []byte("seed"), s, offererEntityID, answererEntityID)
+	if err != nil {
+		t.Fatalf("NewWriter: %s", err)
+	}
+	for tick := 0; tick < ticks; tick++ {
+		if err := w.WriteIntents(testIntent(tick, 0), testIntent(tick, 1)); err != nil {
+			t.Fatalf("WriteIntents: %s", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestWriteRead(t *testing.T) {
+	const ticks = 100
+	raw := writeTestReplay(t, ticks)
+
+	rp, err := Read(bytes.NewReader(raw[:len(raw)-1]))
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+
+	s, offererEntityID, answererEntityID := newTestState()
+	if string(rp.Seed) != "seed" {
+		t.Errorf("expected seed %q, got %q", "seed", rp.Seed)
+	}
+	if rp.OffererEntityID != offererEntityID || rp.AnswererEntityID != answererEntityID {
+		t.Errorf("expected entity IDs %d and %d, got %d and %d", offererEntityID, answererEntityID, rp.OffererEntityID, rp.AnswererEntityID)
+	}
+	if rp.InitialState.Checksum() != s.Checksum() {
+		t.Errorf("initial state differs")
+	}
+	if len(rp.Intents) != ticks-1 {
+		t.Fatalf("expected %d intent pairs, got %d", ticks-1, len(rp.Intents))
+	}
+	for tick, ip := range rp.Intents {
+		if ip.Offerer != testIntent(tick, 0) || ip.Answerer != testIntent(tick, 1) {
+			t.Fatalf("intents differ at tick %d", tick)
+		}
+	}
+}
+
+func TestReadRejectsMismatches(t *testing.T) {
+	raw := writeTestReplay(t, 10)
+
+	corrupt := func(offset int) []byte {
+		buf := append([]byte(nil), raw...)
+		buf[offset] ^= 0xff
+		return buf
+	}
+
+	if _, err := Read(bytes.NewReader(corrupt(0))); err == nil {
+		t.Errorf("expected a bad magic to be rejected")
+	}
+	if _, err := Read(bytes.NewReader(corrupt(len(magic)))); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch, got %v", err)
+	}
+	if _, err := Read(bytes.NewReader(corrupt(len(magic) + 4))); !errors.Is(err, ErrRulesMismatch) {
+		t.Errorf("expected ErrRulesMismatch, got %v", err)
+	}
+}
+
+func TestPlayerSeek(t *testing.T) {
+	const ticks = 3*snapshotInterval + 100
+	rp, err := Read(bytes.NewReader(writeTestReplay(t, ticks)))
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+
+	p := NewPlayer(rp, testData)
+	checksums := []uint64{p.State().Checksum()}
+	for p.Step() {
+		checksums = append(checksums, p.State().Checksum())
+	}
+	if len(checksums) != ticks+1 {
+		t.Fatalf("expected %d ticks, got %d", ticks, len(checksums)-1)
+	}
+
+	// Including to ticks a fresh player hasn't snapshotted yet.
+	p = NewPlayer(rp, testData)
+	for _, tick := range []int{
+		snapshotInterval + 10,
+		snapshotInterval - 1,
+		3*snapshotInterval + 50,
+		2 * snapshotInterval,
+		2*snapshotInterval - 1,
+		snapshotInterval + 1,
+		0,
+		ticks,
+		-1,
+		ticks + 1,
+	} {
+		p.Seek(tick)
+		want := tick
+		if want < 0 {
+			want = 0
+		}
+		if want > ticks {
+			want = ticks
+		}
+		if p.Tick() != want {
+			t.Fatalf("expected to seek to tick %d, got %d", want, p.Tick())
+		}
+		if p.State().Checksum() != checksums[want] {
+			t.Errorf("state after seeking to tick %d differs from a straight playthrough", tick)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package replay
+
+import (
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+)
+
+// snapshotInterval is how often the state is kept, so seeking backwards doesn't
+// re-simulate from the start.
+const snapshotInterval = 600
+
+type Player struct {
+	replay *Replay
+	data   *gamedata.Data
+
+	state *state.State
+	tick  int
+
+	// snapshots[i] is the state at tick i * snapshotInterval.
+	snapshots []*state.State
+}
+
+func NewPlayer(rp *Replay, d *gamedata.Data) *Player {
+	return &Player{
+		replay:    rp,
+		data:      d,
+		state:     rp.InitialState.Clone(),
+		snapshots: []*state.State{rp.InitialState.Clone()},
+	}
+}
+
+func (p *Player) Replay() *Replay {
+	return p.replay
+}
+
+func (p *Player) State() *state.State {
+	return p.state
+}
+
+func (p *Player) Tick() int {
+	return p.tick
+}
+
+func (p *Player) Len() int {
+	return len(p.replay.Intents)
+}
+
+// Step returns false if the replay has ended.
+func (p *Player) Step() bool {
+	if p.tick >= len(p.replay.Intents) {
+		return false
+	}
+
+	ip := p.replay.Intents[p.tick]
+	p.state.Entities[p.replay.OffererEntityID].Intent = ip.Offerer
+	p.state.Entities[p.replay.AnswererEntityID].Intent = ip.Answerer
+	step.Step(p.state, p.data)
+	p.tick++
+
+	if p.tick%snapshotInterval == 0 && len(p.snapshots) == p.tick/snapshotInterval {
+		p.snapshots = append(p.snapshots, p.state.Clone())
+	}
+	return true
+}
+
+func (p *Player) Seek(tick int) {
+	if tick < 0 {
+		tick = 0
+	}
+	if tick > len(p.replay.Intents) {
+		tick = len(p.replay.Intents)
+	}
+
+	i := tick / snapshotInterval
+	if i >= len(p.snapshots) {
+		i = len(p.snapshots) - 1
+	}
+	if snapshotTick := i * snapshotInterval; tick < p.tick || snapshotTick > p.tick {
+		p.state = p.snapshots[i].Clone()
+		p.tick = snapshotTick
+	}
+
+	for p.tick < tick {
+		p.Step()
+	}
+}
@@ -0,0 +1,220 @@
+package rollback
+
+import (
+	"errors"
+
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+)
+
+var ErrTooManyPendingIntents = errors.New("too many pending intents")
+
+// CommitFunc is called in order for every tick whose intents are both known.
+type CommitFunc func(tick int, localIntent state.Intent, remoteIntent state.Intent, s *state.State) error
+
+// Stats are counters for comparing how well predictors do.
+type Stats struct {
+	// PredictedTicks only counts ticks whose remote intent has been received.
+	PredictedTicks int
+	Mispredictions int
+
+	Rollbacks          int
+	TotalRollbackDepth int
+	MaxRollbackDepth   int
+}
+
+// Engine simulates ahead of the remote by predicting its intents, and rolls
+// back only as far as the first mispredicted tick.
+//
+// Snapshots are never mutated once taken, so they may be rendered. Cloning
+// dominates the cost of a tick, so re-simulation only keeps the snapshots at
+// either end: the ones in between are filled back in if they are needed.
+type Engine struct {
+	data *gamedata.Data
+
+	localEntityID  state.EntityID
+	remoteEntityID state.EntityID
+
+	predictor Predictor
+	onCommit  CommitFunc
+
+	stats Stats
+
+	// Indexed by tick modulo the ring size. states[t] is nil if it was skipped
+	// during re-simulation, but never for the committed and head ticks.
+	states               []*state.State
+	localIntents         []state.Intent
+	remoteIntents        []state.Intent
+	usedRemoteIntents    []state.Intent
+	committedTick        int
+	headTick             int
+	lastRemoteIntentTick int
+}
+
+// New allows up to maxPending ticks of intents to be outstanding on either
+// side. If predictor is nil, a ReleaseDirectionPredictor is used.
+func New(s *state.State, d *gamedata.Data, localEntityID state.EntityID, remoteEntityID state.EntityID, maxPending int, predictor Predictor, onCommit CommitFunc) *Engine {
+	if predictor == nil {
+		predictor = &ReleaseDirectionPredictor{}
+	}
+
+	tick := int(s.ElapsedTime)
+	e := &Engine{
+		data: d,
+
+		localEntityID:  localEntityID,
+		remoteEntityID: remoteEntityID,
+
+		predictor: predictor,
+		onCommit:  onCommit,
+
+		states:            make([]*state.State, maxPending+1),
+		localIntents:      make([]state.Intent, maxPending+1),
+		remoteIntents:     make([]state.Intent, maxPending+1),
+		usedRemoteIntents: make([]state.Intent, maxPending+1),
+
+		committedTick:        tick,
+		headTick:             tick,
+		lastRemoteIntentTick: tick,
+	}
+	e.states[e.index(tick)] = s
+	return e
+}
+
+func (e *Engine) index(tick int) int {
+	return tick % len(e.states)
+}
+
+func (e *Engine) maxPending() int {
+	return len(e.states) - 1
+}
+
+func (e *Engine) CommittedTick() int {
+	return e.committedTick
+}
+
+func (e *Engine) CommittedState() *state.State {
+	return e.states[e.index(e.committedTick)]
+}
+
+func (e *Engine) HeadTick() int {
+	return e.headTick
+}
+
+func (e *Engine) HeadState() *state.State {
+	return e.states[e.index(e.headTick)]
+}
+
+func (e *Engine) LastRemoteIntentTick() int {
+	return e.lastRemoteIntentTick
+}
+
+func (e *Engine) PendingLocalIntents() int {
+	return e.headTick - e.committedTick
+}
+
+func (e *Engine) Stats() Stats {
+	return e.stats
+}
+
+func (e *Engine) remoteIntentFor(tick int) state.Intent {
+	if tick <= e.lastRemoteIntentTick {
+		return e.remoteIntents[e.index(tick)]
+	}
+	return e.predictor.Predict(tick - e.lastRemoteIntentTick)
+}
+
+func (e *Engine) stepState(s *state.State, tick int) {
+	remoteIntent := e.remoteIntentFor(tick)
+	s.Entities[e.localEntityID].Intent = e.localIntents[e.index(tick)]
+	s.Entities[e.remoteEntityID].Intent = remoteIntent
+	step.Step(s, e.data)
+	e.usedRemoteIntents[e.index(tick)] = remoteIntent
+}
+
+func (e *Engine) simulate(tick int) {
+	s := e.states[e.index(tick-1)].Clone()
+	e.stepState(s, tick)
+	e.states[e.index(tick)] = s
+}
+
+func (e *Engine) resimulate(tick int) {
+	base := tick - 1
+	for e.states[e.index(base)] == nil {
+		base--
+	}
+
+	e.simulate(base + 1)
+	if base+1 == e.headTick {
+		return
+	}
+
+	s := e.states[e.index(base+1)].Clone()
+	for t := base + 2; t <= e.headTick; t++ {
+		e.states[e.index(t)] = nil
+		e.stepState(s, t)
+	}
+	e.states[e.index(e.headTick)] = s
+}
+
+func (e *Engine) commit() error {
+	for e.committedTick < e.headTick && e.committedTick < e.lastRemoteIntentTick {
+		tick := e.committedTick + 1
+		if e.states[e.index(tick)] == nil {
+			e.simulate(tick)
+		}
+		e.committedTick = tick
+		if e.onCommit != nil {
+			if err := e.onCommit(tick, e.localIntents[e.index(tick)], e.remoteIntents[e.index(tick)], e.states[e.index(tick)]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *Engine) AddLocalIntent(intent state.Intent) error {
+	if e.headTick-e.committedTick >= e.maxPending() {
+		return ErrTooManyPendingIntents
+	}
+	tick := e.headTick + 1
+	e.localIntents[e.index(tick)] = intent
+	e.simulate(tick)
+	e.headTick = tick
+	return e.commit()
+}
+
+func (e *Engine) AddRemoteIntent(intent state.Intent) error {
+	if e.lastRemoteIntentTick-e.committedTick >= e.maxPending() {
+		return ErrTooManyPendingIntents
+	}
+	tick := e.lastRemoteIntentTick + 1
+	e.remoteIntents[e.index(tick)] = intent
+	e.lastRemoteIntentTick = tick
+	e.predictor.Observe(intent)
+
+	if tick <= e.headTick {
+		e.stats.PredictedTicks++
+		if intent != e.usedRemoteIntents[e.index(tick)] {
+			e.stats.Mispredictions++
+		}
+	}
+
+	// Later predictions may have changed too.
+	rollbackTick := tick
+	for rollbackTick <= e.headTick && e.remoteIntentFor(rollbackTick) == e.usedRemoteIntents[e.index(rollbackTick)] {
+		rollbackTick++
+	}
+	if rollbackTick <= e.headTick {
+		depth := e.headTick - rollbackTick + 1
+		e.stats.Rollbacks++
+		e.stats.TotalRollbackDepth += depth
+		if depth > e.stats.MaxRollbackDepth {
+			e.stats.MaxRollbackDepth = depth
+		}
+		e.resimulate(rollbackTick)
+	}
+
+	return e.commit()
+}
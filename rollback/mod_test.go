@@ -0,0 +1,343 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+	"github.com/murkland/nbarena/transport"
+)
+
+var benchmarkData = gamedata.NewData()
+
+var benchmarkFolder = state.Folder{{Chip: chips.Recov200, Code: 'C'}, {Chip: chips.Vulcan1, Code: 'C'}, {Chip: chips.Cannon, Code: 'C'}}
+
+func newBenchmarkState() (*state.State, state.EntityID, state.EntityID) {
+	return match.NewState([]byte("benchmark"), 1, benchmarkFolder, benchmarkFolder)
+}
+
+func benchmarkIntent(tick int, salt int) state.Intent {
+	return state.Intent{
+		Direction:         []state.Direction{state.DirectionNone, state.DirectionUp, state.DirectionLeft, state.DirectionDown, state.DirectionRight}[(tick/15+salt)%5],
+		UseChip:           (tick+salt)%45 == 0,
+		ChargeBasicWeapon: (tick+salt)%40 < 20,
+		Confirm:           (tick+salt)%150 == 0,
+	}
+}
+
+var benchmarkDelays = []int{10, 30, 60}
+
+const benchmarkMaxPending = 60
+
+func BenchmarkEngine(b *testing.B) {
+	for _, delay := range benchmarkDelays {
+		b.Run(fmt.Sprintf("delay=%d", delay), func(b *testing.B) {
+			s, localEntityID, remoteEntityID := newBenchmarkState()
+			e := New(s, benchmarkData, localEntityID, remoteEntityID, benchmarkMaxPending, nil, nil)
+			b.ResetTimer()
+			for i := 1; i <= b.N; i++ {
+				if i > delay {
+					if err := e.AddRemoteIntent(benchmarkIntent(i-delay, 1)); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := e.AddLocalIntent(benchmarkIntent(i, 0)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkResimulate measures the previous approach, which re-simulated every
+// pending tick on every intent.
+func BenchmarkResimulate(b *testing.B) {
+	for _, delay := range benchmarkDelays {
+		b.Run(fmt.Sprintf("delay=%d", delay), func(b *testing.B) {
+			committedState, localEntityID, remoteEntityID := newBenchmarkState()
+			var localIntents []state.Intent
+			var remoteIntents []state.Intent
+
+			fastForward := func() {
+				n := len(localIntents)
+				if len(remoteIntents) < n {
+					n = len(remoteIntents)
+				}
+				for i := 0; i < n; i++ {
+					committedState.Entities[localEntityID].Intent = localIntents[i]
+					committedState.Entities[remoteEntityID].Intent = remoteIntents[i]
+					step.Step(committedState, benchmarkData)
+				}
+				localIntents = localIntents[n:]
+				remoteIntents = remoteIntents[n:]
+
+				dirtyState := committedState.Clone()
+				for _, intent := range localIntents {
+					remoteIntent := committedState.Entities[remoteEntityID].LastIntent
+					remoteIntent.Direction = state.DirectionNone
+					dirtyState.Entities[localEntityID].Intent = intent
+					dirtyState.Entities[remoteEntityID].Intent = remoteIntent
+					step.Step(dirtyState, benchmarkData)
+				}
+			}
+
+			b.ResetTimer()
+			for i := 1; i <= b.N; i++ {
+				if i > delay {
+					remoteIntents = append(remoteIntents, benchmarkIntent(i-delay, 1))
+					fastForward()
+				}
+				localIntents = append(localIntents, benchmarkIntent(i, 0))
+				fastForward()
+			}
+		})
+	}
+}
+
+const testMaxPending = 8
+
+func TestEngine(t *testing.T) {
+	// Long enough to get into battle, and for the ring of snapshots to wrap
+	// around many times.
+	const ticks = 40 * (testMaxPending + 1)
+
+	reference, localEntityID, remoteEntityID := newBenchmarkState()
+	checksums := []uint64{reference.Checksum()}
+	for tick := 1; tick <= ticks; tick++ {
+		reference.Entities[localEntityID].Intent = benchmarkIntent(tick, 0)
+		reference.Entities[remoteEntityID].Intent = benchmarkIntent(tick, 1)
+		step.Step(reference, benchmarkData)
+		checksums = append(checksums, reference.Checksum())
+	}
+
+	for _, name := range PredictorNames {
+		for _, delay := range []int{0, 1, testMaxPending / 2, testMaxPending - 1} {
+			t.Run(fmt.Sprintf("%s/delay=%d", name, delay), func(t *testing.T) {
+				predictor, err := NewPredictor(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				s, localEntityID, remoteEntityID := newBenchmarkState()
+				e := New(s, benchmarkData, localEntityID, remoteEntityID, testMaxPending, predictor, nil)
+
+				addRemoteIntent := func(tick int) {
+					if err := e.AddRemoteIntent(benchmarkIntent(tick, 1)); err != nil {
+						t.Fatalf("AddRemoteIntent(%d): %s", tick, err)
+					}
+				}
+
+				for tick := 1; tick <= ticks; tick++ {
+					if err := e.AddLocalIntent(benchmarkIntent(tick, 0)); err != nil {
+						t.Fatalf("AddLocalIntent(%d): %s", tick, err)
+					}
+					if tick > delay {
+						addRemoteIntent(tick - delay)
+					}
+					if got, want := e.CommittedState().Checksum(), checksums[e.CommittedTick()]; got != want {
+						t.Fatalf("committed state at tick %d differs from a straight run", e.CommittedTick())
+					}
+				}
+				for tick := ticks - delay + 1; tick <= ticks; tick++ {
+					addRemoteIntent(tick)
+				}
+
+				if e.CommittedTick() != ticks || e.HeadTick() != ticks {
+					t.Fatalf("expected everything committed through tick %d, got committed %d and head %d", ticks, e.CommittedTick(), e.HeadTick())
+				}
+				if e.CommittedState().Checksum() != checksums[ticks] {
+					t.Errorf("committed state differs from a straight run")
+				}
+				if e.HeadState().Checksum() != checksums[ticks] {
+					t.Errorf("head state differs from a straight run")
+				}
+				if delay > 0 && e.Stats().Rollbacks == 0 {
+					t.Errorf("expected some predictions to be wrong and rolled back, got %+v", e.Stats())
+				}
+			})
+		}
+	}
+}
+
+func TestEngineTooManyPendingIntents(t *testing.T) {
+	s, localEntityID, remoteEntityID := newBenchmarkState()
+	e := New(s, benchmarkData, localEntityID, remoteEntityID, testMaxPending, nil, nil)
+	for tick := 1; tick <= testMaxPending; tick++ {
+		if err := e.AddLocalIntent(benchmarkIntent(tick, 0)); err != nil {
+			t.Fatalf("AddLocalIntent(%d): %s", tick, err)
+		}
+	}
+	if err := e.AddLocalIntent(state.Intent{}); err != ErrTooManyPendingIntents {
+		t.Fatalf("expected ErrTooManyPendingIntents, got %v", err)
+	}
+
+	if err := e.AddRemoteIntent(benchmarkIntent(1, 1)); err != nil {
+		t.Fatalf("AddRemoteIntent: %s", err)
+	}
+	if err := e.AddLocalIntent(state.Intent{}); err != nil {
+		t.Errorf("expected room for another local intent, got %s", err)
+	}
+}
+
+// peer resends unacknowledged intents every tick, the way the game does.
+type peer struct {
+	conn      transport.Transport
+	engine    *Engine
+	salt      int
+	checksums []uint64
+	committed chan struct{}
+
+	unackedStart uint32
+	unacked      []state.Intent
+}
+
+func newPeer(conn transport.Transport, isAnswerer bool, ticks int) *peer {
+	s, offererEntityID, answererEntityID := newBenchmarkState()
+	p := &peer{conn: conn, committed: make(chan struct{}), unackedStart: 1}
+	localEntityID, remoteEntityID := offererEntityID, answererEntityID
+	if isAnswerer {
+		localEntityID, remoteEntityID = answererEntityID, offererEntityID
+		p.salt = 1
+	}
+	p.engine = New(s, benchmarkData, localEntityID, remoteEntityID, benchmarkMaxPending, &MarkovPredictor{}, func(tick int, localIntent state.Intent, remoteIntent state.Intent, s *state.State) error {
+		p.checksums = append(p.checksums, s.Checksum())
+		if tick == ticks {
+			close(p.committed)
+		}
+		return nil
+	})
+	return p
+}
+
+func (p *peer) handle(packet packets.Intent) error {
+	if n := int(packet.AckTick) - int(p.unackedStart) + 1; n > 0 {
+		if n > len(p.unacked) {
+			n = len(p.unacked)
+		}
+		p.unacked = p.unacked[n:]
+		p.unackedStart += uint32(n)
+	}
+	for i, intent := range packet.Intents[:packet.NumIntents] {
+		if int(packet.StartTick)+i != p.engine.LastRemoteIntentTick()+1 {
+			continue
+		}
+		if err := p.engine.AddRemoteIntent(intent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *peer) run(ctx context.Context, ticks int) error {
+	received := make(chan packets.Packet, 64)
+	go func() {
+		for {
+			packet, err := packets.Recv(ctx, p.conn)
+			if err != nil {
+				close(received)
+				return
+			}
+			select {
+			case received <- packet:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case packet, ok := <-received:
+			if !ok {
+				return nil
+			}
+			intentPacket, ok := packet.(packets.Intent)
+			if !ok {
+				return fmt.Errorf("expected intent, got %T", packet)
+			}
+			if err := p.handle(intentPacket); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if p.engine.HeadTick() < ticks && p.engine.PendingLocalIntents() < benchmarkMaxPending {
+				intent := benchmarkIntent(p.engine.HeadTick()+1, p.salt)
+				if err := p.engine.AddLocalIntent(intent); err != nil {
+					return err
+				}
+				p.unacked = append(p.unacked, intent)
+			}
+			packet := packets.Intent{AckTick: uint32(p.engine.LastRemoteIntentTick()), StartTick: p.unackedStart}
+			packet.NumIntents = uint8(copy(packet.Intents[:], p.unacked))
+			if err := packets.Send(ctx, p.conn, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func TestPeersOverJitter(t *testing.T) {
+	const ticks = 300
+
+	a, b := transport.NewLoopbackPair()
+	conditions := transport.Conditions{
+		Latency:     150 * time.Millisecond,
+		Jitter:      150 * time.Millisecond,
+		LossRate:    0.1,
+		ReorderRate: 0.5,
+	}
+	conditions.Seed = 1
+	offererConn := transport.Simulate(a, conditions)
+	conditions.Seed = 2
+	answererConn := transport.Simulate(b, conditions)
+	defer offererConn.Close()
+	defer answererConn.Close()
+
+	offerer := newPeer(offererConn, false, ticks)
+	answerer := newPeer(answererConn, true, ticks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for _, p := range []*peer{offerer, answerer} {
+		p := p
+		go func() {
+			errs <- p.run(ctx, ticks)
+		}()
+	}
+
+	// Keep sending until both are done, so the last acknowledgements get through.
+	timeout := time.After(30 * time.Second)
+	for _, p := range []*peer{offerer, answerer} {
+		select {
+		case <-p.committed:
+		case err := <-errs:
+			t.Fatalf("peer stopped early: %v", err)
+		case <-timeout:
+			t.Fatalf("timed out waiting for both sides to commit")
+		}
+	}
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("peer failed: %s", err)
+		}
+	}
+
+	if len(offerer.checksums) != ticks || len(answerer.checksums) != ticks {
+		t.Fatalf("expected both sides to commit %d ticks, offerer committed %d and answerer %d", ticks, len(offerer.checksums), len(answerer.checksums))
+	}
+	for i := range offerer.checksums {
+		if offerer.checksums[i] != answerer.checksums[i] {
+			t.Fatalf("sides committed different states at tick %d", i+1)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package rollback
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/murkland/nbarena/state"
+)
+
+type Predictor interface {
+	Observe(intent state.Intent)
+
+	// Predict is called with ticksAhead of at least 1.
+	Predict(ticksAhead int) state.Intent
+}
+
+// ReleaseDirectionPredictor assumes the last intent is held, but without any
+// movement.
+type ReleaseDirectionPredictor struct {
+	last state.Intent
+}
+
+func (p *ReleaseDirectionPredictor) Observe(intent state.Intent) {
+	p.last = intent
+}
+
+func (p *ReleaseDirectionPredictor) Predict(ticksAhead int) state.Intent {
+	intent := p.last
+	intent.Direction = state.DirectionNone
+	return intent
+}
+
+type RepeatPredictor struct {
+	last state.Intent
+}
+
+func (p *RepeatPredictor) Observe(intent state.Intent) {
+	p.last = intent
+}
+
+func (p *RepeatPredictor) Predict(ticksAhead int) state.Intent {
+	return p.last
+}
+
+// HoldPredictor only holds the direction for the first HoldTicks ticks.
+type HoldPredictor struct {
+	HoldTicks int
+
+	last state.Intent
+}
+
+func (p *HoldPredictor) Observe(intent state.Intent) {
+	p.last = intent
+}
+
+func (p *HoldPredictor) Predict(ticksAhead int) state.Intent {
+	intent := p.last
+	if ticksAhead > p.HoldTicks {
+		intent.Direction = state.DirectionNone
+	}
+	return intent
+}
+
+// MarkovPredictor follows the most frequent transitions from the last intent.
+type MarkovPredictor struct {
+	transitions map[state.Intent]map[state.Intent]int
+	last        state.Intent
+
+	// predictions[i] is for i + 1 ticks ahead.
+	predictions []state.Intent
+}
+
+func (p *MarkovPredictor) Observe(intent state.Intent) {
+	if p.transitions == nil {
+		p.transitions = map[state.Intent]map[state.Intent]int{}
+	}
+	next, ok := p.transitions[p.last]
+	if !ok {
+		next = map[state.Intent]int{}
+		p.transitions[p.last] = next
+	}
+	next[intent]++
+	p.last = intent
+	p.predictions = p.predictions[:0]
+}
+
+func intentSortKey(intent state.Intent) uint64 {
+	key := uint64(intent.Direction)
+	for _, b := range []bool{intent.UseChip, intent.Confirm, intent.CutIn, intent.EndTurn, intent.ChargeBasicWeapon} {
+		key <<= 1
+		if b {
+			key |= 1
+		}
+	}
+	return key
+}
+
+func (p *MarkovPredictor) mostLikelyAfter(intent state.Intent) state.Intent {
+	next, ok := p.transitions[intent]
+	if !ok {
+		return intent
+	}
+
+	candidates := make([]state.Intent, 0, len(next))
+	for candidate := range next {
+		candidates = append(candidates, candidate)
+	}
+	// Break ties deterministically, preferring to stay put.
+	sort.Slice(candidates, func(i, j int) bool {
+		if next[candidates[i]] != next[candidates[j]] {
+			return next[candidates[i]] > next[candidates[j]]
+		}
+		if (candidates[i] == intent) != (candidates[j] == intent) {
+			return candidates[i] == intent
+		}
+		return intentSortKey(candidates[i]) < intentSortKey(candidates[j])
+	})
+	return candidates[0]
+}
+
+func (p *MarkovPredictor) Predict(ticksAhead int) state.Intent {
+	for len(p.predictions) < ticksAhead {
+		prev := p.last
+		if len(p.predictions) > 0 {
+			prev = p.predictions[len(p.predictions)-1]
+		}
+		p.predictions = append(p.predictions, p.mostLikelyAfter(prev))
+	}
+	return p.predictions[ticksAhead-1]
+}
+
+var PredictorNames = []string{"release_direction", "repeat", "hold", "markov"}
+
+const defaultHoldTicks = 8
+
+func NewPredictor(name string) (Predictor, error) {
+	switch name {
+	case "release_direction":
+		return &ReleaseDirectionPredictor{}, nil
+	case "repeat":
+		return &RepeatPredictor{}, nil
+	case "hold":
+		return &HoldPredictor{HoldTicks: defaultHoldTicks}, nil
+	case "markov":
+		return &MarkovPredictor{}, nil
+	}
+	return nil, fmt.Errorf("unknown predictor %q, must be one of %v", name, PredictorNames)
+}
@@ -0,0 +1,158 @@
+package rollback
+
+import (
+	"testing"
+
+	"github.com/murkland/nbarena/state"
+)
+
+var (
+	none  = state.Intent{}
+	up    = state.Intent{Direction: state.DirectionUp}
+	down  = state.Intent{Direction: state.DirectionDown}
+	left  = state.Intent{Direction: state.DirectionLeft}
+	shoot = state.Intent{Direction: state.DirectionUp, ChargeBasicWeapon: true}
+)
+
+func observeAll(p Predictor, intents ...state.Intent) {
+	for _, intent := range intents {
+		p.Observe(intent)
+	}
+}
+
+func TestReleaseDirectionPredictor(t *testing.T) {
+	p := &ReleaseDirectionPredictor{}
+	p.Observe(shoot)
+	for _, ticksAhead := range []int{1, 100} {
+		if got := p.Predict(ticksAhead); got != (state.Intent{ChargeBasicWeapon: true}) {
+			t.Errorf("expected the buttons without the direction %d ticks ahead, got %+v", ticksAhead, got)
+		}
+	}
+}
+
+func TestRepeatPredictor(t *testing.T) {
+	p := &RepeatPredictor{}
+	p.Observe(shoot)
+	for _, ticksAhead := range []int{1, 100} {
+		if got := p.Predict(ticksAhead); got != shoot {
+			t.Errorf("expected the last intent %d ticks ahead, got %+v", ticksAhead, got)
+		}
+	}
+}
+
+func TestHoldPredictor(t *testing.T) {
+	p := &HoldPredictor{HoldTicks: 3}
+	p.Observe(shoot)
+	for ticksAhead := 1; ticksAhead <= 3; ticksAhead++ {
+		if got := p.Predict(ticksAhead); got != shoot {
+			t.Errorf("expected the last intent %d ticks ahead, got %+v", ticksAhead, got)
+		}
+	}
+	if got := p.Predict(4); got != (state.Intent{ChargeBasicWeapon: true}) {
+		t.Errorf("expected the buttons without the direction past HoldTicks, got %+v", got)
+	}
+}
+
+func TestMarkovPredictorTieBreaking(t *testing.T) {
+	p := &MarkovPredictor{}
+	p.Observe(left)
+	if got := p.Predict(1); got != left {
+		t.Errorf("expected an unseen intent to be held, got %+v", got)
+	}
+
+	p = &MarkovPredictor{}
+	observeAll(p, up, up, down, up)
+	if got := p.Predict(1); got != up {
+		t.Errorf("expected a tie to prefer staying put, got %+v", got)
+	}
+
+	// Neither stays put, and down has the lower sort key.
+	p = &MarkovPredictor{}
+	observeAll(p, left, none, down, none)
+	if got := p.Predict(1); got != down {
+		t.Errorf("expected a tie to go to the lowest sort key, got %+v", got)
+	}
+
+	p = &MarkovPredictor{}
+	observeAll(p, up, up, down, up, down, up)
+	if got := p.Predict(1); got != down {
+		t.Errorf("expected the most frequent transition, got %+v", got)
+	}
+}
+
+func TestMarkovPredictorFollowsTransitions(t *testing.T) {
+	p := &MarkovPredictor{}
+	observeAll(p, up, down, left, up, down, left, up)
+	for i, want := range []state.Intent{down, left, up, down} {
+		if got := p.Predict(i + 1); got != want {
+			t.Errorf("expected %+v %d ticks ahead, got %+v", want, i+1, got)
+		}
+	}
+}
+
+func TestMarkovPredictorInvalidatesPredictions(t *testing.T) {
+	p := &MarkovPredictor{}
+	observeAll(p, up, down, up, down, left, left)
+	if got := p.Predict(2); got != left {
+		t.Fatalf("expected %+v, got %+v", left, got)
+	}
+
+	p.Observe(up)
+	if got := p.Predict(1); got != down {
+		t.Errorf("expected %+v after observing a new intent, got %+v", down, got)
+	}
+	if got := p.Predict(2); got != up {
+		t.Errorf("expected %+v after observing a new intent, got %+v", up, got)
+	}
+}
+
+func TestNewPredictor(t *testing.T) {
+	for _, name := range PredictorNames {
+		if _, err := NewPredictor(name); err != nil {
+			t.Errorf("NewPredictor(%q): %s", name, err)
+		}
+	}
+	if _, err := NewPredictor("psychic"); err == nil {
+		t.Errorf("expected an unknown predictor to be rejected")
+	}
+}
+
+func TestStats(t *testing.T) {
+	s, localEntityID, remoteEntityID := newBenchmarkState()
+	e := New(s, benchmarkData, localEntityID, remoteEntityID, testMaxPending, &RepeatPredictor{}, nil)
+
+	for i := 0; i < 4; i++ {
+		if err := e.AddLocalIntent(none); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, intent := range []state.Intent{
+		none, // Predicted none: right.
+		up,   // Predicted none: wrong, rolls back ticks 2 to 4, predicting up.
+		up,   // Predicted up: right.
+		none, // Predicted up: wrong, rolls back tick 4.
+	} {
+		if err := e.AddRemoteIntent(intent); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := e.Stats(), (Stats{
+		PredictedTicks:     4,
+		Mispredictions:     2,
+		Rollbacks:          2,
+		TotalRollbackDepth: 4,
+		MaxRollbackDepth:   3,
+	}); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	// Ahead of the local intents, so not predicted.
+	if err := e.AddRemoteIntent(up); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Stats().PredictedTicks; got != 4 {
+		t.Errorf("expected an unpredicted tick not to count, got %d predicted ticks", got)
+	}
+}
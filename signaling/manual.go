@@ -0,0 +1,46 @@
+package signaling
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// manualSDPPrefix makes pasting something else give a clear error.
+const manualSDPPrefix = "nbsdp1:"
+
+// EncodeSDP compresses a session description into a line short enough to copy
+// and paste by hand.
+func EncodeSDP(sdp string) string {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := io.WriteString(w, sdp); err != nil {
+		panic(err)
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return manualSDPPrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes())
+}
+
+func DecodeSDP(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, manualSDPPrefix) {
+		return "", fmt.Errorf("not an encoded session description: must start with %q", manualSDPPrefix)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s[len(manualSDPPrefix):])
+	if err != nil {
+		return "", fmt.Errorf("malformed session description: %w", err)
+	}
+	sdp, err := io.ReadAll(flate.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		return "", fmt.Errorf("malformed session description: %w", err)
+	}
+	return string(sdp), nil
+}
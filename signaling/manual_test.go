@@ -0,0 +1,47 @@
+package signaling
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSDP = `v=0
+o=- 4215775240449105457 1646528960 IN IP4 0.0.0.0
+s=-
+t=0 0
+a=fingerprint:sha-256 6B:8B:F0:65:5F:78:E2:51:3B:AC:6F:F3:3F:46:1B:35:DC:B8:5F:64:1A:24:C2:43:F0:A1:58:D0:A1:2C:19:08
+a=group:BUNDLE 0
+m=application 9 UDP/DTLS/SCTP webrtc-datachannel
+c=IN IP4 0.0.0.0
+a=setup:actpass
+a=mid:0
+a=sendrecv
+a=sctp-port:5000
+a=ice-ufrag:yFkVQGFxWqvXyMtJ
+a=ice-pwd:hMMCzUZKbAqXKzHbOqNPvWjxGaFNZrca
+a=candidate:1 1 udp 2130706431 192.168.1.2 50000 typ host
+a=end-of-candidates
+`
+
+func TestSDPRoundTrip(t *testing.T) {
+	encoded := EncodeSDP(testSDP)
+	if strings.ContainsAny(encoded, " \n") {
+		t.Errorf("encoded SDP %q is not a single word", encoded)
+	}
+
+	decoded, err := DecodeSDP("  " + encoded + "\n")
+	if err != nil {
+		t.Fatalf("DecodeSDP: %s", err)
+	}
+	if decoded != testSDP {
+		t.Errorf("DecodeSDP: got %q, want %q", decoded, testSDP)
+	}
+}
+
+func TestDecodeSDPRejectsGarbage(t *testing.T) {
+	for _, s := range []string{"", "v=0", manualSDPPrefix + "!!!", manualSDPPrefix + "AAAA"} {
+		if _, err := DecodeSDP(s); err == nil {
+			t.Errorf("DecodeSDP(%q): expected error", s)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package signaling serves signor sessions, so matches can be set up without
+// any external services.
+package signaling
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/murkland/signor/pb"
+	"github.com/twitchtv/twirp"
+)
+
+type session struct {
+	// offered is closed once offerSDP has been set.
+	offered  chan struct{}
+	offerSDP string
+
+	answered    bool
+	answerSDPCh chan string
+}
+
+func newSession() *session {
+	return &session{
+		offered:     make(chan struct{}),
+		answerSDPCh: make(chan string, 1),
+	}
+}
+
+func (sess *session) isOffered() bool {
+	select {
+	case <-sess.offered:
+		return true
+	default:
+		return false
+	}
+}
+
+// Server differs from the standalone signor server in that GetOffer waits for
+// an offer instead of failing, so the answerer may be started first.
+type Server struct {
+	sessions   map[string]*session
+	sessionsMu sync.Mutex
+}
+
+var _ pb.SessionService = (*Server)(nil)
+
+func NewServer() *Server {
+	return &Server{
+		sessions: map[string]*session{},
+	}
+}
+
+func (s *Server) getOrCreateSession(id string) *session {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	return s.getOrCreateSessionLocked(id)
+}
+
+func (s *Server) getOrCreateSessionLocked(id string) *session {
+	sess, ok := s.sessions[id]
+	if !ok {
+		sess = newSession()
+		s.sessions[id] = sess
+	}
+	return sess
+}
+
+func (s *Server) removeSession(id string, sess *session) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	if s.sessions[id] == sess {
+		delete(s.sessions, id)
+	}
+}
+
+func (s *Server) NumSessions() int {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	return len(s.sessions)
+}
+
+func (s *Server) Offer(ctx context.Context, req *pb.OfferRequest) (*pb.OfferResponse, error) {
+	id := string(req.SessionId)
+	sess, err := (func() (*session, error) {
+		s.sessionsMu.Lock()
+		defer s.sessionsMu.Unlock()
+		// A cancelled GetOffer could otherwise remove the session before it's
+		// offered.
+		sess := s.getOrCreateSessionLocked(id)
+		if sess.isOffered() {
+			return nil, twirp.AlreadyExists.Error("session already exists")
+		}
+		sess.offerSDP = req.MyOfferSdp
+		close(sess.offered)
+		return sess, nil
+	})()
+	if err != nil {
+		return nil, err
+	}
+	defer s.removeSession(id, sess)
+
+	select {
+	case answerSDP := <-sess.answerSDPCh:
+		return &pb.OfferResponse{
+			TheirAnswerSdp: answerSDP,
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Server) GetOffer(ctx context.Context, req *pb.GetOfferRequest) (*pb.GetOfferResponse, error) {
+	id := string(req.SessionId)
+	sess := s.getOrCreateSession(id)
+
+	select {
+	case <-sess.offered:
+	case <-ctx.Done():
+		s.sessionsMu.Lock()
+		defer s.sessionsMu.Unlock()
+		// Don't leave behind sessions that nobody ever offered.
+		if s.sessions[id] == sess && !sess.isOffered() {
+			delete(s.sessions, id)
+		}
+		return nil, ctx.Err()
+	}
+
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	return &pb.GetOfferResponse{
+		TheirOfferSdp: sess.offerSDP,
+	}, nil
+}
+
+func (s *Server) Answer(ctx context.Context, req *pb.AnswerRequest) (*pb.AnswerResponse, error) {
+	id := string(req.SessionId)
+
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+
+	sess := s.sessions[id]
+	if sess == nil || !sess.isOffered() {
+		return nil, twirp.NotFound.Error("no such session")
+	}
+	if sess.answered {
+		return nil, twirp.AlreadyExists.Error("session already answered")
+	}
+	sess.answered = true
+	sess.answerSDPCh <- req.MyAnswerSdp
+	delete(s.sessions, id)
+
+	return &pb.AnswerResponse{}, nil
+}
+
+// Handler allows cross-origin requests, so the web build can be served from
+// elsewhere.
+func (s *Server) Handler() http.Handler {
+	twirpHandler := pb.NewSessionServiceServer(s)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Twirp-Version")
+			w.Header().Add("Vary", "Origin")
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		twirpHandler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,174 @@
+package signaling
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/murkland/signor/pb"
+	"github.com/twitchtv/twirp"
+)
+
+func newTestClient(t *testing.T) (*Server, pb.SessionService) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, pb.NewSessionServiceProtobufClient(ts.URL, http.DefaultClient)
+}
+
+type offerResult struct {
+	resp *pb.OfferResponse
+	err  error
+}
+
+func offer(ctx context.Context, c pb.SessionService, sessionID string, sdp string) <-chan offerResult {
+	ch := make(chan offerResult, 1)
+	go func() {
+		resp, err := c.Offer(ctx, &pb.OfferRequest{SessionId: []byte(sessionID), MyOfferSdp: sdp})
+		ch <- offerResult{resp, err}
+	}()
+	return ch
+}
+
+func answer(t *testing.T, ctx context.Context, c pb.SessionService, sessionID string, wantOfferSDP string, answerSDP string) {
+	getOfferResp, err := c.GetOffer(ctx, &pb.GetOfferRequest{SessionId: []byte(sessionID)})
+	if err != nil {
+		t.Fatalf("GetOffer: %s", err)
+	}
+	if getOfferResp.TheirOfferSdp != wantOfferSDP {
+		t.Fatalf("GetOffer: got %q, want %q", getOfferResp.TheirOfferSdp, wantOfferSDP)
+	}
+	if _, err := c.Answer(ctx, &pb.AnswerRequest{SessionId: []byte(sessionID), MyAnswerSdp: answerSDP}); err != nil {
+		t.Fatalf("Answer: %s", err)
+	}
+}
+
+func checkOfferResult(t *testing.T, ch <-chan offerResult, wantAnswerSDP string) {
+	r := <-ch
+	if r.err != nil {
+		t.Fatalf("Offer: %s", r.err)
+	}
+	if r.resp.TheirAnswerSdp != wantAnswerSDP {
+		t.Fatalf("Offer: got %q, want %q", r.resp.TheirAnswerSdp, wantAnswerSDP)
+	}
+}
+
+func TestOfferThenAnswer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, c := newTestClient(t)
+
+	offerCh := offer(ctx, c, "session", "offer sdp")
+	answer(t, ctx, c, "session", "offer sdp", "answer sdp")
+	checkOfferResult(t, offerCh, "answer sdp")
+
+	if n := s.NumSessions(); n != 0 {
+		t.Errorf("got %d sessions left behind, want 0", n)
+	}
+}
+
+func TestAnswererFirst(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, c := newTestClient(t)
+
+	answerDone := make(chan struct{})
+	go func() {
+		defer close(answerDone)
+		answer(t, ctx, c, "session", "offer sdp", "answer sdp")
+	}()
+
+	// Give GetOffer a chance to start waiting.
+	time.Sleep(50 * time.Millisecond)
+	checkOfferResult(t, offer(ctx, c, "session", "offer sdp"), "answer sdp")
+	<-answerDone
+}
+
+func TestDuplicateOffer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, c := newTestClient(t)
+
+	offerCh := offer(ctx, c, "session", "offer sdp")
+	if _, err := c.GetOffer(ctx, &pb.GetOfferRequest{SessionId: []byte("session")}); err != nil {
+		t.Fatalf("GetOffer: %s", err)
+	}
+
+	_, err := c.Offer(ctx, &pb.OfferRequest{SessionId: []byte("session"), MyOfferSdp: "other offer sdp"})
+	if twerr, ok := err.(twirp.Error); !ok || twerr.Code() != twirp.AlreadyExists {
+		t.Fatalf("second Offer: got %v, want already exists", err)
+	}
+
+	if _, err := c.Answer(ctx, &pb.AnswerRequest{SessionId: []byte("session"), MyAnswerSdp: "answer sdp"}); err != nil {
+		t.Fatalf("Answer: %s", err)
+	}
+	checkOfferResult(t, offerCh, "answer sdp")
+}
+
+func TestAnswerWithoutOffer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, c := newTestClient(t)
+
+	_, err := c.Answer(ctx, &pb.AnswerRequest{SessionId: []byte("session"), MyAnswerSdp: "answer sdp"})
+	if twerr, ok := err.(twirp.Error); !ok || twerr.Code() != twirp.NotFound {
+		t.Fatalf("Answer: got %v, want not found", err)
+	}
+}
+
+func (s *Server) isOffered(id string) bool {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	sess := s.sessions[id]
+	return sess != nil && sess.isOffered()
+}
+
+func TestGetOfferCancelledDuringOffer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s := NewServer()
+
+	getOfferCtx, cancelGetOffer := context.WithCancel(ctx)
+	getOfferDone := make(chan struct{})
+	go func() {
+		defer close(getOfferDone)
+		s.GetOffer(getOfferCtx, &pb.GetOfferRequest{SessionId: []byte("session")})
+	}()
+	for s.NumSessions() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	offerCh := offer(ctx, s, "session", "offer sdp")
+	for !s.isOffered("session") {
+		time.Sleep(time.Millisecond)
+	}
+	cancelGetOffer()
+	<-getOfferDone
+
+	answer(t, ctx, s, "session", "offer sdp", "answer sdp")
+	checkOfferResult(t, offerCh, "answer sdp")
+}
+
+func TestGetOfferCancelledConcurrentlyWithOffer(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		s := NewServer()
+
+		getOfferDone := make(chan struct{})
+		go func() {
+			defer close(getOfferDone)
+			s.GetOffer(cancelledCtx, &pb.GetOfferRequest{SessionId: []byte("session")})
+		}()
+		offerCh := offer(ctx, s, "session", "offer sdp")
+		<-getOfferDone
+
+		answer(t, ctx, s, "session", "offer sdp", "answer sdp")
+		checkOfferResult(t, offerCh, "answer sdp")
+		cancel()
+	}
+}
@@ -1,11 +1,17 @@
 package sound
 
 import (
+	"time"
+
 	"github.com/faiface/beep"
 	"github.com/murkland/nbarena/bundle"
 	"github.com/murkland/nbarena/state"
 )
 
+func ticksToSampleOffset(sr beep.SampleRate, t state.Ticks) int {
+	return sr.N(time.Duration(t) * time.Second / 60)
+}
+
 type playingSound struct {
 	sound  *state.Sound
 	stream beep.StreamSeeker
@@ -33,7 +39,7 @@ func (s *scheduler) Dispatch(b *bundle.Bundle, sounds map[state.SoundID]*state.S
 		}
 
 		buf := b.Sounds[sound.Type]
-		i := state.TicksToSampleOffset(buf.Format().SampleRate, sound.ElapsedTime)
+		i := ticksToSampleOffset(buf.Format().SampleRate, sound.ElapsedTime)
 		if i >= buf.Len() {
 			continue
 		}
@@ -0,0 +1,138 @@
+package spectate
+
+import (
+	"context"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/murkland/nbarena/replay"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/transport"
+)
+
+// spectatorQueueSize bounds how far a spectator may fall behind before it is
+// dropped, so it never holds up the match.
+const spectatorQueueSize = 600
+
+type spectator struct {
+	t       transport.Transport
+	queue   chan []byte
+	started bool
+}
+
+type Broadcaster struct {
+	offererEntityID  state.EntityID
+	answererEntityID state.EntityID
+
+	mu         sync.Mutex
+	spectators []*spectator
+}
+
+func NewBroadcaster(offererEntityID state.EntityID, answererEntityID state.EntityID) *Broadcaster {
+	return &Broadcaster{
+		offererEntityID:  offererEntityID,
+		answererEntityID: answererEntityID,
+	}
+}
+
+func (b *Broadcaster) Add(t transport.Transport) {
+	sp := &spectator{
+		t:     t,
+		queue: make(chan []byte, spectatorQueueSize),
+	}
+
+	b.mu.Lock()
+	b.spectators = append(b.spectators, sp)
+	b.mu.Unlock()
+
+	go func() {
+		defer t.Close()
+		for buf := range sp.queue {
+			if err := t.Send(context.Background(), buf); err != nil {
+				log.Printf("dropping spectator: %s", err)
+				b.mu.Lock()
+				b.remove(sp, false)
+				b.mu.Unlock()
+				return
+			}
+		}
+	}()
+}
+
+// remove must be called with mu held.
+func (b *Broadcaster) remove(sp *spectator, flush bool) {
+	for i, sp2 := range b.spectators {
+		if sp2 == sp {
+			b.spectators = append(b.spectators[:i], b.spectators[i+1:]...)
+			close(sp.queue)
+			if !flush {
+				sp.t.Close()
+			}
+			return
+		}
+	}
+}
+
+func (b *Broadcaster) NumSpectators() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.spectators)
+}
+
+func (b *Broadcaster) Serve(lis net.Listener) error {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return err
+		}
+		log.Printf("spectator connected from %s", conn.RemoteAddr())
+		b.Add(transport.NewStream(conn))
+	}
+}
+
+// Commit is called with the state after the intents were applied.
+func (b *Broadcaster) Commit(tick int, offererIntent state.Intent, answererIntent state.Intent, s *state.State) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var start []byte
+	var intents []byte
+	for _, sp := range append([]*spectator(nil), b.spectators...) {
+		var buf []byte
+		if !sp.started {
+			// Just joined, so send the state instead.
+			if start == nil {
+				var err error
+				start, err = marshalStart(tick, s, b.offererEntityID, b.answererEntityID)
+				if err != nil {
+					log.Printf("failed to snapshot state for spectator: %s", err)
+					b.remove(sp, false)
+					continue
+				}
+			}
+			buf = start
+			sp.started = true
+		} else {
+			if intents == nil {
+				intents = marshalIntents(tick, replay.IntentPair{Offerer: offererIntent, Answerer: answererIntent})
+			}
+			buf = intents
+		}
+
+		select {
+		case sp.queue <- buf:
+		default:
+			log.Printf("dropping spectator: too far behind")
+			b.remove(sp, false)
+		}
+	}
+}
+
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.spectators) > 0 {
+		b.remove(b.spectators[0], true)
+	}
+}
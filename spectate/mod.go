@@ -0,0 +1,101 @@
+// Package spectate streams committed intents to spectators. Players never
+// read anything spectators send, so spectators can't influence the match.
+package spectate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/murkland/nbarena/packets"
+	"github.com/murkland/nbarena/replay"
+	"github.com/murkland/nbarena/state"
+)
+
+var ErrIncompatible = errors.New("incompatible match")
+
+type messageType uint8
+
+const (
+	messageTypeStart   messageType = 0
+	messageTypeIntents messageType = 1
+)
+
+// startHeader is followed by a snapshot of the state at Tick.
+type startHeader struct {
+	ProtocolVersion  uint32
+	RulesHash        [32]uint8
+	Tick             uint32
+	OffererEntityID  uint64
+	AnswererEntityID uint64
+}
+
+type intentsMessage struct {
+	Tick    uint32
+	Intents replay.IntentPair
+}
+
+func marshal(typ messageType, header any, rest []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(uint8(typ))
+	if err := binary.Write(&buf, binary.LittleEndian, header); err != nil {
+		panic(err)
+	}
+	buf.Write(rest)
+	return buf.Bytes()
+}
+
+func marshalStart(tick int, s *state.State, offererEntityID state.EntityID, answererEntityID state.EntityID) ([]byte, error) {
+	snapshot, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return marshal(messageTypeStart, startHeader{
+		ProtocolVersion:  packets.ProtocolVersion,
+		RulesHash:        state.RulesHash(),
+		Tick:             uint32(tick),
+		OffererEntityID:  uint64(offererEntityID),
+		AnswererEntityID: uint64(answererEntityID),
+	}, snapshot), nil
+}
+
+func marshalIntents(tick int, ip replay.IntentPair) []byte {
+	return marshal(messageTypeIntents, intentsMessage{uint32(tick), ip}, nil)
+}
+
+func unmarshalStart(raw []byte) (startHeader, *state.State, error) {
+	var h startHeader
+	r := bytes.NewReader(raw)
+	if typ, err := r.ReadByte(); err != nil {
+		return h, nil, err
+	} else if messageType(typ) != messageTypeStart {
+		return h, nil, fmt.Errorf("expected start message, got %d", typ)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return h, nil, err
+	}
+	if h.ProtocolVersion != packets.ProtocolVersion {
+		return h, nil, fmt.Errorf("%w: protocol version is %d, ours is %d", ErrIncompatible, h.ProtocolVersion, packets.ProtocolVersion)
+	}
+	if h.RulesHash != state.RulesHash() {
+		return h, nil, fmt.Errorf("%w: simulation rules differ", ErrIncompatible)
+	}
+	s := &state.State{}
+	if err := s.UnmarshalBinary(raw[len(raw)-r.Len():]); err != nil {
+		return h, nil, err
+	}
+	return h, s, nil
+}
+
+func unmarshalIntents(raw []byte) (intentsMessage, error) {
+	var m intentsMessage
+	r := bytes.NewReader(raw)
+	if typ, err := r.ReadByte(); err != nil {
+		return m, err
+	} else if messageType(typ) != messageTypeIntents {
+		return m, fmt.Errorf("expected intents message, got %d", typ)
+	}
+	err := binary.Read(r, binary.LittleEndian, &m)
+	return m, err
+}
@@ -0,0 +1,95 @@
+package spectate
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/match"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+	"github.com/murkland/nbarena/transport"
+)
+
+var testData = gamedata.NewData()
+
+var testFolder = state.Folder{{Chip: chips.Recov200, Code: 'C'}, {Chip: chips.Vulcan1, Code: 'C'}, {Chip: chips.Cannon, Code: 'C'}}
+
+func newTestState() (*state.State, state.EntityID, state.EntityID) {
+	return match.NewState([]byte("spectate"), 1, testFolder, testFolder)
+}
+
+func testIntent(tick int, salt int) state.Intent {
+	return state.Intent{
+		Direction:         []state.Direction{state.DirectionNone, state.DirectionUp, state.DirectionLeft, state.DirectionDown, state.DirectionRight}[(tick/15+salt)%5],
+		UseChip:           (tick+salt)%45 == 0,
+		ChargeBasicWeapon: (tick+salt)%40 < 20,
+		Confirm:           (tick+salt)%150 == 0,
+	}
+}
+
+func TestLateJoin(t *testing.T) {
+	const joinTick = 100
+	const endTick = 400
+	const delay = 10
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, offererEntityID, answererEntityID := newTestState()
+	b := NewBroadcaster(offererEntityID, answererEntityID)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go b.Serve(lis)
+
+	commit := func(tick int) {
+		s.Entities[offererEntityID].Intent = testIntent(tick, 0)
+		s.Entities[answererEntityID].Intent = testIntent(tick, 1)
+		step.Step(s, testData)
+		b.Commit(tick, s.Entities[offererEntityID].Intent, s.Entities[answererEntityID].Intent, s)
+	}
+
+	tick := 1
+	for ; tick <= joinTick; tick++ {
+		commit(tick)
+	}
+
+	conn, err := transport.DialTCP(ctx, lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for b.NumSpectators() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for ; tick <= endTick; tick++ {
+		commit(tick)
+	}
+	b.Close()
+
+	w, err := Watch(ctx, conn, testData, delay)
+	if err != nil {
+		t.Fatalf("Watch: %s", err)
+	}
+	if w.Tick() != joinTick+1 {
+		t.Errorf("started watching at tick %d, want %d", w.Tick(), joinTick+1)
+	}
+
+	for w.Tick() < endTick {
+		if ctx.Err() != nil {
+			t.Fatalf("timed out at tick %d", w.Tick())
+		}
+		w.Update()
+	}
+
+	if got, want := w.State().Checksum(), s.Checksum(); got != want {
+		t.Errorf("spectator checksum %016x, want %016x", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package spectate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/replay"
+	"github.com/murkland/nbarena/state"
+	"github.com/murkland/nbarena/step"
+	"github.com/murkland/nbarena/transport"
+)
+
+// Watcher stays delay ticks behind the latest one received, so it plays back
+// smoothly.
+type Watcher struct {
+	data  *gamedata.Data
+	delay int
+
+	OffererEntityID  state.EntityID
+	AnswererEntityID state.EntityID
+
+	state *state.State
+	tick  int
+
+	buffering bool
+	queue     []replay.IntentPair
+
+	mu       sync.Mutex
+	received []replay.IntentPair
+	err      error
+}
+
+func Watch(ctx context.Context, t transport.Transport, d *gamedata.Data, delay int) (*Watcher, error) {
+	raw, err := t.Recv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h, s, err := unmarshalStart(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		data:  d,
+		delay: delay,
+
+		OffererEntityID:  state.EntityID(h.OffererEntityID),
+		AnswererEntityID: state.EntityID(h.AnswererEntityID),
+
+		state: s,
+		tick:  int(h.Tick),
+
+		buffering: true,
+	}
+	go w.recv(t)
+	return w, nil
+}
+
+func (w *Watcher) recv(t transport.Transport) {
+	nextTick := w.tick + 1
+	for {
+		err := (func() error {
+			raw, err := t.Recv(context.Background())
+			if err != nil {
+				return err
+			}
+			m, err := unmarshalIntents(raw)
+			if err != nil {
+				return err
+			}
+			if int(m.Tick) != nextTick {
+				return fmt.Errorf("expected intents for tick %d, got %d", nextTick, m.Tick)
+			}
+			nextTick++
+
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			w.received = append(w.received, m.Intents)
+			return nil
+		})()
+		if err != nil {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			w.err = err
+			t.Close()
+			return
+		}
+	}
+}
+
+func (w *Watcher) State() *state.State {
+	return w.state
+}
+
+func (w *Watcher) Tick() int {
+	return w.tick
+}
+
+func (w *Watcher) Buffering() bool {
+	return w.buffering
+}
+
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *Watcher) Ended() bool {
+	return w.Err() != nil && len(w.queue) == 0
+}
+
+// Update steps twice if it has fallen too far behind.
+func (w *Watcher) Update() {
+	w.mu.Lock()
+	w.queue = append(w.queue, w.received...)
+	w.received = w.received[:0]
+	ended := w.err != nil
+	w.mu.Unlock()
+
+	if w.buffering {
+		if len(w.queue) < w.delay && !ended {
+			return
+		}
+		w.buffering = false
+	}
+
+	n := 1
+	if len(w.queue) > 2*w.delay {
+		n = 2
+	}
+	for i := 0; i < n && len(w.queue) > 0; i++ {
+		w.step(w.queue[0])
+		w.queue = w.queue[1:]
+	}
+
+	if len(w.queue) == 0 && !ended {
+		w.buffering = true
+	}
+}
+
+func (w *Watcher) step(ip replay.IntentPair) {
+	w.state.Entities[w.OffererEntityID].Intent = ip.Offerer
+	w.state.Entities[w.AnswererEntityID].Intent = ip.Answerer
+	step.Step(w.state, w.data)
+	w.tick++
+}
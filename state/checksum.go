@@ -0,0 +1,106 @@
+package state
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/murkland/syncrand"
+)
+
+var syncrandSourceType = reflect.TypeOf((*syncrand.Source)(nil))
+
+// Checksum hashes the state independently of map order and pointer identity.
+// Functions are skipped, and the random number generator is hashed by its
+// position only.
+func (s *State) Checksum() uint64 {
+	h := fnv.New64a()
+	writeChecksum(h, reflect.ValueOf(s))
+	return h.Sum64()
+}
+
+func writeChecksumUint64(h hash.Hash64, v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	h.Write(buf[:])
+}
+
+func writeChecksum(h hash.Hash64, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			writeChecksumUint64(h, 1)
+		} else {
+			writeChecksumUint64(h, 0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeChecksumUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeChecksumUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeChecksumUint64(h, math.Float64bits(v.Float()))
+	case reflect.String:
+		writeChecksumUint64(h, uint64(v.Len()))
+		h.Write([]byte(v.String()))
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		// Not part of the simulation state.
+	case reflect.Pointer:
+		if v.IsNil() {
+			writeChecksumUint64(h, 0)
+			return
+		}
+		writeChecksumUint64(h, 1)
+		if v.Type() == syncrandSourceType {
+			writeChecksumUint64(h, uint64(v.Interface().(*syncrand.Source).SeedOffset()))
+			return
+		}
+		writeChecksum(h, v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			writeChecksumUint64(h, 0)
+			return
+		}
+		elem := v.Elem()
+		typeName := elem.Type().String()
+		writeChecksumUint64(h, uint64(len(typeName)))
+		h.Write([]byte(typeName))
+		writeChecksum(h, elem)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			writeChecksum(h, v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		writeChecksumUint64(h, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			writeChecksum(h, v.Index(i))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return lessChecksumKey(keys[i], keys[j])
+		})
+		writeChecksumUint64(h, uint64(len(keys)))
+		for _, k := range keys {
+			writeChecksum(h, k)
+			writeChecksum(h, v.MapIndex(k))
+		}
+	default:
+		panic(fmt.Sprintf("cannot checksum value of kind %s", v.Kind()))
+	}
+}
+
+func lessChecksumKey(a reflect.Value, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	panic(fmt.Sprintf("cannot checksum map with key of kind %s", a.Kind()))
+}
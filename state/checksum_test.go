@@ -0,0 +1,150 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testBehavior struct {
+	N int
+}
+
+func (b *testBehavior) Clone() EntityBehavior {
+	return &testBehavior{b.N}
+}
+
+func (b *testBehavior) Traits(e *Entity) EntityBehaviorTraits {
+	return EntityBehaviorTraits{}
+}
+
+func (b *testBehavior) Step(e *Entity, s *State) {}
+
+func (b *testBehavior) Cleanup(e *Entity, s *State) {}
+
+func newTestEntity(id EntityID) *Entity {
+	return &Entity{
+		id: id,
+
+		BehaviorState: EntityBehaviorState{
+			Behavior: &testBehavior{},
+		},
+		NextBehavior: &testBehavior{},
+
+		HP:    int(id) * 10,
+		MaxHP: int(id) * 10,
+
+		TilePos: TilePosXY(int(id)%6+1, int(id)%3+1),
+	}
+}
+
+// newTestState inserts entities and decorations in the given order of IDs.
+func newTestState(order []int) *State {
+	s := New([]byte("checksum"))
+	for _, i := range order {
+		e := newTestEntity(EntityID(i))
+		s.Entities[e.id] = e
+
+		d := &Decoration{id: DecorationID(i), ElapsedTime: Ticks(i)}
+		s.Decorations[d.id] = d
+	}
+	s.nextEntityID = EntityID(len(order) + 1)
+	s.nextDecorationID = DecorationID(len(order) + 1)
+	return s
+}
+
+func TestChecksumIsIndependentOfMapOrder(t *testing.T) {
+	const n = 100
+	forward := make([]int, n)
+	backward := make([]int, n)
+	for i := 0; i < n; i++ {
+		forward[i] = i + 1
+		backward[i] = n - i
+	}
+
+	want := newTestState(forward).Checksum()
+	if got := newTestState(backward).Checksum(); got != want {
+		t.Errorf("checksum depends on insertion order: %016x != %016x", got, want)
+	}
+	for i := 0; i < 10; i++ {
+		if got := newTestState(forward).Checksum(); got != want {
+			t.Fatalf("checksum depends on map iteration order: %016x != %016x", got, want)
+		}
+	}
+	if got := newTestState(forward).Clone().Checksum(); got != want {
+		t.Errorf("checksum of a clone differs: %016x != %016x", got, want)
+	}
+}
+
+func TestChecksumChangesWithRandOffset(t *testing.T) {
+	s := newTestState([]int{1, 2})
+	s2 := s.Clone()
+	s2.RandSource.Int63()
+	if s.Checksum() == s2.Checksum() {
+		t.Errorf("checksum did not change with the random number generator's offset")
+	}
+}
+
+// mutate changes v in place, returning false if it can't.
+func mutate(v reflect.Value) bool {
+	if !v.CanSet() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(!v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(v.Uint() + 1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(v.Float() + 1)
+	case reflect.String:
+		v.SetString(v.String() + "!")
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+			return true
+		}
+		return mutate(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return false
+		}
+		return v.Elem().Kind() == reflect.Pointer && !v.Elem().IsNil() && mutate(v.Elem().Elem())
+	case reflect.Slice:
+		v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	case reflect.Array:
+		return v.Len() > 0 && mutate(v.Index(0))
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if mutate(v.Field(i)) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+	return true
+}
+
+func TestChecksumChangesWithEntityFields(t *testing.T) {
+	want := newTestState([]int{1, 2}).Checksum()
+
+	typ := reflect.TypeOf(Entity{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		s := newTestState([]int{1, 2})
+		if !mutate(reflect.ValueOf(s.Entities[2]).Elem().Field(i)) {
+			t.Errorf("could not change field %s", field.Name)
+			continue
+		}
+		if s.Checksum() == want {
+			t.Errorf("checksum did not change with field %s", field.Name)
+		}
+	}
+}
@@ -1,13 +1,89 @@
 package state
 
+import (
+	"fmt"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ChipCode limits what a chip can be picked alongside on the custom screen.
+type ChipCode byte
+
+// ChipCodeAny goes with every other code.
+const ChipCodeAny ChipCode = '*'
+
+func (c ChipCode) String() string {
+	return string(rune(c))
+}
+
+type ChipClass int
+
+const (
+	ChipClassStandard ChipClass = 0
+	ChipClassMega     ChipClass = 1
+	ChipClassGiga     ChipClass = 2
+)
+
 type Chip struct {
-	Index        int
-	Name         string
-	BaseDamage   int
+	Index      int
+	Name       string
+	BaseDamage int
+
+	Codes []ChipCode
+	MB    int
+	Class ChipClass
+
+	// Element is only what the chip is listed under: its behavior decides the
+	// element of its hits.
+	Element Element
+
 	MakeBehavior func(damage Damage) EntityBehavior
+	// BehaviorSpec lets RulesHash tell apart chips that behave differently.
+	BehaviorSpec string
+}
+
+func (c *Chip) HasCode(code ChipCode) bool {
+	return slices.Contains(c.Codes, code)
 }
 
 func (c Chip) Clone() Chip {
 	// Chips are immutable.
 	return c
 }
+
+var registeredChips = map[int]*Chip{}
+
+// RegisterChip lets a chip be referred to by index in snapshots and packets.
+func RegisterChip(c *Chip) {
+	if _, ok := registeredChips[c.Index]; ok {
+		panic(fmt.Sprintf("chip %d already registered", c.Index))
+	}
+	registeredChips[c.Index] = c
+}
+
+func UnregisterChip(index int) {
+	delete(registeredChips, index)
+}
+
+func ChipByIndex(index int) (*Chip, bool) {
+	c, ok := registeredChips[index]
+	return c, ok
+}
+
+func ChipByName(name string) (*Chip, bool) {
+	for _, c := range RegisteredChips() {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func RegisteredChips() []*Chip {
+	chips := maps.Values(registeredChips)
+	slices.SortFunc(chips, func(a, b *Chip) bool {
+		return a.Index < b.Index
+	})
+	return chips
+}
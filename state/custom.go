@@ -0,0 +1,86 @@
+package state
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+const (
+	HandSize         = 5
+	MaxSelectedChips = 5
+
+	CustomGaugeTicks Ticks = 512
+)
+
+type CustomState struct {
+	// Folder is drawn from the front.
+	Folder Folder
+
+	// Chips that aren't picked stay in Hand for the next custom screen.
+	Hand []FolderChip
+
+	// Selected are indexes into Hand, in the order they were picked.
+	Selected []int
+
+	// Cursor is an index into Hand, or len(Hand) for the OK button.
+	Cursor int
+
+	Confirmed bool
+}
+
+func (cs CustomState) Clone() CustomState {
+	return CustomState{
+		slices.Clone(cs.Folder),
+		slices.Clone(cs.Hand),
+		slices.Clone(cs.Selected),
+		cs.Cursor,
+		cs.Confirmed,
+	}
+}
+
+func (cs *CustomState) IsSelected(i int) bool {
+	return slices.Contains(cs.Selected, i)
+}
+
+func (cs *CustomState) CanSelect(i int) bool {
+	if i < 0 || i >= len(cs.Hand) || cs.IsSelected(i) || len(cs.Selected) >= MaxSelectedChips {
+		return false
+	}
+	chips := make([]FolderChip, 0, len(cs.Selected)+1)
+	for _, j := range cs.Selected {
+		chips = append(chips, cs.Hand[j])
+	}
+	return CanPickTogether(append(chips, cs.Hand[i]))
+}
+
+// Draw doesn't shuffle the folder.
+func (cs *CustomState) Draw() {
+	n := HandSize - len(cs.Hand)
+	if n > len(cs.Folder) {
+		n = len(cs.Folder)
+	}
+	if n > 0 {
+		cs.Hand = append(cs.Hand, cs.Folder[:n]...)
+		cs.Folder = cs.Folder[n:]
+	}
+	cs.Selected = nil
+	cs.Cursor = 0
+	cs.Confirmed = false
+}
+
+// TakeSelected returns the picked chips in the order they were picked.
+func (cs *CustomState) TakeSelected() []*Chip {
+	selected := make([]*Chip, len(cs.Selected))
+	for i, j := range cs.Selected {
+		selected[i] = cs.Hand[j].Chip
+	}
+
+	var hand []FolderChip
+	for i, chip := range cs.Hand {
+		if !cs.IsSelected(i) {
+			hand = append(hand, chip)
+		}
+	}
+	cs.Hand = hand
+	cs.Selected = nil
+	return selected
+}
@@ -3,8 +3,7 @@ package state
 import (
 	"image"
 
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 )
 
 type DecorationID uint64
@@ -18,7 +17,7 @@ type Decoration struct {
 
 	IsFlipped bool
 
-	Type bundle.DecorationType
+	Type gamedata.DecorationType
 
 	TilePos TilePos
 	Offset  image.Point
@@ -47,29 +46,3 @@ func (d *Decoration) Clone() *Decoration {
 func (d *Decoration) Step() {
 	d.ElapsedTime++
 }
-
-func (d *Decoration) Appearance(b *bundle.Bundle) draw.Node {
-	if d.ElapsedTime < 0 {
-		return nil
-	}
-
-	rootNode := &draw.OptionsNode{}
-	x, y := d.TilePos.XY()
-
-	rootNode.Opts.GeoM.Translate(
-		float64((x-1)*TileRenderedWidth+TileRenderedWidth/2),
-		float64((y-1)*TileRenderedHeight+TileRenderedHeight/2),
-	)
-
-	spriteNode := &draw.OptionsNode{}
-	rootNode.Children = append(rootNode.Children, spriteNode)
-
-	sprite := b.DecorationSprites[d.Type]
-	spriteNode.Children = append(spriteNode.Children, draw.ImageWithAnimation(sprite.Image, sprite.Animation, int(d.ElapsedTime)))
-	spriteNode.Opts.GeoM.Translate(float64(d.Offset.X), float64(d.Offset.Y))
-	if d.IsFlipped {
-		spriteNode.Opts.GeoM.Scale(-1, 1)
-	}
-
-	return rootNode
-}
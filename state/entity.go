@@ -1,23 +1,11 @@
 package state
 
 import (
-	"flag"
-	"image"
-	"image/color"
-	"strconv"
-	"sync"
-
-	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/murkland/clone"
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"golang.org/x/exp/slices"
 )
 
-var (
-	debugDrawEntityMarker = flag.Bool("debug_draw_entity_markers", false, "draw entity markers")
-)
-
 type EntityTraits struct {
 	CanStepOnHoleLikeTiles bool
 	IgnoresTileEffects     bool
@@ -133,6 +121,7 @@ type Entity struct {
 
 	IsFlipped bool
 
+	// IsDead fighters are deleted by their behavior rather than removed.
 	IsDead bool
 
 	Element Element
@@ -156,9 +145,12 @@ type Entity struct {
 	HitResolution HitResolution
 	PerTickState  EntityPerTickState
 
+	// Chips are used last to first.
 	Chips         []*Chip
 	ChipUseQueued bool
 
+	Custom CustomState
+
 	DragLockoutTimeLeft    Ticks
 	ChipUseLockoutTimeLeft Ticks
 	RoadLockoutTimeLeft    Ticks
@@ -198,6 +190,7 @@ func (e *Entity) Clone() *Entity {
 		e.Emotion,
 		e.HitResolution, e.PerTickState,
 		slices.Clone(e.Chips), e.ChipUseQueued,
+		e.Custom.Clone(),
 		e.DragLockoutTimeLeft, e.ChipUseLockoutTimeLeft, e.RoadLockoutTimeLeft,
 		e.ChipPlaque,
 	}
@@ -229,7 +222,7 @@ func (e *Entity) UseChip(s *State) bool {
 	e.Emotion = EmotionNormal
 	if dmg.DoubleDamage {
 		s.AttachSound(&Sound{
-			Type: bundle.SoundTypeDoubleDamageConsumed,
+			Type: gamedata.SoundTypeDoubleDamageConsumed,
 		})
 	}
 
@@ -241,7 +234,7 @@ func (e *Entity) UseChip(s *State) bool {
 }
 
 func isOccupiedForMove(s *State, tilePos TilePos) bool {
-	for _, e := range s.Entities {
+	for _, e := range s.OrderedEntities() {
 		if e.TilePos != tilePos && e.FutureTilePos != tilePos {
 			continue
 		}
@@ -280,6 +273,7 @@ func (e *Entity) MoveDirectly(tilePos TilePos, s *State) bool {
 	}
 
 	e.TilePos = tilePos
+	e.FutureTilePos = tilePos
 	return true
 }
 
@@ -312,118 +306,11 @@ func (e *Entity) SetBehaviorImmediate(behavior EntityBehavior, s *State) {
 	e.BehaviorState.Behavior.Step(e, s)
 }
 
-var debugEntityMarkerImage *ebiten.Image
-var debugEntityMarkerImageOnce sync.Once
-
 func BehaviorIs[T EntityBehavior](behavior EntityBehavior) bool {
 	_, ok := behavior.(T)
 	return ok
 }
 
-func (e *Entity) Appearance(b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-	x, y := e.TilePos.XY()
-
-	dx, dy := e.ForcedMovementState.ForcedMovement.Direction.XY()
-	offset := (int(e.ForcedMovementState.ElapsedTime)+2+4)%4 - 2
-	dx *= offset
-	dy *= offset
-
-	rootNode.Opts.GeoM.Translate(
-		float64((x-1)*TileRenderedWidth+TileRenderedWidth/2+dx*TileRenderedWidth/4),
-		float64((y-1)*TileRenderedHeight+TileRenderedHeight/2+dy*TileRenderedHeight/4),
-	)
-
-	rootCharacterNode := &draw.OptionsNode{}
-	rootNode.Children = append(rootNode.Children, rootCharacterNode)
-
-	if e.IsFlipped {
-		rootCharacterNode.Opts.GeoM.Scale(-1, 1)
-	}
-
-	characterNode := &draw.OptionsNode{}
-	rootCharacterNode.Children = append(rootCharacterNode.Children, characterNode)
-
-	characterNode.Children = append(characterNode.Children, e.BehaviorState.Behavior.Appearance(e, b))
-
-	if e.Flashing.TimeLeft > 0 && (e.ElapsedTime/2)%2 == 0 {
-		characterNode.Opts.ColorM.Translate(0.0, 0.0, 0.0, -1.0)
-	}
-	if e.PerTickState.WasHit {
-		characterNode.Opts.ColorM.Translate(1.0, 1.0, 1.0, 0.0)
-	}
-	if e.Emotion == EmotionFullSynchro {
-		characterNode.Opts.ColorM.Translate(float64(0x29)/float64(0xff), float64(0x29)/float64(0xff), float64(0x29)/float64(0xff), 0.0)
-
-		fullSynchroNode := &draw.OptionsNode{Layer: 8}
-		fullSynchroNode.Children = append(fullSynchroNode.Children, draw.ImageWithAnimation(b.FullSynchroSprites.Image, b.FullSynchroSprites.Animations[0], int(e.ElapsedTime)))
-		rootCharacterNode.Children = append(rootCharacterNode.Children, fullSynchroNode)
-	} else if e.Emotion == EmotionAngry {
-		characterNode.Opts.ColorM.Translate(float64(0x80)/float64(0xff), float64(0)/float64(0xff), float64(0)/float64(0xff), 0.0)
-	}
-
-	if *debugDrawEntityMarker {
-		debugEntityMarkerImageOnce.Do(func() {
-			debugEntityMarkerImage = ebiten.NewImage(5, 5)
-			for x := 0; x < 5; x++ {
-				debugEntityMarkerImage.Set(x, 2, color.RGBA{255, 255, 255, 255})
-			}
-			for y := 0; y < 5; y++ {
-				debugEntityMarkerImage.Set(2, y, color.RGBA{255, 255, 255, 255})
-			}
-		})
-		debugEntityMarkerNode := &draw.OptionsNode{}
-		debugEntityMarkerNode.Children = append(debugEntityMarkerNode.Children, draw.ImageWithOrigin(debugEntityMarkerImage, image.Point{2, 2}))
-		debugEntityMarkerNode.Opts.ColorM.Scale(1.0, 0.0, 1.0, 0.5)
-		rootNode.Children = append(rootNode.Children, debugEntityMarkerNode)
-	}
-
-	if e.IsAlliedWithAnswerer {
-		if e.DisplayHP != 0 {
-			hpNode := &draw.OptionsNode{}
-			rootNode.Children = append(rootNode.Children, hpNode)
-
-			// Render HP.
-			hpText := strconv.Itoa(int(e.DisplayHP))
-			hpNode.Opts.GeoM.Translate(float64(0), float64(4))
-
-			for dx := -1; dx <= 1; dx++ {
-				for dy := -1; dy <= 1; dy++ {
-					strokeNode := &draw.OptionsNode{}
-					hpNode.Children = append(hpNode.Children, strokeNode)
-					strokeNode.Opts.GeoM.Translate(float64(dx), float64(dy))
-					strokeNode.Opts.ColorM.Scale(float64(0x31)/float64(0xFF), float64(0x39)/float64(0xFF), float64(0x52)/float64(0xFF), 1.0)
-					strokeNode.Children = append(strokeNode.Children, &draw.TextNode{Text: hpText, Face: b.TinyNumFont, Anchor: draw.TextAnchorCenter | draw.TextAnchorBottom})
-				}
-
-				fillNode := &draw.OptionsNode{}
-				hpNode.Children = append(hpNode.Children, fillNode)
-				if e.DisplayHP > e.HP {
-					fillNode.Opts.ColorM.Scale(float64(0xFF)/float64(0xFF), float64(0x84)/float64(0xFF), float64(0x5A)/float64(0xFF), 1.0)
-				} else if e.DisplayHP < e.HP {
-					fillNode.Opts.ColorM.Scale(float64(0x73)/float64(0xFF), float64(0xFF)/float64(0xFF), float64(0x4A)/float64(0xFF), 1.0)
-				}
-				fillNode.Children = append(fillNode.Children, &draw.TextNode{Text: hpText, Face: b.TinyNumFont, Anchor: draw.TextAnchorCenter | draw.TextAnchorBottom})
-			}
-		}
-	} else {
-		chipsNode := &draw.OptionsNode{}
-		chipsNode.Opts.GeoM.Translate(0, float64(-56))
-		rootNode.Children = append(rootNode.Children, chipsNode)
-
-		for i, chip := range e.Chips {
-			chipNode := &draw.OptionsNode{Layer: 8}
-			j := len(e.Chips) - i - 1
-			chipNode.Opts.GeoM.Translate(float64(-j*2), float64(-j*2))
-			chipsNode.Children = append(chipsNode.Children, chipNode)
-
-			chipNode.Children = append(chipNode.Children, draw.ImageWithFrame(b.ChipIconSprites.Image, b.ChipIconSprites.Animations[chip.Index].Frames[0]))
-		}
-	}
-
-	return rootNode
-}
-
 func (e *Entity) ApplyHit(h Hit) {
 	if h.Element.IsSuperEffectiveAgainst(e.Element) {
 		h.TotalDamage *= 2
@@ -487,13 +374,14 @@ func (e *Entity) Step(s *State) {
 		e.HP = 0
 	}
 
-	if e.HP == 0 && !e.Traits.Intangible {
+	// Dead entities are left for the match to delete.
+	if e.HP == 0 && !e.Traits.Intangible && !e.IsDead {
 		// TODO: This only gets destroyed on the next frame.
 		e.IsPendingDestruction = true
 
 		// TODO: Play sound
 		s.AttachDecoration(&Decoration{
-			Type:      bundle.DecorationTypeDeathExplosion,
+			Type:      gamedata.DecorationTypeDeathExplosion,
 			TilePos:   e.TilePos,
 			IsFlipped: e.IsFlipped,
 		})
@@ -514,7 +402,6 @@ func (e *Entity) Step(s *State) {
 
 type EntityBehavior interface {
 	clone.Cloner[EntityBehavior]
-	Appearance(e *Entity, b *bundle.Bundle) draw.Node
 	Traits(e *Entity) EntityBehaviorTraits
 	Step(e *Entity, s *State)
 	Cleanup(e *Entity, s *State)
@@ -2,8 +2,6 @@ package state
 
 import (
 	"github.com/murkland/clone"
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
 )
 
 type ColumnInfo struct {
@@ -68,7 +66,7 @@ func (f *Field) Flip() {
 }
 
 func isOccupiedForTileOwnerReturn(s *State, tilePos TilePos, isAlliedWithAnswerer bool) bool {
-	for _, e := range s.Entities {
+	for _, e := range s.OrderedEntities() {
 		if e.TilePos != tilePos {
 			continue
 		}
@@ -148,33 +146,3 @@ const (
 	TileRenderedWidth  = 40
 	TileRenderedHeight = 24
 )
-
-func (f *Field) Appearance(b *bundle.Bundle) draw.Node {
-	optsNode := &draw.OptionsNode{}
-	for i, tile := range f.Tiles {
-		x, y := TilePos(i).XY()
-		node := tile.Appearance(y, b)
-		if node == nil {
-			continue
-		}
-
-		childNode := &draw.OptionsNode{}
-		childNode.Opts.GeoM.Translate(float64((x-1)*TileRenderedWidth), float64((y-1)*TileRenderedHeight))
-		childNode.Children = append(childNode.Children, node)
-		optsNode.Children = append(optsNode.Children, childNode)
-	}
-
-	for x := 1; x < 7; x++ {
-		childNode := &draw.OptionsNode{}
-		childNode.Opts.GeoM.Translate(float64((x-1)*TileRenderedWidth), float64((4-1)*TileRenderedHeight))
-		frame := b.Battletiles.Info.Animations[len(b.Battletiles.Info.Animations)-1].Frames[0]
-		tiles := b.Battletiles.OffererTiles
-		if f.Tiles[TilePosXY(x, 3)].IsAlliedWithAnswerer {
-			tiles = b.Battletiles.AnswererTiles
-		}
-		childNode.Children = append(childNode.Children, draw.ImageWithFrame(tiles, frame))
-		optsNode.Children = append(optsNode.Children, childNode)
-	}
-
-	return optsNode
-}
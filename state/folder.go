@@ -0,0 +1,155 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+type FolderChip struct {
+	Chip *Chip
+	Code ChipCode
+}
+
+func (fc FolderChip) String() string {
+	return fmt.Sprintf("%s %s", fc.Chip.Name, fc.Code)
+}
+
+type Folder []FolderChip
+
+// Shuffle's src must be the state's RandSource, to keep it deterministic.
+func (f Folder) Shuffle(src rand.Source) {
+	rand.New(src).Shuffle(len(f), func(i, j int) {
+		f[i], f[j] = f[j], f[i]
+	})
+}
+
+func (f Folder) Chips() []*Chip {
+	chips := make([]*Chip, len(f))
+	for i, fc := range f {
+		chips[i] = fc.Chip
+	}
+	return chips
+}
+
+func (f Folder) MB() int {
+	mb := 0
+	for _, fc := range f {
+		mb += fc.Chip.MB
+	}
+	return mb
+}
+
+func (f Folder) String() string {
+	parts := make([]string, len(f))
+	for i, fc := range f {
+		parts[i] = fc.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseFolder parses e.g. "Cannon A,Sword *". The folder is not validated.
+func ParseFolder(s string) (Folder, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var f Folder
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Fields(part)
+		if len(fields) != 2 || len(fields[1]) != 1 {
+			return nil, fmt.Errorf("expected a chip name and a code, got %q", part)
+		}
+		chip, ok := ChipByName(fields[0])
+		if !ok {
+			return nil, fmt.Errorf("unknown chip %q", fields[0])
+		}
+		f = append(f, FolderChip{chip, ChipCode(fields[1][0])})
+	}
+	return f, nil
+}
+
+type FolderRules struct {
+	Size int
+
+	// MaxCopies applies regardless of code. There may only be one of each mega
+	// or giga chip.
+	MaxCopies int
+
+	MaxMegaChips int
+	MaxGigaChips int
+
+	// MaxMB keeps a folder from being made of nothing but the strongest chips.
+	MaxMB int
+}
+
+var DefaultFolderRules = FolderRules{
+	Size:         30,
+	MaxCopies:    4,
+	MaxMegaChips: 5,
+	MaxGigaChips: 1,
+	MaxMB:        1000,
+}
+
+var ErrIllegalFolder = errors.New("illegal folder")
+
+// Validate returns an error wrapping ErrIllegalFolder. Every chip must also
+// come in the code it has.
+func (f Folder) Validate(rules FolderRules) error {
+	if len(f) != rules.Size {
+		return fmt.Errorf("%w: %d chips, must be %d", ErrIllegalFolder, len(f), rules.Size)
+	}
+
+	copies := map[*Chip]int{}
+	numByClass := map[ChipClass]int{}
+	for _, fc := range f {
+		if !fc.Chip.HasCode(fc.Code) {
+			return fmt.Errorf("%w: %s does not come in code %s", ErrIllegalFolder, fc.Chip.Name, fc.Code)
+		}
+
+		copies[fc.Chip]++
+		maxCopies := rules.MaxCopies
+		if fc.Chip.Class != ChipClassStandard {
+			maxCopies = 1
+		}
+		if copies[fc.Chip] > maxCopies {
+			return fmt.Errorf("%w: more than %d copies of %s", ErrIllegalFolder, maxCopies, fc.Chip.Name)
+		}
+
+		numByClass[fc.Chip.Class]++
+	}
+
+	if n := numByClass[ChipClassMega]; n > rules.MaxMegaChips {
+		return fmt.Errorf("%w: %d mega chips, at most %d allowed", ErrIllegalFolder, n, rules.MaxMegaChips)
+	}
+	if n := numByClass[ChipClassGiga]; n > rules.MaxGigaChips {
+		return fmt.Errorf("%w: %d giga chips, at most %d allowed", ErrIllegalFolder, n, rules.MaxGigaChips)
+	}
+	if mb := f.MB(); mb > rules.MaxMB {
+		return fmt.Errorf("%w: takes up %d MB, at most %d allowed", ErrIllegalFolder, mb, rules.MaxMB)
+	}
+
+	return nil
+}
+
+// CanPickTogether returns true if the chips are all the same chip, or all have
+// the same code, with ChipCodeAny going with any code.
+func CanPickTogether(chips []FolderChip) bool {
+	sameChip := true
+	sameCode := true
+	code := ChipCodeAny
+	for _, fc := range chips {
+		if fc.Chip != chips[0].Chip {
+			sameChip = false
+		}
+		if fc.Code == ChipCodeAny {
+			continue
+		}
+		if code == ChipCodeAny {
+			code = fc.Code
+		} else if fc.Code != code {
+			sameCode = false
+		}
+	}
+	return sameChip || sameCode
+}
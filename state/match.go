@@ -0,0 +1,121 @@
+package state
+
+type MatchPhase int
+
+const (
+	// MatchPhaseIntro is the pause at the start of every round.
+	MatchPhaseIntro MatchPhase = 0
+
+	MatchPhaseBattle MatchPhase = 1
+
+	// MatchPhaseKO plays the deletion, with everything else frozen.
+	MatchPhaseKO MatchPhase = 2
+
+	MatchPhaseResult MatchPhase = 3
+
+	// MatchPhaseOver lasts until the players leave or agree to a rematch.
+	MatchPhaseOver MatchPhase = 4
+
+	// MatchPhaseCustom opens at the start of every round, and whenever a fighter
+	// ends the turn once the custom gauge is full.
+	MatchPhaseCustom MatchPhase = 5
+)
+
+// Match tracks the rounds of a best-of-N match.
+type Match struct {
+	BestOf int
+
+	// Round counts up from 1.
+	Round int
+
+	OffererWins  int
+	AnswererWins int
+
+	Phase            MatchPhase
+	PhaseElapsedTime Ticks
+
+	CustomGauge Ticks
+
+	OffererEntityID  EntityID
+	AnswererEntityID EntityID
+
+	// If both OffererKO and AnswererKO are set, the round is a draw.
+	OffererKO  bool
+	AnswererKO bool
+
+	OffererWantsRematch  bool
+	AnswererWantsRematch bool
+
+	// offererTemplate and answererTemplate are never modified, only copied back
+	// in at the start of each round.
+	offererTemplate  *Entity
+	answererTemplate *Entity
+}
+
+func (m *Match) Clone() *Match {
+	return &Match{
+		m.BestOf,
+		m.Round,
+		m.OffererWins, m.AnswererWins,
+		m.Phase, m.PhaseElapsedTime,
+		m.CustomGauge,
+		m.OffererEntityID, m.AnswererEntityID,
+		m.OffererKO, m.AnswererKO,
+		m.OffererWantsRematch, m.AnswererWantsRematch,
+		m.offererTemplate, m.answererTemplate,
+	}
+}
+
+func (m *Match) IsCustomGaugeFull() bool {
+	return m.CustomGauge >= CustomGaugeTicks
+}
+
+func (m *Match) WinsNeeded() int {
+	return m.BestOf/2 + 1
+}
+
+// IsDecided is also true once every round has been played.
+func (m *Match) IsDecided() bool {
+	return m.OffererWins >= m.WinsNeeded() || m.AnswererWins >= m.WinsNeeded() || m.Round >= m.BestOf
+}
+
+func (m *Match) SetPhase(phase MatchPhase) {
+	m.Phase = phase
+	m.PhaseElapsedTime = 0
+}
+
+// StartMatch must be called with both fighters attached. Each round starts
+// from the state they are in now.
+func (s *State) StartMatch(bestOf int, offererEntityID EntityID, answererEntityID EntityID) {
+	s.Match = &Match{
+		BestOf: bestOf,
+		Round:  1,
+
+		OffererEntityID:  offererEntityID,
+		AnswererEntityID: answererEntityID,
+
+		offererTemplate:  s.Entities[offererEntityID].Clone(),
+		answererTemplate: s.Entities[answererEntityID].Clone(),
+	}
+}
+
+// ResetRound puts the field and the fighters back as they were at the start of
+// the match, and removes everything else. The fighters keep their entity IDs.
+func (s *State) ResetRound() {
+	s.Field = newField()
+
+	s.Entities = map[EntityID]*Entity{}
+	for _, template := range []*Entity{s.Match.offererTemplate, s.Match.answererTemplate} {
+		e := template.Clone()
+		s.Entities[e.id] = e
+	}
+
+	s.Decorations = map[DecorationID]*Decoration{}
+	s.Sounds = map[SoundID]*Sound{}
+	s.Timestop = nil
+	s.CounterPlaqueTimeLeft = 0
+
+	s.Match.CustomGauge = 0
+	s.Match.OffererKO = false
+	s.Match.AnswererKO = false
+}
@@ -26,7 +26,7 @@ func FindNearestEntity(s *state.State, myEntityID state.EntityID, pos state.Tile
 	bestDist := state.TileCols
 
 	var targetID state.EntityID
-	for _, cand := range s.Entities {
+	for _, cand := range s.OrderedEntities() {
 		if cand.ID() == myEntityID || cand.IsAlliedWithAnswerer == isAlliedWithAnswerer {
 			continue
 		}
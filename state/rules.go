@@ -0,0 +1,57 @@
+package state
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// RulesVersion must be bumped whenever the simulation or the layout of the
+// state changes in a way that RulesHash can't see, e.g. the logic in a
+// behavior's Step or a new field in Match.
+const RulesVersion = 5
+
+// RulesHash hashes everything two peers must agree on to simulate identically.
+func RulesHash() [32]byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "rules version %d\n", RulesVersion)
+	fmt.Fprintf(h, "snapshot version %d\n", SnapshotVersion)
+	fmt.Fprintf(h, "field %dx%d\n", TileCols, TileRows)
+	fmt.Fprintf(h, "flash time %d\n", DefaultFlashTime)
+	fmt.Fprintf(h, "paralyze time %d\n", DefaultParalyzeTime)
+
+	names := make([]string, 0, len(registeredTypesByName))
+	for name := range registeredTypesByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "type %s %s\n", name, typeLayout(registeredTypesByName[name]))
+	}
+
+	fmt.Fprintf(h, "folder rules %+v\n", DefaultFolderRules)
+
+	for _, c := range RegisteredChips() {
+		fmt.Fprintf(h, "chip %d %q %d %q %d %d %d %q\n", c.Index, c.Name, c.BaseDamage, c.Codes, c.MB, c.Class, c.Element, c.BehaviorSpec)
+	}
+
+	var sum [32]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+func typeLayout(typ reflect.Type) string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return typ.String()
+	}
+	s := "{"
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		s += fmt.Sprintf("%s %s;", f.Name, f.Type)
+	}
+	return s + "}"
+}
@@ -0,0 +1,361 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"unsafe"
+
+	"github.com/murkland/syncrand"
+)
+
+// SnapshotVersion must be bumped whenever the snapshot encoding itself
+// changes. Changes to the layout of the state are guarded by RulesVersion,
+// which replays and spectating check through RulesHash.
+const SnapshotVersion = 1
+
+var snapshotMagic = [4]byte{'N', 'B', 'S', 'S'}
+
+var ErrSnapshotVersionMismatch = errors.New("snapshot version mismatch")
+
+var (
+	registeredTypesByName = map[string]reflect.Type{}
+	registeredNamesByType = map[reflect.Type]string{}
+)
+
+// RegisterType registers a type that may be stored in an interface field of
+// the state. The name is written into snapshots, so it must never change.
+func RegisterType(name string, v any) {
+	typ := reflect.TypeOf(v)
+	if _, ok := registeredTypesByName[name]; ok {
+		panic(fmt.Sprintf("type name %s already registered", name))
+	}
+	if _, ok := registeredNamesByType[typ]; ok {
+		panic(fmt.Sprintf("type %s already registered", typ))
+	}
+	registeredTypesByName[name] = typ
+	registeredNamesByType[typ] = name
+}
+
+func init() {
+	RegisterType("state.HoleTileBehavior", &HoleTileBehavior{})
+	RegisterType("state.BrokenTileBehavior", &BrokenTileBehavior{})
+	RegisterType("state.NormalTileBehavior", &NormalTileBehavior{})
+	RegisterType("state.CrackedTileBehavior", &CrackedTileBehavior{})
+	RegisterType("state.RoadTileBehavior", &RoadTileBehavior{})
+	RegisterType("state.IceTileBehavior", &IceTileBehavior{})
+}
+
+var chipPtrType = reflect.TypeOf((*Chip)(nil))
+
+func settable(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+type snapshotEncoder struct {
+	buf bytes.Buffer
+}
+
+func (enc *snapshotEncoder) writeUvarint(x uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	enc.buf.Write(buf[:binary.PutUvarint(buf[:], x)])
+}
+
+func (enc *snapshotEncoder) writeVarint(x int64) {
+	var buf [binary.MaxVarintLen64]byte
+	enc.buf.Write(buf[:binary.PutVarint(buf[:], x)])
+}
+
+func (enc *snapshotEncoder) writeString(s string) {
+	enc.writeUvarint(uint64(len(s)))
+	enc.buf.WriteString(s)
+}
+
+func (enc *snapshotEncoder) encode(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			enc.buf.WriteByte(1)
+		} else {
+			enc.buf.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.writeVarint(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.writeUvarint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		enc.writeUvarint(math.Float64bits(v.Float()))
+	case reflect.String:
+		enc.writeString(v.String())
+	case reflect.Func:
+		// Restored by whatever owns them, e.g. chips.
+	case reflect.Pointer:
+		if v.IsNil() {
+			enc.buf.WriteByte(0)
+			return nil
+		}
+		enc.buf.WriteByte(1)
+		switch v.Type() {
+		case syncrandSourceType:
+			enc.writeUvarint(uint64(settable(v).Interface().(*syncrand.Source).SeedOffset()))
+			return nil
+		case chipPtrType:
+			enc.writeVarint(int64(v.Elem().FieldByName("Index").Int()))
+			return nil
+		}
+		return enc.encode(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			enc.writeString("")
+			return nil
+		}
+		elem := v.Elem()
+		name, ok := registeredNamesByType[elem.Type()]
+		if !ok {
+			return fmt.Errorf("type %s is not registered", elem.Type())
+		}
+		enc.writeString(name)
+		return enc.encode(elem)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := enc.encode(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice {
+			enc.writeUvarint(uint64(v.Len()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := enc.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return lessChecksumKey(keys[i], keys[j])
+		})
+		enc.writeUvarint(uint64(len(keys)))
+		for _, k := range keys {
+			if err := enc.encode(k); err != nil {
+				return err
+			}
+			if err := enc.encode(v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode value of kind %s", v.Kind())
+	}
+	return nil
+}
+
+type snapshotDecoder struct {
+	r *bytes.Reader
+
+	randSourceOffset uint64
+}
+
+func (dec *snapshotDecoder) readString() (string, error) {
+	n, err := binary.ReadUvarint(dec.r)
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(dec.r.Len()) {
+		return "", fmt.Errorf("string too long: %d", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(dec.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func (dec *snapshotDecoder) decode(v reflect.Value) error {
+	v = settable(v)
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := dec.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		v.SetBool(b != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := binary.ReadVarint(dec.r)
+		if err != nil {
+			return err
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, err := binary.ReadUvarint(dec.r)
+		if err != nil {
+			return err
+		}
+		v.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := binary.ReadUvarint(dec.r)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(x))
+	case reflect.String:
+		s, err := dec.readString()
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Func:
+	case reflect.Pointer:
+		present, err := dec.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if present == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		switch v.Type() {
+		case syncrandSourceType:
+			// Rebuilt from the seed once the whole state has been decoded.
+			dec.randSourceOffset, err = binary.ReadUvarint(dec.r)
+			return err
+		case chipPtrType:
+			index, err := binary.ReadVarint(dec.r)
+			if err != nil {
+				return err
+			}
+			chip, ok := registeredChips[int(index)]
+			if !ok {
+				return fmt.Errorf("chip %d is not registered", index)
+			}
+			v.Set(reflect.ValueOf(chip))
+			return nil
+		}
+		p := reflect.New(v.Type().Elem())
+		if err := dec.decode(p.Elem()); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.Interface:
+		name, err := dec.readString()
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		typ, ok := registeredTypesByName[name]
+		if !ok {
+			return fmt.Errorf("type %s is not registered", name)
+		}
+		if !typ.AssignableTo(v.Type()) {
+			return fmt.Errorf("type %s is not assignable to %s", name, v.Type())
+		}
+		elem := reflect.New(typ).Elem()
+		if err := dec.decode(elem); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := dec.decode(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		n, err := binary.ReadUvarint(dec.r)
+		if err != nil {
+			return err
+		}
+		if n > uint64(dec.r.Len()) {
+			// Every element takes at least one byte.
+			return fmt.Errorf("slice too long: %d", n)
+		}
+		v.Set(reflect.MakeSlice(v.Type(), int(n), int(n)))
+		for i := 0; i < int(n); i++ {
+			if err := dec.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := dec.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		n, err := binary.ReadUvarint(dec.r)
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMap(v.Type())
+		for i := uint64(0); i < n; i++ {
+			k := reflect.New(v.Type().Key()).Elem()
+			if err := dec.decode(k); err != nil {
+				return err
+			}
+			e := reflect.New(v.Type().Elem()).Elem()
+			if err := dec.decode(e); err != nil {
+				return err
+			}
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("cannot decode value of kind %s", v.Kind())
+	}
+	return nil
+}
+
+func (s *State) MarshalBinary() ([]byte, error) {
+	var enc snapshotEncoder
+	enc.buf.Write(snapshotMagic[:])
+	enc.writeUvarint(SnapshotVersion)
+	if err := enc.encode(reflect.ValueOf(s).Elem()); err != nil {
+		return nil, err
+	}
+	return enc.buf.Bytes(), nil
+}
+
+func (s *State) UnmarshalBinary(data []byte) error {
+	dec := &snapshotDecoder{r: bytes.NewReader(data)}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(dec.r, magic[:]); err != nil {
+		return err
+	}
+	if magic != snapshotMagic {
+		return errors.New("not a snapshot")
+	}
+
+	version, err := binary.ReadUvarint(dec.r)
+	if err != nil {
+		return err
+	}
+	if version != SnapshotVersion {
+		return fmt.Errorf("%w: expected %d, got %d", ErrSnapshotVersionMismatch, SnapshotVersion, version)
+	}
+
+	var s2 State
+	if err := dec.decode(reflect.ValueOf(&s2).Elem()); err != nil {
+		return err
+	}
+
+	s2.RandSource = syncrand.NewSource(s2.RandSeed)
+	for i := uint64(0); i < dec.randSourceOffset; i++ {
+		s2.RandSource.Int63()
+	}
+
+	*s = s2
+	return nil
+}
@@ -1,10 +1,7 @@
 package state
 
 import (
-	"time"
-
-	"github.com/faiface/beep"
-	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/gamedata"
 )
 
 type SoundID uint64
@@ -13,7 +10,7 @@ type Sound struct {
 	id          SoundID
 	ElapsedTime Ticks
 
-	Type bundle.SoundType
+	Type gamedata.SoundType
 }
 
 func (s *Sound) ID() SoundID {
@@ -31,7 +28,3 @@ func (s *Sound) Clone() *Sound {
 func (s *Sound) Step() {
 	s.ElapsedTime++
 }
-
-func TicksToSampleOffset(sr beep.SampleRate, t Ticks) int {
-	return sr.N(time.Duration(t) * time.Second / 60)
-}
@@ -2,8 +2,7 @@ package state
 
 import (
 	"github.com/murkland/clone"
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/syncrand"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
@@ -14,6 +13,7 @@ type Ticks int
 type State struct {
 	ElapsedTime Ticks
 
+	RandSeed   []byte
 	RandSource *syncrand.Source
 
 	Field *Field
@@ -30,12 +30,16 @@ type State struct {
 	Timestop *Timestop
 
 	CounterPlaqueTimeLeft Ticks
+
+	// Match is nil in a sandbox, where nothing ends when HP runs out.
+	Match *Match
 }
 
-func New(randSource *syncrand.Source) *State {
+func New(randSeed []byte) *State {
 	field := newField()
 	return &State{
-		RandSource: randSource,
+		RandSeed:   randSeed,
+		RandSource: syncrand.NewSource(randSeed),
 
 		Field: field,
 
@@ -72,13 +76,14 @@ func (s *State) AttachSound(snd *Sound) {
 func (s *State) Clone() *State {
 	return &State{
 		s.ElapsedTime,
-		s.RandSource.Clone(),
+		s.RandSeed, s.RandSource.Clone(),
 		s.Field.Clone(),
 		clone.Map(s.Entities), s.nextEntityID,
 		clone.Map(s.Decorations), s.nextDecorationID,
 		clone.Map(s.Sounds), s.nextSoundID,
 		clone.ValuePointer(s.Timestop),
 		s.CounterPlaqueTimeLeft,
+		clone.ValuePointer(s.Match),
 	}
 }
 
@@ -92,71 +97,35 @@ func (s *State) Flip() {
 	}
 }
 
-const (
-	fieldOffsetTopFull = 87
-	fieldOffsetTop     = 72
-)
+// OrderedEntities returns all entities in the order they were attached in.
+// Simulation code must never range over the map directly.
+func (s *State) OrderedEntities() []*Entity {
+	entities := maps.Values(s.Entities)
+	slices.SortFunc(entities, func(a, b *Entity) bool {
+		return a.ID() < b.ID()
+	})
+	return entities
+}
 
-func (s *State) Appearance(b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-	rootNode.Opts.GeoM.Translate(0, fieldOffsetTop)
-	{
-		tilesNode := &draw.OptionsNode{}
-		tilesNode.Children = append(tilesNode.Children, s.Field.Appearance(b))
-		rootNode.Children = append(rootNode.Children, tilesNode)
-	}
-	{
-		entitiesNode := &draw.OptionsNode{}
-		entities := maps.Values(s.Entities)
-		slices.SortFunc(entities, func(a *Entity, b *Entity) bool {
-			x1, y1 := a.TilePos.XY()
-			x2, y2 := b.TilePos.XY()
-			if y1 != y2 {
-				return y1 < y2
-			}
-			if x1 != x2 {
-				return x1 < x2
-			}
-			return a.ID() > b.ID()
-		})
-		for _, entity := range entities {
-			node := entity.Appearance(b)
-			if node == nil {
-				continue
-			}
-			entitiesNode.Children = append(entitiesNode.Children, node)
-		}
-		rootNode.Children = append(rootNode.Children, entitiesNode)
-	}
-	{
-		decorationsNode := &draw.OptionsNode{}
-		decorations := maps.Values(s.Decorations)
-		slices.SortFunc(decorations, func(a *Decoration, b *Decoration) bool {
-			x1, y1 := a.TilePos.XY()
-			x2, y2 := b.TilePos.XY()
-			if y1 != y2 {
-				return y1 < y2
-			}
-			if x1 != x2 {
-				return x1 < x2
-			}
-			return a.ID() > b.ID()
-		})
-		for _, decoration := range decorations {
-			node := decoration.Appearance(b)
-			if node == nil {
-				continue
-			}
-			decorationsNode.Children = append(decorationsNode.Children, node)
-		}
-		rootNode.Children = append(rootNode.Children, decorationsNode)
-	}
-	return rootNode
+func (s *State) OrderedDecorations() []*Decoration {
+	decorations := maps.Values(s.Decorations)
+	slices.SortFunc(decorations, func(a, b *Decoration) bool {
+		return a.ID() < b.ID()
+	})
+	return decorations
+}
+
+func (s *State) OrderedSounds() []*Sound {
+	sounds := maps.Values(s.Sounds)
+	slices.SortFunc(sounds, func(a, b *Sound) bool {
+		return a.ID() < b.ID()
+	})
+	return sounds
 }
 
 func (s *State) EntitiesAt(pos TilePos) []*Entity {
 	var entities []*Entity
-	for _, e := range s.Entities {
+	for _, e := range s.OrderedEntities() {
 		if e.TilePos != pos {
 			continue
 		}
@@ -189,7 +158,7 @@ func (s *State) ApplyHit(owner *Entity, pos TilePos, h Hit) bool {
 
 		if h.CanCounter && target.BehaviorState.Behavior.Traits(target).CanBeCountered && target.BehaviorState.ElapsedTime < 15 {
 			s.AttachSound(&Sound{
-				Type: bundle.SoundTypeCounterHit,
+				Type: gamedata.SoundTypeCounterHit,
 			})
 			s.CounterPlaqueTimeLeft = 50
 			owner.Emotion = EmotionFullSynchro
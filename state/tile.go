@@ -1,10 +1,7 @@
 package state
 
 import (
-	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/murkland/clone"
-	"github.com/murkland/nbarena/bundle"
-	"github.com/murkland/nbarena/draw"
 )
 
 type TileBehaviorState struct {
@@ -86,23 +83,6 @@ func (t *Tile) OnLeave(e *Entity, s *State) {
 	t.BehaviorState.Behavior.OnLeave(t, e, s)
 }
 
-func (t *Tile) Appearance(y int, b *bundle.Bundle) draw.Node {
-	rootNode := &draw.OptionsNode{}
-	if t.BehaviorState.Behavior == nil {
-		return nil
-	}
-	tiles := b.Battletiles.OffererTiles
-	if t.IsAlliedWithAnswerer {
-		tiles = b.Battletiles.AnswererTiles
-	}
-	rootNode.Children = append(rootNode.Children, t.BehaviorState.Behavior.Appearance(t, y, b, tiles))
-	if t.IsHighlighted {
-		rootNode.Opts.ColorM.Translate(1.0, 1.0, 1.0, 1.0)
-		rootNode.Opts.ColorM.Scale(1.0, 1.0, 0.0, 1.0)
-	}
-	return rootNode
-}
-
 const TileRows = 5
 const TileCols = 8
 
@@ -126,7 +106,6 @@ func (p TilePos) Flipped() TilePos {
 
 type TileBehavior interface {
 	clone.Cloner[TileBehavior]
-	Appearance(t *Tile, y int, b *bundle.Bundle, tiles *ebiten.Image) draw.Node
 	CanEnter(t *Tile, e *Entity) bool
 	OnLeave(t *Tile, e *Entity, s *State)
 	Flip()
@@ -140,10 +119,6 @@ func (tb *HoleTileBehavior) Clone() TileBehavior {
 	return &HoleTileBehavior{}
 }
 
-func (tb *HoleTileBehavior) Appearance(t *Tile, y int, b *bundle.Bundle, tiles *ebiten.Image) draw.Node {
-	return nil
-}
-
 func (tb *HoleTileBehavior) CanEnter(t *Tile, e *Entity) bool {
 	return e.Traits.CanStepOnHoleLikeTiles
 }
@@ -159,10 +134,6 @@ func (tb *BrokenTileBehavior) Clone() TileBehavior {
 	return &BrokenTileBehavior{tb.returnToNormalTimeLeft}
 }
 
-func (tb *BrokenTileBehavior) Appearance(t *Tile, y int, b *bundle.Bundle, tiles *ebiten.Image) draw.Node {
-	return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[1*3+(y-1)], int(t.BehaviorState.ElapsedTime))
-}
-
 func (tb *BrokenTileBehavior) CanEnter(t *Tile, e *Entity) bool {
 	return e.Traits.CanStepOnHoleLikeTiles
 }
@@ -185,10 +156,6 @@ func (tb *NormalTileBehavior) Clone() TileBehavior {
 	return &NormalTileBehavior{}
 }
 
-func (tb *NormalTileBehavior) Appearance(t *Tile, y int, b *bundle.Bundle, tiles *ebiten.Image) draw.Node {
-	return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[2*3+(y-1)], int(t.BehaviorState.ElapsedTime))
-}
-
 func (tb *NormalTileBehavior) CanEnter(t *Tile, e *Entity) bool {
 	return true
 }
@@ -203,10 +170,6 @@ func (tb *CrackedTileBehavior) Clone() TileBehavior {
 	return &CrackedTileBehavior{}
 }
 
-func (tb *CrackedTileBehavior) Appearance(t *Tile, y int, b *bundle.Bundle, tiles *ebiten.Image) draw.Node {
-	return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[3*3+(y-1)], int(t.BehaviorState.ElapsedTime))
-}
-
 func (tb *CrackedTileBehavior) CanEnter(t *Tile, e *Entity) bool {
 	return true
 }
@@ -232,21 +195,6 @@ func (tb *RoadTileBehavior) Clone() TileBehavior {
 	return &RoadTileBehavior{tb.Direction}
 }
 
-func (tb *RoadTileBehavior) Appearance(t *Tile, y int, b *bundle.Bundle, tiles *ebiten.Image) draw.Node {
-	var offset int
-	switch tb.Direction {
-	case DirectionUp:
-		offset = 0
-	case DirectionDown:
-		offset = 1
-	case DirectionLeft:
-		offset = 2
-	case DirectionRight:
-		offset = 3
-	}
-	return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[(9+offset)*3+(y-1)], int(t.BehaviorState.ElapsedTime))
-}
-
 func (tb *RoadTileBehavior) Flip() {
 	tb.Direction = tb.Direction.FlipH()
 }
@@ -257,7 +205,7 @@ func (tb *RoadTileBehavior) CanEnter(t *Tile, e *Entity) bool {
 func (tb *RoadTileBehavior) OnLeave(t *Tile, e *Entity, s *State) {
 }
 func (tb *RoadTileBehavior) Step(t *Tile, s *State) {
-	for _, e := range s.Entities {
+	for _, e := range s.OrderedEntities() {
 		if e.TilePos != t.TilePos {
 			continue
 		}
@@ -287,10 +235,6 @@ func (tb *IceTileBehavior) Clone() TileBehavior {
 	return &IceTileBehavior{tb.direction}
 }
 
-func (tb *IceTileBehavior) Appearance(t *Tile, y int, b *bundle.Bundle, tiles *ebiten.Image) draw.Node {
-	return draw.ImageWithAnimation(tiles, b.Battletiles.Info.Animations[7*3+(y-1)], int(t.BehaviorState.ElapsedTime))
-}
-
 func (tb *IceTileBehavior) Flip() {
 }
 
@@ -301,7 +245,7 @@ func (tb *IceTileBehavior) OnLeave(t *Tile, e *Entity, s *State) {
 }
 func (tb *IceTileBehavior) Step(t *Tile, s *State) {
 	if tb.direction == DirectionNone {
-		for _, e := range s.Entities {
+		for _, e := range s.OrderedEntities() {
 			if e.FutureTilePos != t.TilePos || e.TilePos == e.FutureTilePos {
 				continue
 			}
@@ -314,7 +258,7 @@ func (tb *IceTileBehavior) Step(t *Tile, s *State) {
 		}
 	}
 
-	for _, e := range s.Entities {
+	for _, e := range s.OrderedEntities() {
 		if e.TilePos != t.TilePos {
 			continue
 		}
@@ -0,0 +1,86 @@
+package step
+
+import (
+	"github.com/murkland/nbarena/state"
+)
+
+// openCustom loses chips picked last time that haven't been used by now.
+func openCustom(s *state.State) {
+	m := s.Match
+	m.SetPhase(state.MatchPhaseCustom)
+	m.CustomGauge = 0
+
+	for _, e := range []*state.Entity{s.Entities[m.OffererEntityID], s.Entities[m.AnswererEntityID]} {
+		e.Chips = nil
+		e.ChipPlaque = state.ChipPlaque{}
+		e.Custom.Draw()
+	}
+}
+
+func closeCustom(s *state.State) {
+	m := s.Match
+	for _, e := range []*state.Entity{s.Entities[m.OffererEntityID], s.Entities[m.AnswererEntityID]} {
+		// Chips are used last to first, but were picked first to last.
+		selected := e.Custom.TakeSelected()
+		e.Chips = make([]*state.Chip, len(selected))
+		for i, chip := range selected {
+			e.Chips[len(selected)-1-i] = chip
+		}
+	}
+	m.SetPhase(state.MatchPhaseBattle)
+}
+
+func isPressed(e *state.Entity, pressed func(intent state.Intent) bool) bool {
+	return pressed(e.Intent) && !pressed(e.LastIntent)
+}
+
+// stepCustomScreen moves the cursor relative to the fighter, so that the
+// custom screen reads the same way for both sides. Charging the buster puts
+// back the last chip picked.
+func stepCustomScreen(e *state.Entity) {
+	cs := &e.Custom
+	if cs.Confirmed {
+		return
+	}
+
+	forward := e.Facing()
+	switch {
+	case isPressed(e, func(intent state.Intent) bool { return intent.Direction&forward != 0 }):
+		if cs.Cursor < len(cs.Hand) {
+			cs.Cursor++
+		}
+	case isPressed(e, func(intent state.Intent) bool { return intent.Direction&forward.FlipH() != 0 }):
+		if cs.Cursor > 0 {
+			cs.Cursor--
+		}
+	}
+
+	switch {
+	case isPressed(e, func(intent state.Intent) bool { return intent.Confirm }):
+		cs.Confirmed = true
+	case isPressed(e, func(intent state.Intent) bool { return intent.UseChip }):
+		if cs.Cursor == len(cs.Hand) {
+			cs.Confirmed = true
+		} else if cs.CanSelect(cs.Cursor) {
+			cs.Selected = append(cs.Selected, cs.Cursor)
+		}
+	case isPressed(e, func(intent state.Intent) bool { return intent.ChargeBasicWeapon }):
+		if len(cs.Selected) > 0 {
+			cs.Selected = cs.Selected[:len(cs.Selected)-1]
+		}
+	}
+}
+
+// wantsCustom is never true during a time stop.
+func wantsCustom(s *state.State) bool {
+	m := s.Match
+	if !m.IsCustomGaugeFull() || s.Timestop != nil {
+		return false
+	}
+	for _, e := range []*state.Entity{s.Entities[m.OffererEntityID], s.Entities[m.AnswererEntityID]} {
+		if isPressed(e, func(intent state.Intent) bool { return intent.EndTurn }) {
+			return true
+		}
+	}
+	return false
+}
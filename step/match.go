@@ -0,0 +1,104 @@
+package step
+
+import (
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/state"
+)
+
+const (
+	matchIntroTicks  = 90
+	matchKOTicks     = behaviors.DeletedTicks + 30
+	matchResultTicks = 150
+)
+
+func checkKO(s *state.State) {
+	m := s.Match
+	offerer := s.Entities[m.OffererEntityID]
+	answerer := s.Entities[m.AnswererEntityID]
+
+	m.OffererKO = offerer.HP <= 0
+	m.AnswererKO = answerer.HP <= 0
+	if !m.OffererKO && !m.AnswererKO {
+		return
+	}
+
+	switch {
+	case m.OffererKO && m.AnswererKO:
+		// Nobody wins a round where both sides go down together.
+	case m.AnswererKO:
+		m.OffererWins++
+	case m.OffererKO:
+		m.AnswererWins++
+	}
+
+	for _, e := range []*state.Entity{offerer, answerer} {
+		if e.HP > 0 {
+			continue
+		}
+		e.IsDead = true
+		e.SetBehaviorImmediate(&behaviors.Deleted{}, s)
+	}
+
+	m.SetPhase(state.MatchPhaseKO)
+}
+
+// stepMatch steps everything but the battle itself.
+func stepMatch(s *state.State) {
+	m := s.Match
+	m.PhaseElapsedTime++
+
+	offerer := s.Entities[m.OffererEntityID]
+	answerer := s.Entities[m.AnswererEntityID]
+
+	switch m.Phase {
+	case state.MatchPhaseIntro:
+		if m.PhaseElapsedTime >= matchIntroTicks {
+			// Every round reshuffles the folders as they were brought in.
+			offerer.Custom.Folder.Shuffle(s.RandSource)
+			answerer.Custom.Folder.Shuffle(s.RandSource)
+			openCustom(s)
+		}
+
+	case state.MatchPhaseCustom:
+		stepCustomScreen(offerer)
+		stepCustomScreen(answerer)
+		if offerer.Custom.Confirmed && answerer.Custom.Confirmed {
+			closeCustom(s)
+		}
+
+	case state.MatchPhaseKO:
+		// Only the deletion plays out.
+		for _, e := range []*state.Entity{offerer, answerer} {
+			if e.IsDead {
+				e.Step(s)
+			}
+		}
+		if m.PhaseElapsedTime >= matchKOTicks {
+			m.SetPhase(state.MatchPhaseResult)
+		}
+
+	case state.MatchPhaseResult:
+		if m.PhaseElapsedTime >= matchResultTicks {
+			if m.IsDecided() {
+				m.SetPhase(state.MatchPhaseOver)
+			} else {
+				m.Round++
+				s.ResetRound()
+				m.SetPhase(state.MatchPhaseIntro)
+				return
+			}
+		}
+
+	case state.MatchPhaseOver:
+		if offerer.Intent.Confirm && !offerer.LastIntent.Confirm {
+			m.OffererWantsRematch = true
+		}
+		if answerer.Intent.Confirm && !answerer.LastIntent.Confirm {
+			m.AnswererWantsRematch = true
+		}
+	}
+
+	// Go by rising edges, so that nothing held down goes off when battle starts.
+	offerer.LastIntent = offerer.Intent
+	answerer.LastIntent = answerer.Intent
+}
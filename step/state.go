@@ -4,10 +4,8 @@ import (
 	"math/rand"
 
 	"github.com/murkland/nbarena/behaviors"
-	"github.com/murkland/nbarena/bundle"
+	"github.com/murkland/nbarena/gamedata"
 	"github.com/murkland/nbarena/state"
-	"golang.org/x/exp/maps"
-	"golang.org/x/exp/slices"
 )
 
 func resolveOne(e *state.Entity, s *state.State) {
@@ -49,7 +47,7 @@ func resolveOne(e *state.Entity, s *state.State) {
 	if e.HitResolution.Damage > 0 {
 		e.PerTickState.WasHit = true
 		s.AttachSound(&state.Sound{
-			Type: bundle.SoundTypeOuch,
+			Type: gamedata.SoundTypeOuch,
 		})
 	}
 
@@ -218,7 +216,7 @@ func resolveSlideOrDrag(e *state.Entity, s *state.State) {
 	}
 }
 
-func Step(s *state.State, b *bundle.Bundle) {
+func Step(s *state.State, d *gamedata.Data) {
 	if s.Timestop != nil && s.Timestop.IsPendingDestruction {
 		s.Timestop = nil
 	}
@@ -229,7 +227,7 @@ func Step(s *state.State, b *bundle.Bundle) {
 		s.CounterPlaqueTimeLeft--
 	}
 
-	for _, e := range s.Entities {
+	for _, e := range s.OrderedEntities() {
 		e.PerTickState = state.EntityPerTickState{}
 
 		if e.RoadLockoutTimeLeft > 0 {
@@ -237,26 +235,39 @@ func Step(s *state.State, b *bundle.Bundle) {
 		}
 	}
 
-	for _, snd := range s.Sounds {
-		bbuf := b.Sounds[snd.Type]
-		if state.TicksToSampleOffset(bbuf.Format().SampleRate, snd.ElapsedTime) >= bbuf.Len() {
+	for _, snd := range s.OrderedSounds() {
+		if int(snd.ElapsedTime) >= d.SoundDurations[snd.Type] {
 			delete(s.Sounds, snd.ID())
 			continue
 		}
 		snd.Step()
 	}
 
-	for _, d := range s.Decorations {
-		if int(d.ElapsedTime) >= len(b.DecorationSprites[d.Type].Animation.Frames) {
-			delete(s.Decorations, d.ID())
+	for _, dec := range s.OrderedDecorations() {
+		if int(dec.ElapsedTime) >= d.DecorationDurations[dec.Type] {
+			delete(s.Decorations, dec.ID())
 			continue
 		}
 
-		if s.Timestop == nil || d.RunsInTimestop {
-			d.Step()
+		if s.Timestop == nil || dec.RunsInTimestop {
+			dec.Step()
 		}
 	}
 
+	if s.Match != nil && s.Match.Phase != state.MatchPhaseBattle {
+		stepMatch(s)
+		return
+	}
+
+	if s.Match != nil && wantsCustom(s) {
+		openCustom(s)
+		// The press that ended the turn mustn't count again on the custom screen.
+		for _, e := range []*state.Entity{s.Entities[s.Match.OffererEntityID], s.Entities[s.Match.AnswererEntityID]} {
+			e.LastIntent = e.Intent
+		}
+		return
+	}
+
 	if s.Timestop == nil {
 		s.Field.Step(s)
 	}
@@ -266,10 +277,7 @@ func Step(s *state.State, b *bundle.Bundle) {
 	}
 
 	// Step all entities in a random order.
-	pending := maps.Values(s.Entities)
-	slices.SortFunc(pending, func(a *state.Entity, b *state.Entity) bool {
-		return a.ID() < b.ID()
-	})
+	pending := s.OrderedEntities()
 	rand.New(s.RandSource).Shuffle(len(pending), func(i, j int) {
 		pending[i], pending[j] = pending[j], pending[i]
 	})
@@ -286,10 +294,7 @@ func Step(s *state.State, b *bundle.Bundle) {
 	}
 
 	// Resolve any hits.
-	pending = maps.Values(s.Entities)
-	slices.SortFunc(pending, func(a *state.Entity, b *state.Entity) bool {
-		return a.ID() < b.ID()
-	})
+	pending = s.OrderedEntities()
 	rand.New(s.RandSource).Shuffle(len(pending), func(i, j int) {
 		pending[i], pending[j] = pending[j], pending[i]
 	})
@@ -316,4 +321,10 @@ func Step(s *state.State, b *bundle.Bundle) {
 		}
 	}
 
+	if s.Match != nil {
+		if s.Timestop == nil && !s.Match.IsCustomGaugeFull() {
+			s.Match.CustomGauge++
+		}
+		checkKO(s)
+	}
 }
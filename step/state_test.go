@@ -0,0 +1,217 @@
+package step
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/murkland/nbarena/behaviors"
+	"github.com/murkland/nbarena/chips"
+	"github.com/murkland/nbarena/gamedata"
+	"github.com/murkland/nbarena/state"
+)
+
+var testData = gamedata.NewData()
+
+// newTestState crowds the field, so entities often share tiles or are
+// equidistant.
+func newTestState() (*state.State, []state.EntityID) {
+	s := state.New([]byte("determinism"))
+
+	s.Field.Tiles[state.TilePosXY(3, 1)].BehaviorState.Behavior = &state.IceTileBehavior{}
+	s.Field.Tiles[state.TilePosXY(4, 3)].BehaviorState.Behavior = &state.IceTileBehavior{}
+	s.Field.Tiles[state.TilePosXY(2, 3)].BehaviorState.Behavior = &state.RoadTileBehavior{Direction: state.DirectionUp}
+	s.Field.Tiles[state.TilePosXY(5, 1)].BehaviorState.Behavior = &state.RoadTileBehavior{Direction: state.DirectionDown}
+
+	var ids []state.EntityID
+	for _, pos := range []state.TilePos{
+		state.TilePosXY(2, 2), state.TilePosXY(5, 2),
+		state.TilePosXY(1, 1), state.TilePosXY(6, 1),
+		state.TilePosXY(3, 3), state.TilePosXY(4, 3),
+	} {
+		x, _ := pos.XY()
+		e := &state.Entity{
+			HP:        1000,
+			MaxHP:     1000,
+			DisplayHP: 1000,
+
+			Chips: []*state.Chip{
+				chips.Recov80, chips.AreaGrab, chips.Fan, chips.WindRack, chips.AirShot,
+				chips.WideSwrd, chips.Cannon, chips.Vulcan3, chips.LongSwrd, chips.SuprVulc,
+			},
+
+			PowerShotChargeTime: state.Ticks(50),
+
+			IsFlipped:            x >= state.TileCols/2,
+			IsAlliedWithAnswerer: x >= state.TileCols/2,
+
+			TilePos:       pos,
+			FutureTilePos: pos,
+
+			BehaviorState: state.EntityBehaviorState{
+				Behavior: &behaviors.Idle{},
+			},
+
+			Traits: state.EntityTraits{
+				ExtendsTileOwnership: true,
+			},
+		}
+		s.AttachEntity(e)
+		ids = append(ids, e.ID())
+	}
+
+	// Whether the first is pushed must not depend on which is looked at first.
+	for _, ignoresTileEffects := range []bool{false, true} {
+		e := &state.Entity{
+			HP:        100,
+			MaxHP:     100,
+			DisplayHP: 100,
+
+			TilePos:       state.TilePosXY(2, 3),
+			FutureTilePos: state.TilePosXY(2, 3),
+
+			BehaviorState: state.EntityBehaviorState{
+				Behavior: &behaviors.Idle{},
+			},
+
+			Traits: state.EntityTraits{
+				IgnoresTileEffects: ignoresTileEffects,
+			},
+		}
+		s.AttachEntity(e)
+	}
+
+	return s, ids
+}
+
+func testIntent(tick int, salt int) state.Intent {
+	return state.Intent{
+		Direction:         []state.Direction{state.DirectionNone, state.DirectionUp, state.DirectionLeft, state.DirectionDown, state.DirectionRight}[(tick*7/23+salt)%5],
+		UseChip:           (tick+salt*17)%53 < 2,
+		ChargeBasicWeapon: (tick+salt)%70 < 55,
+	}
+}
+
+// Go randomizes map iteration order on every range.
+func TestStepIsIndependentOfMapOrder(t *testing.T) {
+	const runs = 20
+	const ticks = 1200
+
+	var want []uint64
+	for run := 0; run < runs; run++ {
+		s, ids := newTestState()
+		for tick := 1; tick <= ticks; tick++ {
+			for i, id := range ids {
+				if e, ok := s.Entities[id]; ok {
+					e.Intent = testIntent(tick, i)
+				}
+			}
+			Step(s, testData)
+
+			checksum := s.Checksum()
+			if run == 0 {
+				want = append(want, checksum)
+				continue
+			}
+			if checksum != want[tick-1] {
+				t.Fatalf("run %d diverged at tick %d: got checksum %016x, want %016x", run, tick, checksum, want[tick-1])
+			}
+		}
+	}
+}
+
+func TestProjectilesTrackFutureTilePos(t *testing.T) {
+	s, ids := newTestState()
+	fighters := map[state.EntityID]bool{}
+	for _, id := range ids {
+		fighters[id] = true
+	}
+
+	moved := map[string]bool{}
+	spawnedAt := map[state.EntityID]state.TilePos{}
+	for tick := 1; tick <= 1200; tick++ {
+		for i, id := range ids {
+			if e, ok := s.Entities[id]; ok {
+				e.Intent = testIntent(tick, i)
+			}
+		}
+		Step(s, testData)
+
+		for _, e := range s.OrderedEntities() {
+			if fighters[e.ID()] {
+				continue
+			}
+			kind := fmt.Sprintf("%T", e.BehaviorState.Behavior)
+			if _, ok := spawnedAt[e.ID()]; !ok {
+				spawnedAt[e.ID()] = e.TilePos
+			}
+			if e.TilePos != spawnedAt[e.ID()] {
+				moved[kind] = true
+			}
+			if e.FutureTilePos != e.TilePos {
+				t.Fatalf("%s at %d is headed to %d at tick %d", kind, e.TilePos, e.FutureTilePos, tick)
+			}
+		}
+	}
+
+	for _, kind := range []string{"*behaviors.Shot", "*behaviors.vulcanShot", "*behaviors.Gust"} {
+		if !moved[kind] {
+			t.Errorf("never saw a %s move", kind)
+		}
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	// Keep decorations around long enough to be snapshotted.
+	d := gamedata.NewData()
+	for typ := gamedata.DecorationTypeDeathExplosion; typ <= gamedata.DecorationTypeRecov; typ++ {
+		d.DecorationDurations[typ] = 20
+	}
+
+	stepWithIntents := func(s *state.State, ids []state.EntityID, tick int) {
+		for i, id := range ids {
+			if e, ok := s.Entities[id]; ok {
+				e.Intent = testIntent(tick, i)
+			}
+		}
+		Step(s, d)
+	}
+
+	s, ids := newTestState()
+	tick := 1
+	for ; ; tick++ {
+		if tick > 2000 {
+			t.Fatalf("never had chips and decorations in flight at once")
+		}
+		stepWithIntents(s, ids, tick)
+
+		chipInUse := false
+		for _, e := range s.Entities {
+			if _, ok := e.BehaviorState.Behavior.(*behaviors.Idle); !ok {
+				chipInUse = true
+			}
+		}
+		if chipInUse && len(s.Decorations) > 0 && len(s.Entities) > len(ids)+2 {
+			break
+		}
+	}
+
+	buf, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %s", err)
+	}
+	var restored state.State
+	if err := restored.UnmarshalBinary(buf); err != nil {
+		t.Fatalf("UnmarshalBinary: %s", err)
+	}
+	if restored.Checksum() != s.Checksum() {
+		t.Fatalf("restored state differs at tick %d", tick)
+	}
+
+	for i := 1; i <= 300; i++ {
+		stepWithIntents(s, ids, tick+i)
+		stepWithIntents(&restored, ids, tick+i)
+		if restored.Checksum() != s.Checksum() {
+			t.Fatalf("restored state diverged %d ticks after tick %d", i, tick)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+// Package datachannel is separate from transport so that the rest of the
+// networking code doesn't need to link against WebRTC.
+package datachannel
+
+import (
+	"github.com/murkland/ctxwebrtc"
+	"github.com/murkland/nbarena/transport"
+	"github.com/pion/webrtc/v3"
+)
+
+var _ transport.Transport = (*ctxwebrtc.DataChannel)(nil)
+
+func Wrap(dc *webrtc.DataChannel) transport.Transport {
+	return ctxwebrtc.WrapDataChannel(dc)
+}
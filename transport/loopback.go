@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"context"
+	"sync"
+)
+
+const loopbackBufferSize = 1024
+
+type loopbackPipe struct {
+	ch        chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newLoopbackPipe() *loopbackPipe {
+	return &loopbackPipe{
+		ch:     make(chan []byte, loopbackBufferSize),
+		closed: make(chan struct{}),
+	}
+}
+
+func (p *loopbackPipe) close() {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+}
+
+type Loopback struct {
+	in  *loopbackPipe
+	out *loopbackPipe
+}
+
+func NewLoopbackPair() (*Loopback, *Loopback) {
+	a := newLoopbackPipe()
+	b := newLoopbackPipe()
+	return &Loopback{in: a, out: b}, &Loopback{in: b, out: a}
+}
+
+func (t *Loopback) Send(ctx context.Context, buf []byte) error {
+	buf = append([]byte(nil), buf...)
+	// Check closing first, or the select below would pick at random.
+	select {
+	case <-t.out.closed:
+		return ErrClosed
+	case <-t.in.closed:
+		return ErrClosed
+	default:
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.out.closed:
+		return ErrClosed
+	case <-t.in.closed:
+		return ErrClosed
+	case t.out.ch <- buf:
+		return nil
+	}
+}
+
+func (t *Loopback) Recv(ctx context.Context) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case buf := <-t.in.ch:
+		return buf, nil
+	case <-t.in.closed:
+		return nil, ErrClosed
+	}
+}
+
+func (t *Loopback) Close() error {
+	t.in.close()
+	t.out.close()
+	return nil
+}
@@ -0,0 +1,56 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+var ErrClosed = errors.New("transport closed")
+
+type Transport interface {
+	Send(ctx context.Context, buf []byte) error
+	Recv(ctx context.Context) ([]byte, error)
+	Close() error
+}
+
+// withContext makes f return early if ctx is done, by tripping its deadline.
+func withContext(ctx context.Context, setDeadline func(t time.Time) error, f func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	if err := setDeadline(deadline); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			setDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	err := f()
+	// Don't trip the deadline of whatever runs next.
+	close(done)
+	<-stopped
+
+	if err != nil {
+		// The deadline may go off before the context notices.
+		if hasDeadline && errors.Is(err, os.ErrDeadlineExceeded) {
+			<-ctx.Done()
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	return nil
+}
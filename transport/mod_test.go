@@ -0,0 +1,240 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// slowConn makes every read of a frame partial.
+type slowConn struct {
+	net.Conn
+}
+
+func (c slowConn) Read(buf []byte) (int, error) {
+	if len(buf) > 1 {
+		buf = buf[:1]
+	}
+	return c.Conn.Read(buf)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	a, b := net.Pipe()
+	sa := NewStream(a)
+	sb := NewStream(slowConn{b})
+	defer sa.Close()
+	defer sb.Close()
+
+	ctx := context.Background()
+	want := [][]byte{[]byte("hello"), {}, bytes.Repeat([]byte{0xab}, 1000)}
+	go func() {
+		for _, buf := range want {
+			if err := sa.Send(ctx, buf); err != nil {
+				t.Errorf("Send: %s", err)
+				return
+			}
+		}
+	}()
+
+	for _, w := range want {
+		got, err := sb.Recv(ctx)
+		if err != nil {
+			t.Fatalf("Recv: %s", err)
+		}
+		if !bytes.Equal(got, w) {
+			t.Errorf("expected %d bytes, got %d", len(w), len(got))
+		}
+	}
+}
+
+func TestStreamRejectsOversizeFrame(t *testing.T) {
+	a, b := net.Pipe()
+	s := NewStream(b)
+	defer a.Close()
+	defer s.Close()
+
+	go func() {
+		var header [4]byte
+		binary.LittleEndian.PutUint32(header[:], maxStreamPacketSize+1)
+		a.Write(header[:])
+	}()
+
+	if _, err := s.Recv(context.Background()); err == nil {
+		t.Errorf("expected a frame over %d bytes to be rejected", maxStreamPacketSize)
+	}
+}
+
+func TestStreamRecvContextDone(t *testing.T) {
+	a, b := net.Pipe()
+	s := NewStream(b)
+	defer a.Close()
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Recv(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := s.Recv(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestTCPAcceptDial(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	accepted := make(chan *Stream, 1)
+	go func() {
+		s, err := AcceptTCP(ctx, addr)
+		if err != nil {
+			t.Errorf("AcceptTCP: %s", err)
+		}
+		accepted <- s
+	}()
+
+	// AcceptTCP may not be listening yet.
+	var dialed *Stream
+	for dialed == nil {
+		if dialed, err = DialTCP(ctx, addr); err != nil {
+			if ctx.Err() != nil {
+				t.Fatalf("DialTCP: %s", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	defer dialed.Close()
+	s := <-accepted
+	if s == nil {
+		t.FailNow()
+	}
+	defer s.Close()
+
+	if err := dialed.Send(ctx, []byte("ping")); err != nil {
+		t.Fatalf("Send: %s", err)
+	}
+	if buf, err := s.Recv(ctx); err != nil || string(buf) != "ping" {
+		t.Errorf("expected ping, got %q (%v)", buf, err)
+	}
+}
+
+func acceptDialUDP(t *testing.T, ctx context.Context) (*UDP, *UDP) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close()
+
+	accepted := make(chan *UDP, 1)
+	go func() {
+		u, err := AcceptUDP(ctx, addr)
+		if err != nil {
+			t.Errorf("AcceptUDP: %s", err)
+		}
+		accepted <- u
+	}()
+
+	dialed, err := DialUDP(ctx, addr)
+	if err != nil {
+		t.Fatalf("DialUDP: %s", err)
+	}
+	u := <-accepted
+	if u == nil {
+		dialed.Close()
+		t.FailNow()
+	}
+	return u, dialed
+}
+
+func TestUDPAcceptDial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	accepted, dialed := acceptDialUDP(t, ctx)
+	defer accepted.Close()
+	defer dialed.Close()
+
+	if err := dialed.Send(ctx, []byte("ping")); err != nil {
+		t.Fatalf("Send: %s", err)
+	}
+	if buf, err := accepted.Recv(ctx); err != nil || string(buf) != "ping" {
+		t.Errorf("expected ping, got %q (%v)", buf, err)
+	}
+	if err := accepted.Send(ctx, []byte("pong")); err != nil {
+		t.Fatalf("Send: %s", err)
+	}
+	if buf, err := dialed.Recv(ctx); err != nil || string(buf) != "pong" {
+		t.Errorf("expected pong, got %q (%v)", buf, err)
+	}
+}
+
+func TestUDPDialKeepsFirstPacket(t *testing.T) {
+	// As if the empty reply to the handshake had been lost.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, maxUDPPacketSize)
+		_, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		conn.WriteTo([]byte("first"), from)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	dialed, err := DialUDP(ctx, conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("DialUDP: %s", err)
+	}
+	defer dialed.Close()
+
+	if buf, err := dialed.Recv(ctx); err != nil || string(buf) != "first" {
+		t.Errorf("expected the first packet to be kept, got %q (%v)", buf, err)
+	}
+}
+
+func TestLoopbackCloseUnblocksRecv(t *testing.T) {
+	a, b := NewLoopbackPair()
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := b.Recv(context.Background())
+		errs <- err
+	}()
+
+	a.Close()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Recv still blocked after Close")
+	}
+
+	if err := b.Send(context.Background(), []byte("x")); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed from Send, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package transport
+
+import (
+	"container/heap"
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type Conditions struct {
+	Latency time.Duration
+	// Jitter is added to or subtracted from Latency, uniformly.
+	Jitter time.Duration
+
+	LossRate      float64
+	DuplicateRate float64
+	// ReorderRate is the probability that a packet may overtake earlier ones
+	// if its jitter would let it. Otherwise, packets are delivered in order.
+	ReorderRate float64
+
+	Seed int64
+}
+
+func (c Conditions) IsZero() bool {
+	return c.Latency == 0 && c.Jitter == 0 && c.LossRate == 0 && c.DuplicateRate == 0 && c.ReorderRate == 0
+}
+
+type scheduledPacket struct {
+	deliverAt time.Time
+	seq       uint64
+	buf       []byte
+}
+
+type scheduledPacketHeap []scheduledPacket
+
+func (h scheduledPacketHeap) Len() int { return len(h) }
+
+func (h scheduledPacketHeap) Less(i, j int) bool {
+	if !h[i].deliverAt.Equal(h[j].deliverAt) {
+		return h[i].deliverAt.Before(h[j].deliverAt)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h scheduledPacketHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *scheduledPacketHeap) Push(x any) { *h = append(*h, x.(scheduledPacket)) }
+
+func (h *scheduledPacketHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// Simulated only degrades what is sent through it, so wrap both ends to
+// degrade both directions.
+type Simulated struct {
+	underlying Transport
+	conditions Conditions
+
+	mu            sync.Mutex
+	rand          *rand.Rand
+	pending       scheduledPacketHeap
+	nextSeq       uint64
+	lastDeliverAt time.Time
+	err           error
+
+	wake   chan struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+func Simulate(underlying Transport, conditions Conditions) *Simulated {
+	t := &Simulated{
+		underlying: underlying,
+		conditions: conditions,
+		rand:       rand.New(rand.NewSource(conditions.Seed)),
+		wake:       make(chan struct{}, 1),
+		closed:     make(chan struct{}),
+	}
+	go t.deliver()
+	return t
+}
+
+func (t *Simulated) delay() time.Duration {
+	d := t.conditions.Latency
+	if t.conditions.Jitter > 0 {
+		d += time.Duration(t.rand.Int63n(int64(2*t.conditions.Jitter)+1)) - t.conditions.Jitter
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+func (t *Simulated) schedule(now time.Time, buf []byte) {
+	deliverAt := now.Add(t.delay())
+	if t.rand.Float64() >= t.conditions.ReorderRate && deliverAt.Before(t.lastDeliverAt) {
+		deliverAt = t.lastDeliverAt
+	}
+	if deliverAt.After(t.lastDeliverAt) {
+		t.lastDeliverAt = deliverAt
+	}
+	heap.Push(&t.pending, scheduledPacket{deliverAt, t.nextSeq, buf})
+	t.nextSeq++
+}
+
+// Send returns immediately, so errors from the underlying transport are
+// reported by later calls.
+func (t *Simulated) Send(ctx context.Context, buf []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return t.err
+	}
+
+	if t.rand.Float64() < t.conditions.LossRate {
+		return nil
+	}
+
+	buf = append([]byte(nil), buf...)
+	now := time.Now()
+	t.schedule(now, buf)
+	if t.rand.Float64() < t.conditions.DuplicateRate {
+		t.schedule(now, buf)
+	}
+
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (t *Simulated) deliver() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		t.mu.Lock()
+		var next *scheduledPacket
+		if len(t.pending) > 0 {
+			next = &t.pending[0]
+		}
+		var wait time.Duration
+		if next != nil {
+			wait = time.Until(next.deliverAt)
+		}
+		if next != nil && wait <= 0 {
+			p := heap.Pop(&t.pending).(scheduledPacket)
+			t.mu.Unlock()
+
+			if err := t.underlying.Send(context.Background(), p.buf); err != nil {
+				t.mu.Lock()
+				if t.err == nil {
+					t.err = err
+				}
+				t.mu.Unlock()
+			}
+			continue
+		}
+		t.mu.Unlock()
+
+		if next == nil {
+			wait = time.Hour
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-t.closed:
+			return
+		case <-t.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *Simulated) Recv(ctx context.Context) ([]byte, error) {
+	return t.underlying.Recv(ctx)
+}
+
+func (t *Simulated) Close() error {
+	t.once.Do(func() {
+		close(t.closed)
+	})
+	return t.underlying.Close()
+}
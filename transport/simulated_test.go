@@ -0,0 +1,133 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+type arrival struct {
+	seq    uint32
+	sentAt time.Time
+	recvAt time.Time
+}
+
+func sendAndCollect(t *testing.T, conditions Conditions, n int) []arrival {
+	t.Helper()
+
+	a, b := NewLoopbackPair()
+	sim := Simulate(a, conditions)
+	defer sim.Close()
+
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 12)
+		binary.LittleEndian.PutUint32(buf, uint32(i))
+		binary.LittleEndian.PutUint64(buf[4:], uint64(time.Now().UnixNano()))
+		if err := sim.Send(context.Background(), buf); err != nil {
+			t.Fatalf("Send: %s", err)
+		}
+	}
+
+	var arrivals []arrival
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), conditions.Latency+conditions.Jitter+100*time.Millisecond)
+		buf, err := b.Recv(ctx)
+		cancel()
+		if err != nil {
+			return arrivals
+		}
+		arrivals = append(arrivals, arrival{
+			seq:    binary.LittleEndian.Uint32(buf),
+			sentAt: time.Unix(0, int64(binary.LittleEndian.Uint64(buf[4:]))),
+			recvAt: time.Now(),
+		})
+	}
+}
+
+func expectRate(t *testing.T, what string, got float64, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 0.05 {
+		t.Errorf("expected %s rate of about %.2f, got %.3f", what, want, got)
+	}
+}
+
+func TestSimulatedLossAndDuplication(t *testing.T) {
+	const n = 2000
+	arrivals := sendAndCollect(t, Conditions{
+		Latency:       time.Millisecond,
+		LossRate:      0.2,
+		DuplicateRate: 0.1,
+		Seed:          1,
+	}, n)
+
+	copies := map[uint32]int{}
+	for _, a := range arrivals {
+		copies[a.seq]++
+	}
+	duplicated := 0
+	for _, c := range copies {
+		if c > 2 {
+			t.Fatalf("expected at most 2 copies of a packet, got %d", c)
+		}
+		if c == 2 {
+			duplicated++
+		}
+	}
+	expectRate(t, "loss", 1-float64(len(copies))/n, 0.2)
+	expectRate(t, "duplication", float64(duplicated)/float64(len(copies)), 0.1)
+}
+
+func overtakers(arrivals []arrival) int {
+	n := 0
+	minLater := uint32(math.MaxUint32)
+	for i := len(arrivals) - 1; i >= 0; i-- {
+		if minLater < arrivals[i].seq {
+			n++
+		}
+		if arrivals[i].seq < minLater {
+			minLater = arrivals[i].seq
+		}
+	}
+	return n
+}
+
+func TestSimulatedReordering(t *testing.T) {
+	const n = 1000
+	conditions := Conditions{
+		Latency: 60 * time.Millisecond,
+		Jitter:  40 * time.Millisecond,
+		Seed:    2,
+	}
+
+	if arrivals := sendAndCollect(t, conditions, n); overtakers(arrivals) != 0 {
+		t.Errorf("expected packets to stay in order without reordering, %d overtook others", overtakers(arrivals))
+	}
+
+	// Sent all at once, nearly every packet's jitter would let it overtake.
+	conditions.ReorderRate = 0.3
+	arrivals := sendAndCollect(t, conditions, n)
+	if len(arrivals) != n {
+		t.Fatalf("expected all %d packets to arrive, got %d", n, len(arrivals))
+	}
+	expectRate(t, "reorder", float64(overtakers(arrivals))/n, 0.3)
+}
+
+func TestSimulatedDelay(t *testing.T) {
+	conditions := Conditions{
+		Latency:     80 * time.Millisecond,
+		Jitter:      50 * time.Millisecond,
+		ReorderRate: 0.5,
+		Seed:        3,
+	}
+	// Packets may arrive late, but never early.
+	const leeway = 25 * time.Millisecond
+
+	for _, a := range sendAndCollect(t, conditions, 200) {
+		delay := a.recvAt.Sub(a.sentAt)
+		if delay < conditions.Latency-conditions.Jitter || delay > conditions.Latency+conditions.Jitter+leeway {
+			t.Errorf("packet %d took %s, expected %s±%s", a.seq, delay, conditions.Latency, conditions.Jitter)
+		}
+	}
+}
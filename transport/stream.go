@@ -0,0 +1,106 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+const maxStreamPacketSize = 1 << 20
+
+// Stream prefixes each packet with its length. If the context passed to Send
+// or Recv is done partway through a packet, the stream is no longer usable.
+type Stream struct {
+	conn net.Conn
+
+	r   *bufio.Reader
+	rMu sync.Mutex
+
+	wMu sync.Mutex
+}
+
+func NewStream(conn net.Conn) *Stream {
+	return &Stream{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func DialTCP(ctx context.Context, addr string) (*Stream, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewStream(conn), nil
+}
+
+func AcceptTCP(ctx context.Context, addr string) (*Stream, error) {
+	var lc net.ListenConfig
+	lis, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer lis.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			lis.Close()
+		case <-done:
+		}
+	}()
+
+	conn, err := lis.Accept()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	return NewStream(conn), nil
+}
+
+func (t *Stream) Send(ctx context.Context, buf []byte) error {
+	t.wMu.Lock()
+	defer t.wMu.Unlock()
+
+	frame := make([]byte, 4+len(buf))
+	binary.LittleEndian.PutUint32(frame, uint32(len(buf)))
+	copy(frame[4:], buf)
+
+	return withContext(ctx, t.conn.SetWriteDeadline, func() error {
+		_, err := t.conn.Write(frame)
+		return err
+	})
+}
+
+func (t *Stream) Recv(ctx context.Context) ([]byte, error) {
+	t.rMu.Lock()
+	defer t.rMu.Unlock()
+
+	var buf []byte
+	if err := withContext(ctx, t.conn.SetReadDeadline, func() error {
+		var header [4]byte
+		if _, err := io.ReadFull(t.r, header[:]); err != nil {
+			return err
+		}
+		n := binary.LittleEndian.Uint32(header[:])
+		if n > maxStreamPacketSize {
+			return fmt.Errorf("packet too large: %d bytes", n)
+		}
+		buf = make([]byte, n)
+		_, err := io.ReadFull(t.r, buf)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (t *Stream) Close() error {
+	return t.conn.Close()
+}
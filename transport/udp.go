@@ -0,0 +1,128 @@
+package transport
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	maxUDPPacketSize = 65535
+	udpHelloInterval = 100 * time.Millisecond
+)
+
+// UDP is unreliable and unordered. Zero-length datagrams are used for the
+// handshake and are never delivered to Recv.
+type UDP struct {
+	conn net.PacketConn
+	peer net.Addr
+
+	rMu     sync.Mutex
+	buf     []byte
+	pending []byte
+}
+
+func DialUDP(ctx context.Context, addr string) (*UDP, error) {
+	peer, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &UDP{conn: conn, peer: peer, buf: make([]byte, maxUDPPacketSize)}
+	for {
+		if _, err := conn.WriteTo(nil, peer); err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		helloCtx, cancel := context.WithTimeout(ctx, udpHelloInterval)
+		n, from, err := t.readFrom(helloCtx)
+		cancel()
+		if err == nil && from.String() == peer.String() {
+			// The reply to the handshake may have been lost.
+			if n != 0 {
+				t.pending = append([]byte(nil), t.buf[:n]...)
+			}
+			return t, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			conn.Close()
+			return nil, ctxErr
+		}
+	}
+}
+
+func AcceptUDP(ctx context.Context, addr string) (*UDP, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &UDP{conn: conn, buf: make([]byte, maxUDPPacketSize)}
+	n, from, err := t.readFrom(ctx)
+	for err == nil && n != 0 {
+		n, from, err = t.readFrom(ctx)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	t.peer = from
+
+	// Hellos the dialer retries before it sees this are ignored in Recv.
+	if _, err := conn.WriteTo(nil, t.peer); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *UDP) readFrom(ctx context.Context) (int, net.Addr, error) {
+	var n int
+	var from net.Addr
+	err := withContext(ctx, t.conn.SetReadDeadline, func() error {
+		var err error
+		n, from, err = t.conn.ReadFrom(t.buf)
+		return err
+	})
+	return n, from, err
+}
+
+func (t *UDP) Send(ctx context.Context, buf []byte) error {
+	return withContext(ctx, t.conn.SetWriteDeadline, func() error {
+		_, err := t.conn.WriteTo(buf, t.peer)
+		return err
+	})
+}
+
+func (t *UDP) Recv(ctx context.Context) ([]byte, error) {
+	t.rMu.Lock()
+	defer t.rMu.Unlock()
+
+	if t.pending != nil {
+		buf := t.pending
+		t.pending = nil
+		return buf, nil
+	}
+
+	for {
+		n, from, err := t.readFrom(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 || from.String() != t.peer.String() {
+			continue
+		}
+		return append([]byte(nil), t.buf[:n]...), nil
+	}
+}
+
+func (t *UDP) Close() error {
+	return t.conn.Close()
+}